@@ -20,6 +20,14 @@ func PublicApi(e *echo.Echo) {
 	pb.POST("/user/forgot/search", controller.ForgotSearch)
 	pb.POST("/user/forgot/validate", controller.ForgotValidate)
 	pb.POST("/user/forgot/change", controller.ForgotChange)
+
+	// Supplier portal, authenticated by the link of the provider
+	pb.POST("/portal/requirement", controller.GetPortalRequirement)
+	pb.POST("/portal/quotation", controller.CreatePortalQuotation)
+	pb.POST("/portal/decline", controller.DeclinePortalRequirement)
+
+	// Signed links of the files, authenticated by the signature
+	pb.GET("/files/*", controller.DownloadFile)
 }
 
 // ProtectedApi protected api token jwt
@@ -33,6 +41,13 @@ func ProtectedApi(e *echo.Echo) {
 	}
 	ar.Use(middleware.JWTWithConfig(con))
 
+	// Server-sent events, the short lived stream token goes in the query
+	stream := con
+	stream.SigningKey = utilities.StreamKey()
+	stream.TokenLookup = "query:token"
+	e.GET("/api/v1/stream", controller.Stream, middleware.JWTWithConfig(stream))
+	ar.POST("/stream/token", controller.StreamToken)
+
 	// Crud user
 	ar.POST("/user/all", controller.GetUsers)
 	ar.POST("/user/byid", controller.GetUserByID)
@@ -40,8 +55,10 @@ func ProtectedApi(e *echo.Echo) {
 	ar.PUT("/user", controller.UpdateUser)
 	ar.DELETE("/user", controller.DeleteUser)
 	ar.POST("/user/upload/avatar", controller.UploadAvatarUser)
+	ar.GET("/user/download/avatar", controller.DownloadAvatarUser)
 	ar.POST("/user/reset/password", controller.ResetPasswordUser)
 	ar.POST("/user/change/password", controller.ChangePasswordUser)
+	ar.PUT("/user/locale", controller.ChangeLocaleUser)
 
 	// Crud Product
 	ar.POST("/product/all", controller.GetProducts)
@@ -51,6 +68,13 @@ func ProtectedApi(e *echo.Echo) {
 	ar.DELETE("/product", controller.DeleteProduct)
 	ar.POST("/product/search", controller.GetProductSearch)
 
+	// Categories of the products
+	ar.POST("/category/all", controller.GetCategories)
+	ar.POST("/category/byid", controller.GetCategoryByID)
+	ar.POST("/category", controller.CreateCategory)
+	ar.PUT("/category", controller.UpdateCategory)
+	ar.DELETE("/category", controller.DeleteCategory)
+
 	// Crud Provider
 	ar.POST("/provider/all", controller.GetProviders)
 	ar.POST("/provider/byid", controller.GetProviderByID)
@@ -61,6 +85,8 @@ func ProtectedApi(e *echo.Echo) {
 	ar.POST("/provider/validate/ruc", controller.ValidateRucProvider)
 	ar.GET("/provider/download/template", controller.GetTempUploadProvider)
 	ar.POST("/provider/upload/template", controller.SetTempUploadProvider)
+	ar.POST("/provider/catalog", controller.GetProviderCatalog)
+	ar.PUT("/provider/catalog", controller.SaveProviderCatalog)
 
 	// Crud Requirement
 	ar.POST("/requirement/all", controller.GetRequirements)
@@ -70,6 +96,19 @@ func ProtectedApi(e *echo.Echo) {
 	ar.DELETE("/requirement", controller.DeleteRequirement)
 	ar.PUT("/requirement/set/rejected", controller.SetRejectedRequirement)
 	ar.PUT("/requirement/set/closed", controller.SetClosedRequirement)
+	ar.PUT("/requirement/open/bids", controller.OpenBidsRequirement)
+	ar.POST("/requirement/clone", controller.CloneRequirement)
+	ar.POST("/requirement/activity", controller.GetRequirementActivity)
+	ar.POST("/requirement/providers", controller.GetSuggestedProviders)
+
+	// Requirement templates
+	ar.POST("/template/all", controller.GetTemplates)
+	ar.POST("/template/byid", controller.GetTemplateByID)
+	ar.POST("/template", controller.CreateTemplate)
+	ar.PUT("/template", controller.UpdateTemplate)
+	ar.DELETE("/template", controller.DeleteTemplate)
+	ar.POST("/template/from/requirement", controller.SaveRequirementTemplate)
+	ar.POST("/template/requirement", controller.CreateRequirementFromTemplate)
 
 	// Crud Require
 	ar.POST("/require/by/requirement", controller.GetRequireByRequirement)
@@ -83,9 +122,63 @@ func ProtectedApi(e *echo.Echo) {
 	ar.PUT("/quotation", controller.UpdateQuotation)
 	ar.DELETE("/quotation", controller.DeleteQuotation)
 	ar.PUT("/quotation/set/winner", controller.SetWinnerQuotation)
+	ar.PUT("/quotation/approve", controller.ApproveQuotation)
+	ar.POST("/quotation/revisions", controller.GetQuotationRevisions)
+	ar.POST("/quotation/revisions/diff", controller.DiffQuotationRevisions)
+
+	// Requests for quotation
+	ar.POST("/rfq", controller.SendRfq)
+	ar.POST("/rfq/all", controller.GetRfqs)
+	ar.GET("/rfq/download", controller.DownloadRfq)
 	ar.POST("/quotation/comparativeTable", controller.ComparativeTable)
 	ar.POST("/quotation/purchaseOrder", controller.PurchaseOrder)
 
+	// Exchange rates
+	ar.POST("/exchange/rate/all", controller.GetExchangeRates)
+	ar.POST("/exchange/rate", controller.SaveExchangeRate)
+	ar.DELETE("/exchange/rate", controller.DeleteExchangeRate)
+	ar.POST("/exchange/rate/upload", controller.UploadExchangeRates)
+
+	// Cost centers and budgets
+	ar.POST("/cost/center/all", controller.GetCostCenters)
+	ar.POST("/cost/center/byid", controller.GetCostCenterByID)
+	ar.POST("/cost/center", controller.CreateCostCenter)
+	ar.PUT("/cost/center", controller.UpdateCostCenter)
+	ar.DELETE("/cost/center", controller.DeleteCostCenter)
+	ar.POST("/cost/center/budget", controller.SaveBudget)
+	ar.POST("/cost/center/execution", controller.BudgetExecution)
+
+	// Attachments of requirements, quotations and providers
+	ar.POST("/attachment/all", controller.GetAttachments)
+	ar.POST("/attachment/upload", controller.UploadAttachment)
+	ar.GET("/attachment/download", controller.DownloadAttachment)
+	ar.POST("/attachment/link", controller.AttachmentLink)
+	ar.DELETE("/attachment", controller.DeleteAttachment)
+
+	// Comments of requirements and quotations
+	ar.POST("/comment/all", controller.GetComments)
+	ar.POST("/comment", controller.CreateComment)
+	ar.PUT("/comment", controller.UpdateComment)
+	ar.DELETE("/comment", controller.DeleteComment)
+
+	// Notifications of the user
+	ar.POST("/notification/all", controller.GetNotifications)
+	ar.PUT("/notification/read", controller.ReadNotifications)
+	ar.GET("/notification/preferences", controller.GetNotificationPreferences)
+	ar.PUT("/notification/preferences", controller.SaveNotificationPreferences)
+
+	// Webhooks of the external systems, only for the administrators
+	ar.POST("/webhook/all", controller.GetWebhooks)
+	ar.POST("/webhook/byid", controller.GetWebhookByID)
+	ar.POST("/webhook", controller.CreateWebhook)
+	ar.PUT("/webhook", controller.UpdateWebhook)
+	ar.DELETE("/webhook", controller.DeleteWebhook)
+	ar.POST("/webhook/deliveries", controller.GetWebhookDeliveries)
+	ar.POST("/webhook/redeliver", controller.RedeliverWebhook)
+
+	// Outbox of the emails, only for the administrators
+	ar.POST("/email/all", controller.GetEmails)
+
 	// Global settings
 	ar.POST("/setting/global", controller.GetGlobalSettings)
 	ar.GET("/setting", controller.GetSetting)
@@ -95,10 +188,12 @@ func ProtectedApi(e *echo.Echo) {
 
 	// Statistic
 	ar.POST("/statistic/top/provider/winners", controller.TopProviderWinner)
+	ar.POST("/statistic/top/provider/amounts", controller.TopProviderAmount)
 	ar.POST("/statistic/top/users", controller.TopUsers)
 	ar.POST("/statistic/top/products", controller.TopProducts)
 	ar.POST("/statistic/top/requirements", controller.TopRequirements)
 
 	// Reporting EXCEL generate and Download
 	ar.GET("/download/requirement/all", controller.ExportRequirementAll)
+	ar.GET("/download/quotation/awarded", controller.ExportAwardedAll)
 }
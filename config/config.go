@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"sync"
 )
 
 // Config models
+// Database driver = postgres | sqlite3, empty is postgres
 type Database struct {
+	Driver   string
 	Server   string
 	Port     string
 	User     string
@@ -15,39 +18,83 @@ type Database struct {
 	Database string
 }
 
+// Email sender of the emails, server = host:port of the SMTP server and host = name in its certificate
+// security = tls | starttls | none, empty is tls for the port 465 and starttls for the others
+// username of the authentication, by default from. dev = directory where the emails are written
+// instead of sending them, for development
 type Email struct {
 	Name     string
 	From     string
+	Username string
 	Password string
 	Server   string
 	Host     string
+	Security string
+	Dev      string
 }
 
 type Config struct {
 	Database Database
 	Email    Email
 	Server   Server
+	Log      Log
+	Upload   Upload
+	Storage  Storage
 }
 
+// Server portal = url of the page where the providers quote, the links sent add ?token=
 type Server struct {
-	Port string
-	Key  string
+	Port   string
+	Key    string
+	Portal string
 }
 
+// Upload max size of a attached file in MB, by default 10 MB
+type Upload struct {
+	MaxSize int64
+}
+
+// Storage where the uploaded files are saved, driver = local | s3, empty is local
+// local: directory, by default "uploads"
+// s3:    endpoint, region, bucket, access and secret key of a S3 compatible service as MinIO
+// The download links expire after the minutes, by default 15
+type Storage struct {
+	Driver    string
+	Directory string
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Expires   int
+}
+
+// Log level = debug | info | warn | error, debug also prints the SQL statements
+type Log struct {
+	Level string
+}
+
+var (
+	cfg     Config
+	cfgOnce sync.Once
+)
+
 // GetConfig return configuration from database json
+// the file is read only once, on the first call at the start of the application
 func GetConfig() Config {
-	var c Config
-
-	file, err := os.Open("./config/config.json")
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer file.Close()
+	cfgOnce.Do(func() {
+		file, err := os.Open("./config/config.json")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
 
-	err = json.NewDecoder(file).Decode(&c)
-	if err != nil {
-		log.Fatal(err)
-	}
+		err = json.NewDecoder(file).Decode(&cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	})
 
-	return c
+	return cfg
 }
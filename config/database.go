@@ -4,23 +4,65 @@ import (
 	"fmt"
 	"github.com/jinzhu/gorm"
 	_ "github.com/lib/pq"
-	"log"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/paulantezana/requirement/logger"
 	"os"
 )
 
-// GetConnection get connection database
-func GetConnection() *gorm.DB {
-	c := GetConfig()
+// Database drivers supported
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
 
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", c.Database.User, c.Database.Pass, c.Database.Server, c.Database.Port, c.Database.Database)
+// GetConnection get connection database
+func GetConnection() (*gorm.DB, error) {
+	driver, dsn, err := dataSource(GetConfig().Database)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := gorm.Open("postgres", dsn)
+	db, err := gorm.Open(driver, dsn)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	db.SetLogger(logger.Default().SQL())
+	db.LogMode(true)
+
+	// SQLite allows only one writer, the connection waits in busy_timeout
+	if driver == DriverSQLite {
+		db.DB().SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+// dataSource driver and data source name of the database
+// the environment variables DATABASE_DRIVER and DATABASE_URL take precedence over the config file
+// postgres: server, port, user, pass and database
+// sqlite3:  database is the path of the file
+func dataSource(c Database) (string, string, error) {
+	driver := c.Driver
+	if env := os.Getenv("DATABASE_DRIVER"); env != "" {
+		driver = env
+	}
+	if driver == "" {
+		driver = DriverPostgres
+	}
+	dsn := os.Getenv("DATABASE_URL")
+
+	switch driver {
+	case DriverPostgres:
+		if dsn == "" {
+			dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", c.User, c.Pass, c.Server, c.Port, c.Database)
+		}
+	case DriverSQLite:
+		if dsn == "" {
+			dsn = fmt.Sprintf("file:%s?_busy_timeout=5000", c.Database)
+		}
+	default:
+		return "", "", fmt.Errorf("config: database driver %q not supported", driver)
 	}
 
-	return db
+	return driver, dsn, nil
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDataSource(t *testing.T) {
+	os.Unsetenv("DATABASE_DRIVER")
+	os.Unsetenv("DATABASE_URL")
+
+	tests := []struct {
+		db     Database
+		driver string
+		dsn    string
+	}{
+		{Database{Server: "localhost", Port: "5432", User: "u", Pass: "p", Database: "requirement"}, DriverPostgres, "postgres://u:p@localhost:5432/requirement?sslmode=disable"},
+		{Database{Driver: DriverSQLite, Database: "requirement.db"}, DriverSQLite, "file:requirement.db?_busy_timeout=5000"},
+	}
+	for _, tt := range tests {
+		driver, dsn, err := dataSource(tt.db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if driver != tt.driver || dsn != tt.dsn {
+			t.Errorf("expected %s %s, got %s %s", tt.driver, tt.dsn, driver, dsn)
+		}
+	}
+
+	if _, _, err := dataSource(Database{Driver: "mysql"}); err == nil {
+		t.Error("expected error for a driver not supported")
+	}
+
+	os.Setenv("DATABASE_DRIVER", DriverSQLite)
+	os.Setenv("DATABASE_URL", "file::memory:")
+	defer os.Unsetenv("DATABASE_DRIVER")
+	defer os.Unsetenv("DATABASE_URL")
+	if driver, dsn, _ := dataSource(Database{}); driver != DriverSQLite || dsn != "file::memory:" {
+		t.Errorf("expected the environment, got %s %s", driver, dsn)
+	}
+}
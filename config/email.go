@@ -1,87 +1,71 @@
 package config
 
 import (
-	"crypto/tls"
 	"fmt"
+	"net"
 	"net/mail"
-	"net/smtp"
-)
-
-// SendEmail using gmail server nmtp
-func SendEmail(to string, subject string, tem string) error {
-	c := GetConfig()
+	"os"
+	"strings"
 
-	from := mail.Address{c.Email.Name, c.Email.From}
-	toMail := mail.Address{"", to}
-
-	headers := make(map[string]string)
-	headers["From"] = from.String()
-	headers["To"] = toMail.String()
-	headers["Subject"] = subject
-	headers["Content-Type"] = `text/html; charset="UTF-8"`
+	"github.com/paulantezana/requirement/mailer"
+)
 
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += tem
+// EmailTemplates directory of the named templates of the emails, with a directory by language
+const EmailTemplates = "templates/email"
 
-	// authentication
-	auth := smtp.PlainAuth("", c.Email.From, c.Email.Password, c.Email.Host)
+// GetMailer get the sender of the emails
+func GetMailer() (mailer.Sender, error) {
+	return newMailer(emailConfig(GetConfig().Email))
+}
 
-	// Config tls security
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		ServerName:         c.Email.Host,
+// emailConfig the environment variables SMTP_SERVER, SMTP_HOST, SMTP_USERNAME, SMTP_PASSWORD,
+// SMTP_SECURITY and EMAIL_DEV take precedence over the config file
+func emailConfig(c Email) Email {
+	env := map[string]*string{
+		"SMTP_SERVER":   &c.Server,
+		"SMTP_HOST":     &c.Host,
+		"SMTP_USERNAME": &c.Username,
+		"SMTP_PASSWORD": &c.Password,
+		"SMTP_SECURITY": &c.Security,
+		"EMAIL_DEV":     &c.Dev,
 	}
-	conn, err := tls.Dial("tcp", c.Email.Server, tlsConfig)
-	if err != nil {
-		return err
-	}
-
-	// ---------------------------------------------------------------------------
-	//  create new client
-	client, err := smtp.NewClient(conn, c.Email.Host)
-	if err != nil {
-		return err
+	for name, field := range env {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
 	}
-
-	// Authenticate
-	err = client.Auth(auth)
-	if err != nil {
-		return err
+	if c.Host == "" {
+		c.Host, _, _ = net.SplitHostPort(c.Server)
 	}
-
-	// Set From email
-	err = client.Mail(from.Address)
-	if err != nil {
-		return err
+	if c.Security == "" {
+		c.Security = mailer.SecurityStartTLS
+		if strings.HasSuffix(c.Server, ":465") {
+			c.Security = mailer.SecurityTLS
+		}
 	}
-
-	// Set to email recipient
-	err = client.Rcpt(toMail.Address)
-	if err != nil {
-		return err
+	// A local catcher does not authenticate
+	if c.Username == "" && c.Security != mailer.SecurityNone {
+		c.Username = c.From
 	}
+	return c
+}
 
-	// Process data send
-	w, err := client.Data()
-	if err != nil {
-		return err
+// newMailer sender of the config, the directory of development when it is set
+func newMailer(c Email) (mailer.Sender, error) {
+	from := mail.Address{Name: c.Name, Address: c.From}
+	if c.Dev != "" {
+		return mailer.Dir{From: from, Path: c.Dev}, nil
 	}
-	_, err = w.Write([]byte(message))
-	if err != nil {
-		return err
+	switch c.Security {
+	case mailer.SecurityTLS, mailer.SecurityStartTLS, mailer.SecurityNone:
+		return &mailer.SMTP{
+			From:     from,
+			Server:   c.Server,
+			Host:     c.Host,
+			Username: c.Username,
+			Password: c.Password,
+			Security: c.Security,
+		}, nil
 	}
-
-	// Close client
-	err = w.Close()
-	if err != nil {
-		return err
-	}
-
-	// Exit client
-	client.Quit()
-
-	return nil
+	return nil, fmt.Errorf("email security %q not supported", c.Security)
 }
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/paulantezana/requirement/mailer"
+)
+
+func TestMailer(t *testing.T) {
+	for _, name := range []string{"SMTP_SERVER", "SMTP_HOST", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_SECURITY", "EMAIL_DEV"} {
+		os.Unsetenv(name)
+	}
+
+	c := emailConfig(Email{From: "rw@example.com", Server: "smtp.example.com:465"})
+	if c.Host != "smtp.example.com" || c.Security != mailer.SecurityTLS || c.Username != "rw@example.com" {
+		t.Errorf("unexpected defaults %+v", c)
+	}
+	if c := emailConfig(Email{Server: "smtp.example.com:587"}); c.Security != mailer.SecurityStartTLS {
+		t.Errorf("expected starttls, got %q", c.Security)
+	}
+	if s, err := newMailer(c); err != nil {
+		t.Fatal(err)
+	} else if _, ok := s.(*mailer.SMTP); !ok {
+		t.Errorf("expected smtp sender, got %T", s)
+	}
+
+	os.Setenv("EMAIL_DEV", "temp/emails")
+	defer os.Unsetenv("EMAIL_DEV")
+	if s, err := newMailer(emailConfig(Email{})); err != nil {
+		t.Fatal(err)
+	} else if _, ok := s.(mailer.Dir); !ok {
+		t.Errorf("expected dev sender, got %T", s)
+	}
+
+	if _, err := newMailer(Email{Security: "ssl"}); err == nil {
+		t.Error("expected error for a security not supported")
+	}
+}
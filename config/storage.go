@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paulantezana/requirement/storage"
+)
+
+// Storage drivers supported
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// FilesURL path of the api that serves the links of the local storage
+const FilesURL = "/api/v1/files"
+
+// GetStorage get the storage of the uploaded files
+func GetStorage() (storage.Storage, error) {
+	c := GetConfig()
+	return newStorage(storageConfig(c.Storage), []byte(c.Server.Key))
+}
+
+// storageConfig the environment variables STORAGE_DRIVER, STORAGE_DIRECTORY, S3_ENDPOINT, S3_REGION,
+// S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY take precedence over the config file
+func storageConfig(c Storage) Storage {
+	env := map[string]*string{
+		"STORAGE_DRIVER":    &c.Driver,
+		"STORAGE_DIRECTORY": &c.Directory,
+		"S3_ENDPOINT":       &c.Endpoint,
+		"S3_REGION":         &c.Region,
+		"S3_BUCKET":         &c.Bucket,
+		"S3_ACCESS_KEY":     &c.AccessKey,
+		"S3_SECRET_KEY":     &c.SecretKey,
+	}
+	for name, field := range env {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+	if c.Driver == "" {
+		c.Driver = StorageLocal
+	}
+	if c.Directory == "" {
+		c.Directory = "uploads"
+	}
+	if c.Expires <= 0 {
+		c.Expires = 15
+	}
+	return c
+}
+
+// newStorage storage of the driver, the links of the local storage are signed with the key
+func newStorage(c Storage, key []byte) (storage.Storage, error) {
+	expires := time.Duration(c.Expires) * time.Minute
+	switch c.Driver {
+	case StorageLocal:
+		return storage.NewLocal(c.Directory, FilesURL, key, expires), nil
+	case StorageS3:
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  c.Endpoint,
+			Region:    c.Region,
+			Bucket:    c.Bucket,
+			AccessKey: c.AccessKey,
+			SecretKey: c.SecretKey,
+			PathStyle: c.PathStyle,
+			Expires:   expires,
+		})
+	}
+	return nil, fmt.Errorf("storage driver %q not supported", c.Driver)
+}
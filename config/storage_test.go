@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/paulantezana/requirement/storage"
+)
+
+func TestStorage(t *testing.T) {
+	for _, name := range []string{"STORAGE_DRIVER", "STORAGE_DIRECTORY", "S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY", "S3_SECRET_KEY"} {
+		os.Unsetenv(name)
+	}
+
+	c := storageConfig(Storage{})
+	if c.Driver != StorageLocal || c.Directory != "uploads" || c.Expires != 15 {
+		t.Errorf("unexpected defaults %+v", c)
+	}
+	if s, err := newStorage(c, nil); err != nil {
+		t.Fatal(err)
+	} else if _, ok := s.(*storage.Local); !ok {
+		t.Errorf("expected local storage, got %T", s)
+	}
+
+	os.Setenv("STORAGE_DRIVER", StorageS3)
+	os.Setenv("S3_BUCKET", "requirement")
+	defer os.Unsetenv("STORAGE_DRIVER")
+	defer os.Unsetenv("S3_BUCKET")
+	c = storageConfig(Storage{Endpoint: "http://localhost:9000", PathStyle: true})
+	if s, err := newStorage(c, nil); err != nil {
+		t.Fatal(err)
+	} else if _, ok := s.(*storage.S3); !ok {
+		t.Errorf("expected s3 storage, got %T", s)
+	}
+
+	if _, err := newStorage(Storage{Driver: "ftp"}, nil); err == nil {
+		t.Error("expected error for a driver not supported")
+	}
+}
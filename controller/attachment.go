@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"strconv"
+)
+
+func GetAttachments(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestAttachment{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
+		return err
+	}
+
+	// Execute instructions
+	attachments, err := service.NewAttachmentService(repository.NewStore(db), files).List(request.Owner, request.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    attachments,
+	})
+}
+
+// UploadAttachment attach the file of the form to a requirement, a quotation or a provider
+func UploadAttachment(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Read form fields
+	ownerID, _ := strconv.Atoi(c.FormValue("owner_id"))
+	attachment := models.Attachment{
+		Owner:   c.FormValue("owner"),
+		OwnerID: uint(ownerID),
+	}
+
+	// Source
+	file, err := c.FormFile("file")
+	if err == http.ErrMissingFile {
+		return utilities.NewValidationError(utilities.FieldError{Field: "file", Code: utilities.FieldRequired})
+	}
+	if err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	attachment.Name = file.Filename
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
+		return err
+	}
+
+	// Save file and attachment in database
+	if err := service.NewAttachmentService(repository.NewStore(db), files).Upload(currentUser.ID, &attachment, src); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    attachment,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgAttachmentSaved, attachment.Name),
+	})
+}
+
+func DownloadAttachment(c echo.Context) error {
+	// Get data request
+	id, _ := strconv.Atoi(c.QueryParam("id"))
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
+		return err
+	}
+
+	// Open content
+	attachment, content, err := service.NewAttachmentService(repository.NewStore(db), files).Open(uint(id))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, storage.Disposition(attachment.Name))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(attachment.Size, 10))
+	return c.Stream(http.StatusOK, attachment.ContentType, content)
+}
+
+// AttachmentLink signed link to download the attachment without the token, it expires
+func AttachmentLink(c echo.Context) error {
+	// Get data request
+	attachment := models.Attachment{}
+	if err := c.Bind(&attachment); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
+		return err
+	}
+
+	// Sign link
+	link, err := service.NewAttachmentService(repository.NewStore(db), files).Link(attachment.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    link,
+	})
+}
+
+func DeleteAttachment(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	attachment := models.Attachment{}
+	if err := c.Bind(&attachment); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
+		return err
+	}
+
+	// Delete attachment and its file
+	if err := service.NewAttachmentService(repository.NewStore(db), files).Delete(currentUser, attachment.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    attachment.ID,
+	})
+}
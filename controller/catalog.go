@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+// GetProviderCatalog categories and products sold by the provider with the id
+func GetProviderCatalog(c echo.Context) error {
+	// Get data request
+	provider := models.Provider{}
+	if err := c.Bind(&provider); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	catalog, err := service.NewCatalogService(repository.NewStore(db)).Get(provider.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    catalog,
+	})
+}
+
+// SaveProviderCatalog replace the categories and products sold by the provider
+func SaveProviderCatalog(c echo.Context) error {
+	// Get data request
+	catalog := service.ProviderCatalog{}
+	if err := c.Bind(&catalog); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	if err := service.NewCatalogService(repository.NewStore(db)).Save(&catalog); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    catalog.ProviderID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgCatalogSaved),
+	})
+}
+
+// GetSuggestedProviders providers to ask for quotations of the requirement with the id, first
+// the ones that sell more of its products and win more
+func GetSuggestedProviders(c echo.Context) error {
+	// Get data request
+	requirement := models.Requirement{}
+	if err := c.Bind(&requirement); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	suggested, err := service.NewCatalogService(repository.NewStore(db)).Suggest(requirement.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    suggested,
+	})
+}
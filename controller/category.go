@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+func GetCategories(c echo.Context) error {
+	// Get data request
+	request := utilities.Request{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	categories, total, err := service.NewCategoryService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        categories,
+		Total:       total,
+		CurrentPage: request.CurrentPage,
+	})
+}
+
+func GetCategoryByID(c echo.Context) error {
+	// Get data request
+	category := models.Category{}
+	if err := c.Bind(&category); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	category, err = service.NewCategoryService(repository.NewStore(db)).Get(category.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    category,
+	})
+}
+
+func CreateCategory(c echo.Context) error {
+	// Get data request
+	category := models.Category{}
+	if err := c.Bind(&category); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert category in database
+	if err := service.NewCategoryService(repository.NewStore(db)).Create(&category); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    category.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgCategoryCreated, category.Name),
+	})
+}
+
+func UpdateCategory(c echo.Context) error {
+	// Get data request
+	category := models.Category{}
+	if err := c.Bind(&category); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Update category in database
+	if err := service.NewCategoryService(repository.NewStore(db)).Update(&category); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    category.ID,
+	})
+}
+
+func DeleteCategory(c echo.Context) error {
+	// Get data request
+	category := models.Category{}
+	if err := c.Bind(&category); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete category in database
+	if err := service.NewCategoryService(repository.NewStore(db)).Delete(category.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    category.ID,
+	})
+}
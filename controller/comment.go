@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+// notifyMentions notify the comment to the mentioned users
+func notifyMentions(c echo.Context, db *gorm.DB, author models.User, comment models.Comment, users []models.User) {
+	if len(users) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{
+		Type:          models.EventCommentMention,
+		ActorID:       author.ID,
+		RequirementID: comment.RequirementID,
+		CommentID:     comment.ID,
+		Recipients:    ids,
+		Detail:        comment.Body,
+	})
+}
+
+func GetComments(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestComment{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	comments, err := service.NewCommentService(repository.NewStore(db)).List(request.Owner, request.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    comments,
+	})
+}
+
+func CreateComment(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	comment := models.Comment{}
+	if err := c.Bind(&comment); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert comment in database
+	mentioned, err := service.NewCommentService(repository.NewStore(db)).Create(currentUser, &comment)
+	if err != nil {
+		return err
+	}
+	notifyMentions(c, db, currentUser, comment, mentioned)
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    comment,
+	})
+}
+
+func UpdateComment(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	comment := models.Comment{}
+	if err := c.Bind(&comment); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Update comment in database
+	mentioned, err := service.NewCommentService(repository.NewStore(db)).Update(currentUser, &comment)
+	if err != nil {
+		return err
+	}
+	notifyMentions(c, db, currentUser, comment, mentioned)
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    comment,
+	})
+}
+
+func DeleteComment(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	comment := models.Comment{}
+	if err := c.Bind(&comment); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete comment in database
+	if err := service.NewCommentService(repository.NewStore(db)).Delete(currentUser, comment.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    comment.ID,
+	})
+}
+
+// GetRequirementActivity comments of the requirement and its quotations with the changes of its state
+func GetRequirementActivity(c echo.Context) error {
+	// Get data request
+	requirement := models.Requirement{}
+	if err := c.Bind(&requirement); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	activity, err := service.NewCommentService(repository.NewStore(db)).Activity(requirement.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    activity,
+	})
+}
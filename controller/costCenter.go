@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"time"
+)
+
+func GetCostCenters(c echo.Context) error {
+	// Get data request
+	request := utilities.Request{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	costCenters, total, err := service.NewCostCenterService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        costCenters,
+		Total:       total,
+		CurrentPage: request.CurrentPage,
+	})
+}
+
+func GetCostCenterByID(c echo.Context) error {
+	// Get data request
+	costCenter := models.CostCenter{}
+	if err := c.Bind(&costCenter); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	costCenter, err = service.NewCostCenterService(repository.NewStore(db)).Get(costCenter.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    costCenter,
+	})
+}
+
+func CreateCostCenter(c echo.Context) error {
+	// Get data request
+	costCenter := models.CostCenter{}
+	if err := c.Bind(&costCenter); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert cost center in database
+	if err := service.NewCostCenterService(repository.NewStore(db)).Create(&costCenter); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    costCenter.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgCostCenterCreated, costCenter.Name),
+	})
+}
+
+func UpdateCostCenter(c echo.Context) error {
+	// Get data request
+	costCenter := models.CostCenter{}
+	if err := c.Bind(&costCenter); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Update cost center in database
+	if err := service.NewCostCenterService(repository.NewStore(db)).Update(&costCenter); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    costCenter.ID,
+	})
+}
+
+func DeleteCostCenter(c echo.Context) error {
+	// Get data request
+	costCenter := models.CostCenter{}
+	if err := c.Bind(&costCenter); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete cost center in database
+	if err := service.NewCostCenterService(repository.NewStore(db)).Delete(costCenter.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    costCenter.ID,
+	})
+}
+
+// SaveBudget create the budget or replace the budget of the cost center in the year
+func SaveBudget(c echo.Context) error {
+	// Get data request
+	budget := models.Budget{}
+	if err := c.Bind(&budget); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Save budget in database
+	if err := service.NewCostCenterService(repository.NewStore(db)).SaveBudget(&budget); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    budget.ID,
+	})
+}
+
+// BudgetExecution budget, committed and consumed amounts by cost center and month of a year
+func BudgetExecution(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestBudget{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if request.Year == 0 {
+		request.Year = time.Now().Year()
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	execution, err := service.NewCostCenterService(repository.NewStore(db)).Execution(request.Year)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    execution,
+	})
+}
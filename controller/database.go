@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// getConnection get connection database whose SQL logs carry the request id
+func getConnection(c echo.Context) (*gorm.DB, error) {
+	l := logger.FromContext(c)
+
+	db, err := config.GetConnection()
+	if err != nil {
+		l.WithError(err).Errorf("database connection failed")
+		return nil, err
+	}
+	db.SetLogger(l.SQL())
+
+	return db, nil
+}
+
+// newPage pagination of the request, the first page by default
+func newPage(request *utilities.Request) repository.Page {
+	if request.CurrentPage == 0 {
+		request.CurrentPage = 1
+	}
+	return repository.Page{
+		Search: request.Search,
+		Offset: request.Limit*request.CurrentPage - request.Limit,
+		Limit:  request.Limit,
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/mailer"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// queueEmail render the named template of templates/email in the language and save the email in
+// the outbox with the request id of the logger l
+func queueEmail(l *logger.Logger, store repository.Store, lang string, to string, name string, data interface{}, attachments ...mailer.Attachment) (uint, error) {
+	message, err := mailer.Templates{Dir: filepath.Join(config.EmailTemplates, lang)}.Render(name, data)
+	if err != nil {
+		return 0, err
+	}
+	email := models.Email{
+		Recipient: to,
+		Subject:   message.Subject,
+		Template:  name,
+		RequestID: l.RequestID(),
+		Text:      message.Text,
+		HTML:      message.HTML,
+	}
+	for _, a := range attachments {
+		email.Attachments = append(email.Attachments, models.EmailAttachment{Name: a.Name, ContentType: a.ContentType, Data: a.Data})
+	}
+	if err := service.NewOutboxService(store, nil).Queue(&email, time.Now()); err != nil {
+		return 0, err
+	}
+	return email.ID, nil
+}
+
+// sendEmails send the emails of the outbox in the background with its own connection, the
+// emails not sent are retried by the scheduler
+func sendEmails(l *logger.Logger, ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		sender, err := config.GetMailer()
+		if err != nil {
+			l.WithError(err).Errorf("emails not sent")
+			return
+		}
+		db, err := config.GetConnection()
+		if err != nil {
+			l.WithError(err).Errorf("emails not sent")
+			return
+		}
+		defer db.Close()
+		if err := service.NewOutboxService(repository.NewStore(db), sender).Send(time.Now(), ids...); err != nil {
+			l.WithError(err).Errorf("emails not sent")
+		}
+	}()
+}
+
+// GetEmails page of the outbox, the emails pending, sent and failed with the last error
+func GetEmails(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestEmail{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	page := utilities.Request{Search: request.Search, CurrentPage: request.CurrentPage, Limit: request.Limit}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	emails, total, err := service.NewOutboxService(repository.NewStore(db), nil).List(currentUser, request.State, newPage(&page))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        emails,
+		Total:       total,
+		CurrentPage: page.CurrentPage,
+	})
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+func GetExchangeRates(c echo.Context) error {
+	// Get data request
+	request := utilities.Request{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	rates, total, err := service.NewExchangeRateService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        rates,
+		Total:       total,
+		CurrentPage: request.CurrentPage,
+	})
+}
+
+// SaveExchangeRate create the rate or replace the rate of the currency on the date
+func SaveExchangeRate(c echo.Context) error {
+	// Get data request
+	rate := models.ExchangeRate{}
+	if err := c.Bind(&rate); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Save rate in database
+	if err := service.NewExchangeRateService(repository.NewStore(db)).Save(&rate); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    rate.ID,
+	})
+}
+
+func DeleteExchangeRate(c echo.Context) error {
+	// Get data request
+	rate := models.ExchangeRate{}
+	if err := c.Bind(&rate); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete rate in database
+	if err := service.NewExchangeRateService(repository.NewStore(db)).Delete(rate.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    rate.ID,
+	})
+}
+
+// UploadExchangeRates import the rates of a CSV file: date (YYYY-MM-DD), currency, rate
+func UploadExchangeRates(c echo.Context) error {
+	// Source
+	file, err := c.FormFile("file")
+	if err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Read rates of the file
+	rates, err := service.ParseRates(src)
+	if err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Save rates in database
+	if err := service.NewExchangeRateService(repository.NewStore(db)).Import(rates); err != nil {
+		return err
+	}
+
+	// Response success
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgRatesSaved, len(rates)),
+	})
+}
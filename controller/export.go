@@ -1,16 +1,28 @@
 package controller
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
 )
 
 func ExportRequirementAll(c echo.Context) error {
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query get config app
@@ -18,22 +30,22 @@ func ExportRequirementAll(c echo.Context) error {
 	db.First(&con)
 
 	// Create new BOOK EXCEL
+	lang := utilities.Language(c)
 	xlsx := excelize.NewFile()
 
-	err := xlsx.AddPicture("Sheet1", "B2", "./static/logo.png", `{"x_scale": 0.5, "y_scale": 0.5}`)
-	if err != nil {
+	if err := addLogo(xlsx, con.Logo); err != nil {
 		return err
 	}
 	xlsx.SetCellValue("Sheet1", "A5", con.CompanyName)
 	xlsx.SetCellValue("Sheet1", "A6", con.City)
-	xlsx.SetCellValue("Sheet1", "A8", "Requerimiento")
+	xlsx.SetCellValue("Sheet1", "A8", utilities.Message(lang, utilities.XlsxRequirements))
 
 	//SET HEADER TABLE
-	xlsx.SetCellValue("Sheet1", "A10", "Requerimiento")
-	xlsx.SetCellValue("Sheet1", "B10", "Lugar")
-	xlsx.SetCellValue("Sheet1", "C10", "Destino")
-	xlsx.SetCellValue("Sheet1", "D10", "Fecha Emision")
-	xlsx.SetCellValue("Sheet1", "E10", "Estado")
+	xlsx.SetCellValue("Sheet1", "A10", utilities.Message(lang, utilities.XlsxRequirement))
+	xlsx.SetCellValue("Sheet1", "B10", utilities.Message(lang, utilities.XlsxPlace))
+	xlsx.SetCellValue("Sheet1", "C10", utilities.Message(lang, utilities.XlsxDestination))
+	xlsx.SetCellValue("Sheet1", "D10", utilities.Message(lang, utilities.XlsxEmissionDate))
+	xlsx.SetCellValue("Sheet1", "E10", utilities.Message(lang, utilities.XlsxState))
 
 	// Get all requirements
 	requirements := make([]models.Requirement, 0)
@@ -48,12 +60,97 @@ func ExportRequirementAll(c echo.Context) error {
 		xlsx.SetCellValue("Sheet1", fmt.Sprintf("E%d", currentRow+k), rq.State)
 	}
 
-	fileAddress := "templates/requerimeinto.xlsx"
+	buf := new(bytes.Buffer)
+	if err := xlsx.Write(buf); err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, storage.Disposition(utilities.Message(lang, utilities.FileRequirements)))
+	return c.Blob(http.StatusOK, xlsxContentType, buf.Bytes())
+}
+
+// addLogo picture of the logo of the setting in the header of the excel. The picture is read from
+// a file, the logo of the storage is copied to a temporary file
+func addLogo(xlsx *excelize.File, logo string) error {
+	if logo == "" {
+		return nil
+	}
+	content, err := openFile(logo)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	tmp, err := ioutil.TempFile("", "logo-*"+path.Ext(logo))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return xlsx.AddPicture("Sheet1", "B2", tmp.Name(), `{"x_scale": 0.5, "y_scale": 0.5}`)
+}
+
+// ExportAwardedAll excel of the awarded quotations with the original and the converted amounts
+func ExportAwardedAll(c echo.Context) error {
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
-	err = xlsx.SaveAs("./" + fileAddress)
+	// Query get config app
+	con := models.Setting{}
+	db.First(&con)
+
+	awarded, err := service.NewReportService(repository.NewStore(db)).Awarded()
 	if err != nil {
 		return err
 	}
 
-	return c.File(fileAddress)
+	// Create new BOOK EXCEL
+	sheet := "Sheet1"
+	lang := utilities.Language(c)
+	xlsx := excelize.NewFile()
+	xlsx.SetCellValue(sheet, "A1", con.CompanyName)
+	xlsx.SetCellValue(sheet, "A2", con.City)
+	xlsx.SetCellValue(sheet, "A4", utilities.Message(lang, utilities.XlsxAwarded))
+
+	//SET HEADER TABLE
+	headers := []string{
+		utilities.XlsxRequirement, utilities.XlsxProvider, utilities.XlsxEmissionDate, utilities.XlsxCurrency, utilities.XlsxSubtotal,
+		utilities.XlsxTax, utilities.XlsxTotal, utilities.XlsxRate, utilities.XlsxBaseCurrency, utilities.XlsxConverted,
+	}
+	for k, h := range headers {
+		xlsx.SetCellValue(sheet, fmt.Sprintf("%c6", 'A'+k), utilities.Message(lang, h))
+	}
+	xlsx.SetColWidth(sheet, "A", "B", 40)
+	xlsx.SetColWidth(sheet, "C", "J", 16)
+
+	currentRow := 7
+	for k, line := range awarded {
+		row := currentRow + k
+		xlsx.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.RequirementName)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.ProviderName)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("C%d", row), line.EmissionDate.Format("02/01/2006"))
+		xlsx.SetCellValue(sheet, fmt.Sprintf("D%d", row), line.Currency)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("E%d", row), line.Subtotal.Float64())
+		xlsx.SetCellValue(sheet, fmt.Sprintf("F%d", row), line.Tax.Float64())
+		xlsx.SetCellValue(sheet, fmt.Sprintf("G%d", row), line.Total.Float64())
+		xlsx.SetCellValue(sheet, fmt.Sprintf("H%d", row), line.Rate.Float64())
+		xlsx.SetCellValue(sheet, fmt.Sprintf("I%d", row), line.BaseCurrency)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("J%d", row), line.Converted.Float64())
+	}
+
+	buf := new(bytes.Buffer)
+	if err := xlsx.Write(buf); err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, storage.Disposition(utilities.Message(lang, utilities.FileAwarded)))
+	return c.Blob(http.StatusOK, xlsxContentType, buf.Bytes())
 }
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// uploadFiles storage and max size of the uploaded files, by default 10 MB
+func uploadFiles() (service.Files, error) {
+	files := service.Files{MaxSize: config.GetConfig().Upload.MaxSize << 20}
+	if files.MaxSize <= 0 {
+		files.MaxSize = 10 << 20
+	}
+	var err error
+	files.Storage, err = config.GetStorage()
+	return files, err
+}
+
+// openFile content of a saved file, the caller closes it
+func openFile(key string) (io.ReadCloser, error) {
+	// The bundled files are the files of the application as the default logo
+	if strings.HasPrefix(key, storage.StaticPrefix) {
+		if path.Clean(key) != key || strings.Contains(key, "..") {
+			return nil, storage.ErrInvalidKey
+		}
+		return os.Open(key)
+	}
+	files, err := config.GetStorage()
+	if err != nil {
+		return nil, err
+	}
+	return files.Get(key)
+}
+
+// fileURL signed link to download a saved file with the name, empty without file
+func fileURL(key string, name string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(key, storage.StaticPrefix) {
+		return "/" + key, nil
+	}
+	files, err := config.GetStorage()
+	if err != nil {
+		return "", err
+	}
+	return files.URL(key, name)
+}
+
+// streamFile send a saved file, with the name to download it as attachment
+func streamFile(c echo.Context, key string, name string) error {
+	content, err := openFile(key)
+	if err == storage.ErrNotFound || err == storage.ErrInvalidKey || os.IsNotExist(err) {
+		return utilities.NewError(http.StatusNotFound, utilities.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if name != "" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, storage.Disposition(name))
+	}
+	return c.Stream(http.StatusOK, storage.ContentType(key), content)
+}
+
+// DownloadFile serve the file of a signed link of the local storage, the links of
+// other storages point to the storage
+func DownloadFile(c echo.Context) error {
+	files, err := config.GetStorage()
+	if err != nil {
+		return err
+	}
+	local, ok := files.(*storage.Local)
+	if !ok {
+		return utilities.NewError(http.StatusNotFound, utilities.ErrRouteNotFound)
+	}
+
+	key, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		return utilities.NewError(http.StatusBadRequest, utilities.ErrBadRequest)
+	}
+	name := c.QueryParam("name")
+	if err := local.Verify(key, name, c.QueryParam("expires"), c.QueryParam("signature")); err != nil {
+		return utilities.NewError(http.StatusUnauthorized, utilities.ErrInvalidLink)
+	}
+	return streamFile(c, key, name)
+}
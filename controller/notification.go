@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/realtime"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"time"
+)
+
+// notificationEmail data of the email of a notification
+type notificationEmail struct {
+	User    models.User
+	Message string
+	Detail  string
+}
+
+// notificationsResponse page of notifications with the count of the unread ones
+type notificationsResponse struct {
+	utilities.ResponsePaginate
+	Unread uint `json:"unread"`
+}
+
+// hub events pushed to the clients connected to the stream
+var hub = realtime.NewHub()
+
+// rankingEvents events that change the ranking of the quotations of the requirement
+var rankingEvents = map[string]bool{
+	models.EventBidsOpened:       true,
+	models.EventQuotationCreated: true,
+	models.EventQuotationUpdated: true,
+	models.EventQuotationDeleted: true,
+	models.EventQuotationAwarded: true,
+}
+
+// rankingChanged data of the ranking.changed event
+type rankingChanged struct {
+	RequirementID uint             `json:"requirement_id"`
+	Ranking       []service.Ranked `json:"ranking"`
+}
+
+// emit notify the event to its recipients, push it to the connected clients and the webhooks and
+// send the emails the users want, after the change was saved. A notification not sent does not
+// fail the request, the errors are logged
+func emit(l *logger.Logger, store repository.Store, event service.Event) {
+	l = l.With(logger.Fields{"event": event.Type, "requirement_id": event.RequirementID})
+	deliveries, err := service.NewNotificationService(store).Notify(event)
+	if err != nil {
+		l.WithError(err).Errorf("event not notified")
+	}
+
+	// Clients watching the requirement and the notified users
+	hub.Publish(realtime.RequirementTopic(event.RequirementID), realtime.Message{Event: event.Type, Data: event})
+	emails := make([]service.Delivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		hub.Publish(realtime.UserTopic(d.User.ID), realtime.Message{Event: "notification", Data: d.Notification})
+		if d.Email {
+			emails = append(emails, d)
+		}
+	}
+	if rankingEvents[event.Type] {
+		ranking, err := service.NewAwardService(store).Ranking(event.RequirementID)
+		if err != nil {
+			l.WithError(err).Errorf("ranking not pushed")
+		} else {
+			hub.Publish(realtime.RequirementTopic(event.RequirementID), realtime.Message{
+				Event: "ranking.changed",
+				Data:  rankingChanged{RequirementID: event.RequirementID, Ranking: ranking},
+			})
+		}
+	}
+	ids, err := service.NewWebhookService(store, nil).Enqueue(event, time.Now())
+	if err != nil {
+		l.WithError(err).Errorf("webhooks not enqueued")
+	}
+	deliverWebhooks(l, ids)
+	if len(emails) == 0 {
+		return
+	}
+
+	queued := make([]uint, 0, len(emails))
+	for _, email := range emails {
+		data := notificationEmail{User: email.User, Message: email.Notification.Message, Detail: event.Detail}
+		id, err := queueEmail(l, store, utilities.UserLanguage(email.User, utilities.DefaultLanguage), email.User.Email, "notification", data)
+		if err != nil {
+			l.WithError(err).Errorf("notification email not queued")
+			continue
+		}
+		queued = append(queued, id)
+	}
+	sendEmails(l, queued)
+}
+
+func GetNotifications(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestNotification{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	page := utilities.Request{CurrentPage: request.CurrentPage, Limit: request.Limit}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	notifications, total, unread, err := service.NewNotificationService(repository.NewStore(db)).List(currentUser.ID, utilities.Language(c), request.Unread, newPage(&page))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, notificationsResponse{
+		ResponsePaginate: utilities.ResponsePaginate{
+			Success:     true,
+			Data:        notifications,
+			Total:       total,
+			CurrentPage: page.CurrentPage,
+		},
+		Unread: unread,
+	})
+}
+
+func ReadNotifications(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestNotification{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Mark as read
+	if err := service.NewNotificationService(repository.NewStore(db)).Read(currentUser.ID, request.IDs); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Message: "OK",
+	})
+}
+
+func GetNotificationPreferences(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	preferences, err := service.NewNotificationService(repository.NewStore(db)).Preferences(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    preferences,
+	})
+}
+
+func SaveNotificationPreferences(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	preferences := make([]models.NotificationPreference, 0)
+	if err := c.Bind(&preferences); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Save the preferences of the user
+	if err := service.NewNotificationService(repository.NewStore(db)).SavePreferences(currentUser.ID, preferences); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Message: "OK",
+	})
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+// portalQuotation quotation sent by the provider with its link
+type portalQuotation struct {
+	utilities.RequestPortal
+	models.Quotation
+}
+
+// portalDecline answer of the provider that does not quote
+type portalDecline struct {
+	utilities.RequestPortal
+	Reason string `json:"reason"`
+}
+
+// GetPortalRequirement requirement of the link of the provider
+func GetPortalRequirement(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestPortal{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	claim, err := utilities.ParsePortalToken(request.Token)
+	if err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Find requires to quote
+	view, err := service.NewPortalService(repository.NewStore(db)).View(claim.ProviderID, claim.RequirementID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    view,
+	})
+}
+
+// CreatePortalQuotation quotation sent by the provider, pending until the buyer approves it
+func CreatePortalQuotation(c echo.Context) error {
+	// Get data request
+	request := portalQuotation{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	claim, err := utilities.ParsePortalToken(request.Token)
+	if err != nil {
+		return err
+	}
+
+	// The provider and the requirement are the ones of the link
+	quotation := request.Quotation
+	quotation.ProviderID = claim.ProviderID
+	quotation.RequirementID = claim.RequirementID
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert quotation in database
+	if err := service.NewPortalService(repository.NewStore(db)).Submit(claim.UserID, &quotation); err != nil {
+		return err
+	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventQuotationCreated, ActorID: 0, RequirementID: quotation.RequirementID, QuotationID: quotation.ID})
+
+	// Return response success
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    quotation.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgQuotationSent),
+	})
+}
+
+// DeclinePortalRequirement the provider of the link does not quote the requirement
+func DeclinePortalRequirement(c echo.Context) error {
+	// Get data request
+	request := portalDecline{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	claim, err := utilities.ParsePortalToken(request.Token)
+	if err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Record the answer
+	if err := service.NewPortalService(repository.NewStore(db)).Decline(claim.ProviderID, claim.RequirementID, request.Reason); err != nil {
+		return err
+	}
+
+	// Return response success
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgQuotationDeclined),
+	})
+}
@@ -1,10 +1,10 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
 	"net/http"
 )
@@ -17,24 +17,15 @@ func GetProducts(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Pagination calculate
-	if request.CurrentPage == 0 {
-		request.CurrentPage = 1
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
-	offset := request.Limit*request.CurrentPage - request.Limit
+	defer db.Close()
 
 	// Execute instructions
-	var total uint
-	products := make([]models.Product, 0)
-
-	if err := db.Where("lower(name) LIKE lower(?)", "%"+request.Search+"%").
-		Order("id desc").
-		Offset(offset).Limit(request.Limit).Find(&products).
-		Offset(-1).Limit(-1).Count(&total).
-		Error; err != nil {
+	products, total, err := service.NewProductService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
 		return err
 	}
 
@@ -49,7 +40,7 @@ func GetProducts(c echo.Context) error {
 				Name: product.Name,
 			})
 		}
-		return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+		return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 			Success:     true,
 			Data:        customProducts,
 			Total:       total,
@@ -57,7 +48,7 @@ func GetProducts(c echo.Context) error {
 		})
 	}
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 		Success:     true,
 		Data:        products,
 		Total:       total,
@@ -73,13 +64,15 @@ func GetProductSearch(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	products := make([]models.Product, 0)
-	if err := db.Where("lower(name) LIKE lower(?)", "%"+request.Search+"%").
-		Limit(5).Find(&products).Error; err != nil {
+	products, _, err := service.NewProductService(repository.NewStore(db)).List(repository.Page{Search: request.Search, Limit: 5})
+	if err != nil {
 		return err
 	}
 
@@ -93,7 +86,7 @@ func GetProductSearch(c echo.Context) error {
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    customProducts,
 	})
@@ -107,16 +100,20 @@ func GetProductByID(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	if err := db.First(&product, product.ID).Error; err != nil {
+	product, err = service.NewProductService(repository.NewStore(db)).Get(product.ID)
+	if err != nil {
 		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    product,
 	})
@@ -130,22 +127,22 @@ func CreateProduct(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Insert product in database
-	if err := db.Create(&product).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("%s", err),
-		})
+	if err := service.NewProductService(repository.NewStore(db)).Create(&product); err != nil {
+		return err
 	}
 
 	// Return response
 	return c.JSON(http.StatusCreated, utilities.Response{
 		Success: true,
 		Data:    product.ID,
-		Message: fmt.Sprintf("El producto %s se registro exitosamente", product.Name),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgProductCreated, product.Name),
 	})
 }
 
@@ -157,23 +154,19 @@ func UpdateProduct(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Update product in database
-	rows := db.Model(&product).Update(product).RowsAffected
-	if !product.State {
-		rows = db.Model(product).UpdateColumn("state", false).RowsAffected
-	}
-	if rows == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se pudo actualizar el registro con el id = %d", product.ID),
-		})
+	if err := service.NewProductService(repository.NewStore(db)).Update(&product); err != nil {
+		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    product.ID,
 	})
@@ -187,27 +180,19 @@ func DeleteProduct(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation product exist
-	if db.First(&product).RecordNotFound() {
-		return c.JSON(http.StatusCreated, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se encontró el registro con id %d", product.ID),
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
 	// Delete product in database
-	if err := db.Delete(&product).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("%s", err),
-		})
+	if err := service.NewProductService(repository.NewStore(db)).Delete(product.ID); err != nil {
+		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    product.ID,
 	})
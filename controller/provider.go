@@ -1,16 +1,13 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -22,24 +19,15 @@ func GetProviders(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Pagination calculate
-	if request.CurrentPage == 0 {
-		request.CurrentPage = 1
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
-	offset := request.Limit*request.CurrentPage - request.Limit
+	defer db.Close()
 
 	// Execute instructions
-	var total uint
-	providers := make([]models.Provider, 0)
-
-	if err := db.Where("lower(name) LIKE lower(?)", "%"+request.Search+"%").
-		Or("ruc LIKE ?", "%"+request.Search+"%").
-		Order("id desc").
-		Offset(offset).Limit(request.Limit).Find(&providers).
-		Offset(-1).Limit(-1).Count(&total).Error; err != nil {
+	providers, total, err := service.NewProviderService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
 		return err
 	}
 
@@ -54,7 +42,7 @@ func GetProviders(c echo.Context) error {
 				Name: provider.Name,
 			})
 		}
-		return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+		return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 			Success:     true,
 			Data:        customProvider,
 			Total:       total,
@@ -62,7 +50,7 @@ func GetProviders(c echo.Context) error {
 		})
 	}
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 		Success:     true,
 		Data:        providers,
 		Total:       total,
@@ -78,14 +66,15 @@ func GetProviderSearch(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	providers := make([]models.Provider, 0)
-	if err := db.Where("lower(name) LIKE lower(?)", "%"+request.Search+"%").
-		Or("ruc LIKE ?", "%"+request.Search+"%").
-		Limit(5).Find(&providers).Error; err != nil {
+	providers, _, err := service.NewProviderService(repository.NewStore(db)).List(repository.Page{Search: request.Search, Limit: 5})
+	if err != nil {
 		return err
 	}
 
@@ -98,7 +87,7 @@ func GetProviderSearch(c echo.Context) error {
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    customProviders,
 	})
@@ -112,16 +101,20 @@ func GetProviderByID(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	if err := db.First(&provider, provider.ID).Error; err != nil {
+	provider, err = service.NewProviderService(repository.NewStore(db)).Get(provider.ID)
+	if err != nil {
 		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    provider,
 	})
@@ -135,21 +128,22 @@ func CreateProvider(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Insert provider in database
-	if err := db.Create(&provider).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("%s", err),
-		})
+	if err := service.NewProviderService(repository.NewStore(db)).Create(&provider); err != nil {
+		return err
 	}
 
 	// Return response
 	return c.JSON(http.StatusCreated, utilities.Response{
 		Success: true,
 		Data:    provider.ID,
-		Message: fmt.Sprintf("El proveedor %s se registro exitosamente", provider.Name),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgProviderCreated, provider.Name),
 	})
 }
 
@@ -161,18 +155,15 @@ func UpdateProvider(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Update provider in database
-	rows := db.Model(&provider).Update(provider).RowsAffected
-	if !provider.State {
-		rows = db.Model(provider).UpdateColumn("state", false).RowsAffected
-	}
-	if rows == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se pudo actualizar el registro con el id = %d", provider.ID),
-		})
+	if err := service.NewProviderService(repository.NewStore(db)).Update(&provider); err != nil {
+		return err
 	}
 
 	// Return response
@@ -190,21 +181,15 @@ func DeleteProvider(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation provider exist
-	if db.First(&provider).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", provider.ID),
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
 	// Delete provider in database
-	if err := db.Delete(&provider).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("%s", err),
-		})
+	if err := service.NewProviderService(repository.NewStore(db)).Delete(provider.ID); err != nil {
+		return err
 	}
 
 	// Return response
@@ -222,21 +207,21 @@ func ValidateRucProvider(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Validations
-	if err := db.Where("ruc = ?", provider.RUC).First(&provider).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: true,
-			Message: "OK",
-		})
+	if err := service.NewProviderService(repository.NewStore(db)).CheckRUC(provider.RUC); err != nil {
+		return err
 	}
 
 	// Return response
 	return c.JSON(http.StatusOK, utilities.Response{
-		Success: false,
-		Message: fmt.Sprintf("El número de RUC ya esta registrado"),
+		Success: true,
+		Message: "OK",
 	})
 }
 
@@ -256,23 +241,10 @@ func SetTempUploadProvider(c echo.Context) error {
 	}
 	defer src.Close()
 
-	// Destination
-	auxDir := "temp/provider" + filepath.Ext(file.Filename)
-	dst, err := os.Create(auxDir)
-	if err != nil {
-		return err
-	}
-	defer dst.Close()
-
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
-		return err
-	}
-
 	// ---------------------
 	// Read File whit Excel
 	// ---------------------
-	xlsx, err := excelize.OpenFile(auxDir)
+	xlsx, err := excelize.OpenReader(src)
 	if err != nil {
 		return err
 	}
@@ -282,43 +254,42 @@ func SetTempUploadProvider(c echo.Context) error {
 	ignoreCols := 1
 
 	// Get all the rows in the proveedores(Sheet).
+	// Columns: RUC, Nombre o razón social, representante, email, telefono, dirección
 	rows := xlsx.GetRows("proveedores")
 	for k, row := range rows {
 		if k >= ignoreCols {
-			providers = append(providers, models.Provider{
-				RUC:     strings.TrimSpace(row[0]),
-				Name:    strings.TrimSpace(row[1]),
-				Manager: strings.TrimSpace(row[5]),
-				Email:   strings.TrimSpace(row[5]),
-				Phone:   strings.TrimSpace(row[5]),
-				Address: strings.TrimSpace(row[5]),
+			cols := make([]string, 6)
+			for i := 0; i < len(cols) && i < len(row); i++ {
+				cols[i] = strings.TrimSpace(row[i])
+			}
+			provider := models.Provider{
+				RUC:     cols[0],
+				Name:    cols[1],
+				Manager: cols[2],
+				Email:   cols[3],
+				Phone:   cols[4],
+				Address: cols[5],
 				State:   true,
-			})
+			}
+			providers = append(providers, provider)
 		}
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Insert providers in database
-	tr := db.Begin()
-	for _, provider := range providers {
-		if err := tr.Create(&provider).Error; err != nil {
-			tr.Rollback()
-			return c.JSON(http.StatusOK, utilities.Response{
-				Success: false,
-				Message: fmt.Sprintf("Ocurrió un error al insertar el proveedores %s con "+
-					"RUC: %s es posible que este proveedor ya este en la base de datos o los datos son incorrectos, "+
-					"Error: %s, no se realizo ninguna cambio en la base de datos", provider.Name, provider.RUC, err),
-			})
-		}
+	if err := service.NewProviderService(repository.NewStore(db)).Import(providers); err != nil {
+		return err
 	}
-	tr.Commit()
 
 	// Response success
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
-		Message: fmt.Sprintf("Se guardo %d registros den la base de datos", len(providers)),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgProvidersImported, len(providers)),
 	})
 }
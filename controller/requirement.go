@@ -1,14 +1,14 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
 	"net/http"
-	"time"
 )
 
 func GetRequirements(c echo.Context) error {
@@ -19,25 +19,15 @@ func GetRequirements(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Pagination calculate
-	if request.CurrentPage == 0 {
-		request.CurrentPage = 1
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
-	offset := request.Limit*request.CurrentPage - request.Limit
+	defer db.Close()
 
 	// Execute instructions
-	var total uint
-	requirements := make([]models.Requirement, 0)
-
-	if err := db.Where("lower(name) LIKE lower(?)", "%"+request.Search+"%").
-		Or("lower(destination) LIKE lower(?)", "%"+request.Search+"%").
-		Or("lower(claimant) LIKE lower(?)", "%"+request.Search+"%").
-		Order("id desc").
-		Offset(offset).Limit(request.Limit).Find(&requirements).
-		Offset(-1).Limit(-1).Count(&total).Error; err != nil {
+	requirements, total, err := service.NewRequirementService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
 		return err
 	}
 
@@ -52,7 +42,7 @@ func GetRequirements(c echo.Context) error {
 				Name: product.Name,
 			})
 		}
-		return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+		return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 			Success:     true,
 			Data:        customRequirements,
 			Total:       total,
@@ -60,7 +50,7 @@ func GetRequirements(c echo.Context) error {
 		})
 	}
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 		Success:     true,
 		Data:        requirements,
 		Total:       total,
@@ -76,16 +66,20 @@ func GetRequirementByID(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	if err := db.First(&requirement, requirement.ID).Error; err != nil {
+	requirement, err = service.NewRequirementService(repository.NewStore(db)).Get(requirement.ID)
+	if err != nil {
 		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    requirement,
 	})
@@ -102,37 +96,25 @@ func CreateRequirement(c echo.Context) error {
 	if err := c.Bind(&requirement); err != nil {
 		return err
 	}
-	requirement.UserID = currentUser.ID
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation
-	if len(requirement.Requires) == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: "Agregue al menos un producto para crear el requerimiento",
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	// Default values
-	requirement.EmissionDate = time.Now()
-	requirement.State = "0"
-
-	// Insert product in database
-	if err := db.Create(&requirement).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("%s", err),
-		})
+	// Insert requirement in database
+	if err := service.NewRequirementService(repository.NewStore(db)).Create(currentUser.ID, &requirement); err != nil {
+		return err
 	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventRequirementCreated, ActorID: currentUser.ID, RequirementID: requirement.ID})
 
 	// Return response
 	return c.JSON(http.StatusCreated, utilities.Response{
 		Success: true,
 		Data:    requirement.ID,
-		Message: fmt.Sprintf("El requerimiento %s se registro exitosamente", requirement.Name),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgRequirementSaved, requirement.Name),
 	})
 }
 
@@ -144,27 +126,30 @@ func UpdateRequirement(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Update product in database
-	rows := db.Debug().Model(&requirement).Update(requirement).RowsAffected
-
-	if rows == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se pudo actualizar el registro con el id = %d", requirement.ID),
-		})
+	// Update requirement in database
+	if err := service.NewRequirementService(repository.NewStore(db)).Update(&requirement); err != nil {
+		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    requirement.ID,
 	})
 }
 
 func SetRejectedRequirement(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
 	// Get data request
 	requirement := models.Requirement{}
 	if err := c.Bind(&requirement); err != nil {
@@ -172,26 +157,31 @@ func SetRejectedRequirement(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Update product in database
-	rows := db.Model(&requirement).UpdateColumn("state", "2").RowsAffected
-	if rows == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se pudo actualizar el registro con el id = %d", requirement.ID),
-		})
+	// Change state requirement
+	if err := service.NewRequirementService(repository.NewStore(db)).Reject(currentUser.ID, requirement.ID); err != nil {
+		return err
 	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventRequirementRejected, ActorID: currentUser.ID, RequirementID: requirement.ID})
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    requirement.ID,
 	})
 }
 
 func SetClosedRequirement(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
 	// Get data request
 	requirement := models.Requirement{}
 	if err := c.Bind(&requirement); err != nil {
@@ -199,20 +189,20 @@ func SetClosedRequirement(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Update product in database
-	rows := db.Model(&requirement).UpdateColumn("state", "4").RowsAffected
-	if rows == 0 {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se pudo actualizar el registro con el id = %d", requirement.ID),
-		})
+	// Change state requirement
+	if err := service.NewRequirementService(repository.NewStore(db)).Close(currentUser.ID, requirement.ID); err != nil {
+		return err
 	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventRequirementClosed, ActorID: currentUser.ID, RequirementID: requirement.ID})
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    requirement.ID,
 	})
@@ -226,28 +216,54 @@ func DeleteRequirement(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Validation product exist
-	if db.First(&requirement).RecordNotFound() {
-		return c.JSON(http.StatusCreated, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se encontró el registro con id %d", requirement.ID),
-		})
+	// Delete requirement and its requires in database
+	if err := service.NewRequirementService(repository.NewStore(db)).Delete(requirement.ID); err != nil {
+		return err
 	}
 
-	// Delete product in database
-	if err := db.Delete(&requirement).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("%s", err),
-		})
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    requirement.ID,
+	})
+}
+
+// OpenBidsRequirement reveal the prices of the sealed quotations before the expiration date
+func OpenBidsRequirement(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	requirement := models.Requirement{}
+	if err := c.Bind(&requirement); err != nil {
+		return err
 	}
 
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Open the bids and rank the quotations
+	if err := service.NewAwardService(repository.NewStore(db)).OpenBids(currentUser, requirement.ID); err != nil {
+		return err
+	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventBidsOpened, ActorID: currentUser.ID, RequirementID: requirement.ID})
+
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    requirement.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgBidsOpened, requirement.ID),
 	})
 }
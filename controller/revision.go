@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+// GetQuotationRevisions history of the offers of a quotation
+func GetQuotationRevisions(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestRevision{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	revisions, err := service.NewRevisionService(repository.NewStore(db)).List(request.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    revisions,
+	})
+}
+
+// DiffQuotationRevisions changes between two revisions of a quotation
+// RequestRevision.From == 0 -> first offer     // Optional
+// RequestRevision.To == 0   -> last offer      // Optional
+func DiffQuotationRevisions(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestRevision{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	diff, err := service.NewRevisionService(repository.NewStore(db)).Diff(request.ID, request.From, request.To)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    diff,
+	})
+}
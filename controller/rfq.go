@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/mailer"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type rfqResponse struct {
+	ID           uint      `json:"id"`
+	ProviderID   uint      `json:"provider_id"`
+	ProviderName string    `json:"provider_name"`
+	Email        string    `json:"email"`
+	Link         string    `json:"link"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type rfqEmail struct {
+	Provider    models.Provider
+	Requirement models.Requirement
+	Link        string
+	Expires     time.Time
+	Company     string
+}
+
+// SendRfq send to every provider the request for quotation with the excel of the requires
+// and the link to quote in the portal
+func SendRfq(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestInvitation{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Validate requirement and providers
+	store := repository.NewStore(db)
+	rfqs := service.NewRfqService(store)
+	requirement, providers, err := rfqs.Prepare(request.RequirementID, request.Providers)
+	if err != nil {
+		return err
+	}
+	lines, err := service.NewRequirementService(store).Requires(requirement.ID)
+	if err != nil {
+		return err
+	}
+	setting, err := store.Settings().Get()
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	lang := utilities.Language(c)
+	expires := service.LinkExpiration(requirement)
+	responses := make([]rfqResponse, 0)
+	ids := make([]uint, 0, len(providers))
+	for _, provider := range providers {
+		// Signed link of the provider
+		token, err := utilities.GeneratePortalToken(utilities.PortalClaim{
+			ProviderID:    provider.ID,
+			RequirementID: requirement.ID,
+			UserID:        currentUser.ID,
+		}, expires)
+		if err != nil {
+			return err
+		}
+		link := cfg.Server.Portal + "?token=" + url.QueryEscape(token)
+
+		// Excel of the requires
+		document, err := rfqDocument(lang, setting, requirement, provider, lines)
+		if err != nil {
+			return err
+		}
+
+		// SEND EMAIL with the excel attached
+		attachment := mailer.Attachment{
+			Name:        rfqFileName(lang, requirement),
+			ContentType: xlsxContentType,
+			Data:        document,
+		}
+		id, err := queueEmail(logger.FromContext(c), store, lang, provider.Email, "rfq", rfqEmail{
+			Provider:    provider,
+			Requirement: requirement,
+			Link:        link,
+			Expires:     expires,
+			Company:     setting.CompanyName,
+		}, attachment)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+
+		// Track the state of the request
+		rfq, err := rfqs.Sent(currentUser.ID, requirement.ID, provider)
+		if err != nil {
+			return err
+		}
+
+		responses = append(responses, rfqResponse{
+			ID:           rfq.ID,
+			ProviderID:   provider.ID,
+			ProviderName: provider.Name,
+			Email:        provider.Email,
+			Link:         link,
+			ExpiresAt:    expires,
+		})
+	}
+
+	sendEmails(logger.FromContext(c), ids)
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    responses,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgRfqSent, len(responses)),
+	})
+}
+
+// GetRfqs state of the requests for quotation of the requirement by provider
+func GetRfqs(c echo.Context) error {
+	// Get data request
+	request := utilities.RequestQuotation{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Find requests
+	rfqs, err := service.NewRfqService(repository.NewStore(db)).List(request.RequirementID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    rfqs,
+	})
+}
+
+// DownloadRfq excel of the request for quotation of the requirement, without provider
+func DownloadRfq(c echo.Context) error {
+	// Get data request
+	requirementID, _ := strconv.Atoi(c.QueryParam("requirement_id"))
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Find requirement and requires
+	store := repository.NewStore(db)
+	requirements := service.NewRequirementService(store)
+	requirement, err := requirements.Get(uint(requirementID))
+	if err != nil {
+		return err
+	}
+	lines, err := requirements.Requires(requirement.ID)
+	if err != nil {
+		return err
+	}
+	setting, err := store.Settings().Get()
+	if err != nil {
+		return err
+	}
+
+	lang := utilities.Language(c)
+	document, err := rfqDocument(lang, setting, requirement, models.Provider{}, lines)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, storage.Disposition(rfqFileName(lang, requirement)))
+	return c.Blob(http.StatusOK, xlsxContentType, document)
+}
+
+// rfqFileName name of the excel of the request for quotation in the language
+func rfqFileName(lang string, requirement models.Requirement) string {
+	return utilities.Message(lang, utilities.FileRfq, requirement.ID)
+}
+
+// rfqDocument excel with the requires of the requirement and the deadline to quote, the headers
+// are in the language
+func rfqDocument(lang string, setting models.Setting, requirement models.Requirement, provider models.Provider, lines []repository.RequireLine) ([]byte, error) {
+	sheet := "Sheet1"
+	xlsx := excelize.NewFile()
+
+	xlsx.SetCellValue(sheet, "A1", setting.CompanyName)
+	xlsx.SetCellValue(sheet, "A2", setting.City)
+	xlsx.SetCellValue(sheet, "A4", utilities.Message(lang, utilities.XlsxRfq))
+
+	xlsx.SetCellValue(sheet, "A6", utilities.Message(lang, utilities.XlsxRequirement))
+	xlsx.SetCellValue(sheet, "B6", requirement.Name)
+	xlsx.SetCellValue(sheet, "A7", utilities.Message(lang, utilities.XlsxDeliveryPlace))
+	xlsx.SetCellValue(sheet, "B7", requirement.Destination)
+	xlsx.SetCellValue(sheet, "A8", utilities.Message(lang, utilities.XlsxDeadline))
+	if !requirement.ExpirationDate.IsZero() {
+		xlsx.SetCellValue(sheet, "B8", requirement.ExpirationDate.Format("02/01/2006"))
+	}
+	xlsx.SetCellValue(sheet, "A9", utilities.Message(lang, utilities.XlsxProvider))
+	if provider.ID != 0 {
+		xlsx.SetCellValue(sheet, "B9", fmt.Sprintf("%s - RUC %s", provider.Name, provider.RUC))
+	}
+
+	// SET HEADER TABLE
+	headers := []string{utilities.XlsxNumber, utilities.XlsxProduct, utilities.XlsxAmount, utilities.XlsxUnitMeasure, utilities.XlsxObservation, utilities.XlsxUnitPrice, utilities.XlsxTotal}
+	for k, h := range headers {
+		xlsx.SetCellValue(sheet, fmt.Sprintf("%c11", 'A'+k), utilities.Message(lang, h))
+	}
+	xlsx.SetColWidth(sheet, "B", "B", 40)
+	xlsx.SetColWidth(sheet, "D", "G", 18)
+
+	// Requires, the provider fills the prices
+	currentRow := 12
+	for k, line := range lines {
+		row := currentRow + k
+		xlsx.SetCellValue(sheet, fmt.Sprintf("A%d", row), k+1)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.ProductName)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("C%d", row), line.Amount.Float64())
+		xlsx.SetCellValue(sheet, fmt.Sprintf("D%d", row), line.UnitMeasure)
+		xlsx.SetCellValue(sheet, fmt.Sprintf("E%d", row), line.Observation)
+		xlsx.SetCellFormula(sheet, fmt.Sprintf("G%d", row), fmt.Sprintf("C%d*F%d", row, row))
+	}
+
+	buf := new(bytes.Buffer)
+	if err := xlsx.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,22 +1,22 @@
 package controller
 
 import (
-	"fmt"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/decimal"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
-	"io"
 	"net/http"
-	"os"
-	"path"
 )
 
 type GlobalSettings struct {
-	Message string         `json:"message"`
-	Success bool           `json:"success"`
-	Setting models.Setting `json:"setting"`
-	User    models.User    `json:"user"`
+	Message   string         `json:"message"`
+	Success   bool           `json:"success"`
+	Setting   models.Setting `json:"setting"`
+	User      models.User    `json:"user"`
+	LogoURL   string         `json:"logo_url"`   // Signed link of the logo, it expires
+	AvatarURL string         `json:"avatar_url"` // Signed link of the avatar of the user, it expires
 }
 
 func GetGlobalSettings(c echo.Context) error {
@@ -28,24 +28,39 @@ func GetGlobalSettings(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	if err := db.First(&user, user.ID).Error; err != nil {
-		return c.NoContent(http.StatusUnauthorized)
+	if db.First(&user, user.ID).RecordNotFound() {
+		return utilities.NewError(http.StatusUnauthorized, utilities.ErrUnauthorized)
 	}
 	user.Password = ""
 	user.Key = ""
 
 	db.First(&con) // Find settings
 
+	// Links of the images
+	logoURL, err := fileURL(con.Logo, "")
+	if err != nil {
+		return err
+	}
+	avatarURL, err := fileURL(user.Avatar, "")
+	if err != nil {
+		return err
+	}
+
 	// Set object response
 	return c.JSON(http.StatusOK, GlobalSettings{
-		User:    user,
-		Setting: con,
-		Success: true,
-		Message: "OK",
+		User:      user,
+		Setting:   con,
+		LogoURL:   logoURL,
+		AvatarURL: avatarURL,
+		Success:   true,
+		Message:   "OK",
 	})
 }
 
@@ -54,7 +69,10 @@ func GetSetting(c echo.Context) error {
 	con := models.Setting{}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query database
@@ -67,17 +85,50 @@ func GetSetting(c echo.Context) error {
 	})
 }
 
+// settingRequest setting to update, the fields that can be false or 0 only change when they are sent
+type settingRequest struct {
+	models.Setting
+	TaxRate     *decimal.Decimal `json:"tax_rate"`
+	TaxIncluded *bool            `json:"tax_included"`
+	BudgetBlock *bool            `json:"budget_block"`
+}
+
 func UpdateSetting(c echo.Context) error {
 	// Get data request
-	con := models.Setting{}
-	if err := c.Bind(&con); err != nil {
+	request := settingRequest{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	con := request.Setting
+	columns := map[string]interface{}{}
+	if request.TaxRate != nil {
+		con.TaxRate = *request.TaxRate
+		columns["tax_rate"] = con.TaxRate
+	}
+	if request.TaxIncluded != nil {
+		con.TaxIncluded = *request.TaxIncluded
+		columns["tax_included"] = con.TaxIncluded
+	}
+	if request.BudgetBlock != nil {
+		con.BudgetBlock = *request.BudgetBlock
+		columns["budget_block"] = con.BudgetBlock
+	}
+	if err := c.Validate(&con); err != nil {
 		return err
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
+	// The quotations are converted to the base currency, it is fixed once there are quotations
+	if err := service.CheckBaseCurrency(repository.NewStore(db), con.Currency); err != nil {
+		return err
+	}
+
 	// Validation first data
 	var exist uint
 	db.Model(&models.Setting{}).Count(&exist)
@@ -89,10 +140,16 @@ func UpdateSetting(c echo.Context) error {
 		}
 	}
 
-	// Update con in database
+	// Update con in database, the logo only changes by its upload
+	con.Logo, con.LogoThumbnail = "", ""
 	if err := db.Model(&con).Update(con).Error; err != nil {
 		return err
 	}
+	if len(columns) > 0 {
+		if err := db.Model(&con).UpdateColumns(columns).Error; err != nil {
+			return err
+		}
+	}
 
 	// Response config
 	return c.JSON(http.StatusOK, utilities.Response{
@@ -103,22 +160,6 @@ func UpdateSetting(c echo.Context) error {
 }
 
 func UploadLogoSetting(c echo.Context) error {
-	// Read form fields
-	idSetting := c.FormValue("id")
-	setting := models.Setting{}
-
-	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation user exist
-	if db.First(&setting, "id = ?", idSetting).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se encontró el registro con id %d", setting.ID),
-		})
-	}
-
 	// Source
 	file, err := c.FormFile("logo")
 	if err != nil {
@@ -130,22 +171,20 @@ func UploadLogoSetting(c echo.Context) error {
 	}
 	defer src.Close()
 
-	// Destination
-	logoSRC := fmt.Sprintf("static/logo%s", path.Ext(file.Filename))
-	dst, err := os.Create(logoSRC)
+	// get connection
+	db, err := getConnection(c)
 	if err != nil {
 		return err
 	}
-	defer dst.Close()
-	setting.Logo = logoSRC
-
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
 		return err
 	}
 
-	// Update database user
-	if err := db.Model(&setting).Update(setting).Error; err != nil {
+	// Resize and save the logo, the previous is removed
+	setting, err := service.NewPictureService(repository.NewStore(db), files).SetLogo(src)
+	if err != nil {
 		return err
 	}
 
@@ -159,16 +198,20 @@ func UploadLogoSetting(c echo.Context) error {
 
 func DownloadLogoSetting(c echo.Context) error {
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Validation user exist
 	setting := models.Setting{}
 	if db.First(&setting).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Success: false,
-			Message: fmt.Sprintf("No se encontró el registro con id %d", setting.ID),
-		})
+		return utilities.NewNotFoundError(setting.ID)
+	}
+	key := setting.Logo
+	if c.QueryParam("thumbnail") == "true" && setting.LogoThumbnail != "" {
+		key = setting.LogoThumbnail
 	}
-	return c.File(setting.Logo)
+	return streamFile(c, key, "")
 }
@@ -2,8 +2,9 @@ package controller
 
 import (
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
 	"net/http"
 )
@@ -17,7 +18,10 @@ type providerTop struct {
 
 func TopProviderWinner(c echo.Context) error {
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query database top 5
@@ -25,8 +29,8 @@ func TopProviderWinner(c echo.Context) error {
 	if err := db.Table("quotations").
 		Select("providers.id, quotations.winner, providers.name, count(winner) as top").
 		Joins("INNER JOIN providers on quotations.provider_id = providers.id").
+		Where("quotations.winner = ?", true).
 		Group("providers.id,  providers.name, quotations.winner").
-		Having("quotations.winner = true").
 		Order("top desc").
 		Limit(15).
 		Scan(&providerTops).Error; err != nil {
@@ -44,6 +48,32 @@ func TopProviderWinner(c echo.Context) error {
 	})
 }
 
+// TopProviderAmount providers with the greater amounts awarded in the base currency
+func TopProviderAmount(c echo.Context) error {
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Query database top 15
+	providerTops, err := service.NewReportService(repository.NewStore(db)).TopProviderAmounts(15)
+	if err != nil {
+		return err
+	}
+
+	// Total registers
+	var total uint
+	db.Model(models.Provider{}).Count(&total)
+
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success: true,
+		Data:    providerTops,
+		Total:   total,
+	})
+}
+
 type userTop struct {
 	ID        uint   `json:"-"`
 	FirstName string `json:"first_name"`
@@ -53,7 +83,10 @@ type userTop struct {
 
 func TopUsers(c echo.Context) error {
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query database top 5
@@ -87,7 +120,10 @@ type productTop struct {
 
 func TopProducts(c echo.Context) error {
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query database top 5
@@ -121,7 +157,10 @@ type requirementStateTop struct {
 
 func TopRequirements(c echo.Context) error {
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Query database top 5
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/realtime"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamPing interval of the comments that keep the connection open through the proxies
+const streamPing = 25 * time.Second
+
+// streamTokenResponse token to connect to the stream, valid for expires_in seconds
+type streamTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// StreamToken issue the short lived token that goes in the query of the stream, so the
+// session token never goes in an url. The token is only checked on the connection, an open
+// stream is not closed when it expires
+func StreamToken(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	token, err := utilities.GenerateStreamToken(currentUser)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    streamTokenResponse{Token: token, ExpiresIn: int(utilities.StreamTokenLife.Seconds())},
+	})
+}
+
+// Stream server-sent events of the notifications of the user and, with ?requirement=id, of the
+// changes of the requirement and its quotations. The token of StreamToken goes in the query
+// because the browsers do not send headers with EventSource.
+//
+// The EventSource reconnects by itself with the same url, which is rejected with 401 once the
+// token expired and leaves the EventSource closed: on an error with readyState CLOSED the
+// client requests a new token, opens a new EventSource and reloads the data it shows
+func Stream(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Topics of the client
+	topics := []string{realtime.UserTopic(currentUser.ID)}
+	if id := c.QueryParam("requirement"); id != "" {
+		requirementID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil || requirementID == 0 {
+			return utilities.NewError(http.StatusBadRequest, utilities.ErrBadRequest)
+		}
+		topics = append(topics, realtime.RequirementTopic(uint(requirementID)))
+	}
+	subscription := hub.Subscribe(topics...)
+	defer subscription.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "retry: 3000\n\n")
+	res.Flush()
+
+	ping := time.NewTicker(streamPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ping.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+		case m, ok := <-subscription.C:
+			// Closed when the client falls behind, it reconnects, with a new token when the
+			// token expired, and reloads the data
+			if !ok {
+				return nil
+			}
+			if _, err := m.WriteTo(res); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+)
+
+func GetTemplates(c echo.Context) error {
+	// Get data request
+	request := utilities.Request{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	templates, total, err := service.NewTemplateService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        templates,
+		Total:       total,
+		CurrentPage: request.CurrentPage,
+	})
+}
+
+func GetTemplateByID(c echo.Context) error {
+	// Get data request
+	template := models.RequirementTemplate{}
+	if err := c.Bind(&template); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	template, err = service.NewTemplateService(repository.NewStore(db)).Get(template.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    template,
+	})
+}
+
+func CreateTemplate(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	template := models.RequirementTemplate{}
+	if err := c.Bind(&template); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert template in database
+	if err := service.NewTemplateService(repository.NewStore(db)).Create(currentUser.ID, &template); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    template.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgTemplateSaved, template.Name),
+	})
+}
+
+func UpdateTemplate(c echo.Context) error {
+	// Get data request
+	template := models.RequirementTemplate{}
+	if err := c.Bind(&template); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Update template in database
+	if err := service.NewTemplateService(repository.NewStore(db)).Update(&template); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    template.ID,
+	})
+}
+
+func DeleteTemplate(c echo.Context) error {
+	// Get data request
+	template := models.RequirementTemplate{}
+	if err := c.Bind(&template); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete template in database
+	if err := service.NewTemplateService(repository.NewStore(db)).Delete(template.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    template.ID,
+	})
+}
+
+// SaveRequirementTemplate save a requirement with its requires as a template
+func SaveRequirementTemplate(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestTemplate{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert template in database
+	template, err := service.NewTemplateService(repository.NewStore(db)).Save(currentUser.ID, request.RequirementID, request.Name)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    template.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgTemplateSaved, template.Name),
+	})
+}
+
+// CreateRequirementFromTemplate create a requirement with the data and the products of a template
+func CreateRequirementFromTemplate(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestTemplate{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert requirement in database
+	requirement, err := service.NewTemplateService(repository.NewStore(db)).Instantiate(currentUser.ID, request.ID)
+	if err != nil {
+		return err
+	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventRequirementCreated, ActorID: currentUser.ID, RequirementID: requirement.ID})
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    requirement.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgRequirementSaved, requirement.Name),
+	})
+}
+
+// CloneRequirement create a requirement with the data and the products of other requirement
+func CloneRequirement(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestTemplate{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert requirement in database
+	requirement, err := service.NewTemplateService(repository.NewStore(db)).Clone(currentUser.ID, request.RequirementID)
+	if err != nil {
+		return err
+	}
+	emit(logger.FromContext(c), repository.NewStore(db), service.Event{Type: models.EventRequirementCreated, ActorID: currentUser.ID, RequirementID: requirement.ID})
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    requirement.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgRequirementSaved, requirement.Name),
+	})
+}
@@ -1,19 +1,15 @@
 package controller
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"fmt"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
-	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
 	"github.com/paulantezana/requirement/utilities"
-	"html/template"
-	"io"
-	"math/rand"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 )
 
 type loginDataResponse struct {
@@ -29,37 +25,28 @@ func Login(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Hash password
-	cc := sha256.Sum256([]byte(user.Password))
-	pwd := fmt.Sprintf("%x", cc)
-
 	// Validate user and email
-	if db.Where("user_name = ? and password = ?", user.UserName, pwd).First(&user).RecordNotFound() {
-		if db.Debug().Where("email = ? and password = ?", user.UserName, pwd).First(&user).RecordNotFound() {
-			return c.JSON(http.StatusOK, utilities.Response{
-				Message: "El nombre de usuario o contraseña es incorecta",
-			})
-		}
-	}
-
-	// Check state user
-	if !user.State {
-		return c.NoContent(http.StatusForbidden)
+	user, err = service.NewUserService(repository.NewStore(db)).Login(user.UserName, user.Password)
+	if err != nil {
+		return err
 	}
 
-	// Prepare response data
-	user.Password = ""
-
 	// get token key
-	token := utilities.GenerateJWT(user)
+	token, err := utilities.GenerateJWT(user)
+	if err != nil {
+		return err
+	}
 
 	// Login success
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
-		Message: fmt.Sprintf("Bienvenido al sistema %s", user.UserName),
+		Message: utilities.Message(utilities.UserLanguage(user, utilities.Language(c)), utilities.MsgWelcome, user.UserName),
 		Data: loginDataResponse{
 			User:  user,
 			Token: token,
@@ -74,45 +61,24 @@ func ForgotSearch(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validations
-	if err := db.Where("email = ?", user.Email).First(&user).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("Tu búsqueda no arrojó ningún resultado. Vuelve a intentarlo con otros datos."),
-		})
-	}
-
-	// Generate key validation
-	key := (int)(rand.Float32() * 10000000)
-	user.Key = fmt.Sprint(key)
-
-	// Update database
-	if err := db.Model(&user).Update(user).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("%s", err),
-		})
-	}
-
-	// SEND EMAIL get html template
-	t, err := template.ParseFiles("./templates/email.html")
+	db, err := getConnection(c)
 	if err != nil {
-		c.NoContent(http.StatusInternalServerError)
+		return err
 	}
+	defer db.Close()
 
-	// SEND EMAIL new buffer
-	buf := new(bytes.Buffer)
-	err = t.Execute(buf, user)
+	// Generate key validation
+	user, err = service.NewUserService(repository.NewStore(db)).ForgotSearch(user.Email)
 	if err != nil {
-		c.NoContent(http.StatusInternalServerError)
+		return err
 	}
 
-	// SEND EMAIL
-	err = config.SendEmail(user.Email, fmt.Sprint(key)+" es el código de recuperación de tu cuenta en RQSystem", buf.String())
+	// SEND EMAIL in the background, the outbox retries it
+	id, err := queueEmail(logger.FromContext(c), repository.NewStore(db), utilities.UserLanguage(user, utilities.Language(c)), user.Email, "recovery", user)
 	if err != nil {
-		c.NoContent(http.StatusInternalServerError)
+		return err
 	}
+	sendEmails(logger.FromContext(c), []uint{id})
 
 	// Response success api service
 	return c.JSON(http.StatusOK, utilities.Response{
@@ -128,14 +94,15 @@ func ForgotValidate(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Validations
-	if err := db.Where("id = ? AND key = ?", user.ID, user.Key).First(&user).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("El número %s que ingresaste no coincide con tu código de seguridad. Vuelve a intentarlo", user.Key),
-		})
+	if _, err := service.NewUserService(repository.NewStore(db)).ForgotValidate(user.ID, user.Key); err != nil {
+		return err
 	}
 
 	// Response
@@ -152,29 +119,15 @@ func ForgotChange(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validate
-	currentUser := models.User{}
-	if err := db.Where("id = ?", user.ID).First(&currentUser).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontro ningun registro con el id %d", user.ID),
-		})
-	}
-
-	// Encrypted old password
-	cc := sha256.Sum256([]byte(user.Password))
-	pwd := fmt.Sprintf("%x", cc)
-	user.Password = pwd
-
-	// Update
-	if err := db.Model(&user).Update(user).Error; err != nil {
+	db, err := getConnection(c)
+	if err != nil {
 		return err
 	}
+	defer db.Close()
 
-	// Update key
-	if err := db.Model(&user).UpdateColumn("key", "").Error; err != nil {
+	// Update password and key
+	currentUser, err := service.NewUserService(repository.NewStore(db)).ForgotChange(user.ID, user.Password)
+	if err != nil {
 		return err
 	}
 
@@ -182,7 +135,7 @@ func ForgotChange(c echo.Context) error {
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    user.ID,
-		Message: fmt.Sprintf("La contraseña del usuario %s se cambio exitosamente", currentUser.UserName),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgPasswordChanged, currentUser.UserName),
 	})
 }
 
@@ -194,28 +147,15 @@ func GetUsers(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Pagination calculate
-	if request.CurrentPage == 0 {
-		request.CurrentPage = 1
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
-	offset := request.Limit*request.CurrentPage - request.Limit
-
-	// Check the number of matches
-	var total uint
-	users := make([]models.User, 0)
+	defer db.Close()
 
 	// Find users
-	if err := db.Where("lower(user_name) LIKE lower(?)", "%"+request.Search+"%").
-		Or("lower(dni) LIKE lower(?)", "%"+request.Search+"%").
-		Or("lower(last_name) LIKE lower(?)", "%"+request.Search+"%").
-		Or("lower(first_name) LIKE lower(?)", "%"+request.Search+"%").
-		Order("id desc").
-		Offset(offset).Limit(request.Limit).Find(&users).
-		Offset(-1).Limit(-1).Count(&total).
-		Error; err != nil {
+	users, total, err := service.NewUserService(repository.NewStore(db)).List(newPage(&request))
+	if err != nil {
 		return err
 	}
 
@@ -230,7 +170,7 @@ func GetUsers(c echo.Context) error {
 				UserName: user.UserName,
 			})
 		}
-		return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+		return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 			Success:     true,
 			Data:        customUsers,
 			Total:       total,
@@ -238,7 +178,7 @@ func GetUsers(c echo.Context) error {
 		})
 	}
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.ResponsePaginate{
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
 		Success:     true,
 		Data:        users,
 		Total:       total,
@@ -254,16 +194,20 @@ func GetUserByID(c echo.Context) error {
 	}
 
 	// Get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	// Execute instructions
-	if err := db.First(&user, user.ID).Error; err != nil {
+	user, err = service.NewUserService(repository.NewStore(db)).Get(user.ID)
+	if err != nil {
 		return err
 	}
 
 	// Return response
-	return c.JSON(http.StatusCreated, utilities.Response{
+	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    user,
 	})
@@ -276,71 +220,50 @@ func CreateUser(c echo.Context) error {
 		return err
 	}
 
-	// Default empty values
-	if len(user.Profile) == 0 {
-		user.Profile = "user"
-	}
-
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Hash password
-	cc := sha256.Sum256([]byte(user.Password))
-	pwd := fmt.Sprintf("%x", cc)
-	user.Password = pwd
-
 	// Insert user in database
-	if err := db.Create(&user).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("%s", err),
-		})
+	if err := service.NewUserService(repository.NewStore(db)).Create(&user); err != nil {
+		return err
 	}
 
 	// Return response
 	return c.JSON(http.StatusCreated, utilities.Response{
 		Success: true,
 		Data:    user.ID,
-		Message: fmt.Sprintf("El usuario %s se registro exitosamente", user.UserName),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgUserCreated, user.UserName),
 	})
 }
 
 func UpdateUser(c echo.Context) error {
 	// Get data request
-	newUser := models.User{}
-	if err := c.Bind(&newUser); err != nil {
+	user := models.User{}
+	if err := c.Bind(&user); err != nil {
 		return err
 	}
-	oldUser := models.User{
-		ID: newUser.ID,
-	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation user exist
-	if db.First(&oldUser).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", oldUser.ID),
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
 	// Update user in database
-	if err := db.Model(&newUser).Update(newUser).Error; err != nil {
+	if err := service.NewUserService(repository.NewStore(db)).Update(&user); err != nil {
 		return err
 	}
-	if !newUser.State {
-		if err := db.Model(newUser).UpdateColumn("state", false).Error; err != nil {
-			return err
-		}
-	}
 
 	// Return response
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
-		Data:    newUser.ID,
-		Message: fmt.Sprintf("Los datos del usuario %s, se actualizarón correctamente", oldUser.UserName),
+		Data:    user.ID,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgUserUpdated, user.UserName),
 	})
 }
 
@@ -352,46 +275,29 @@ func DeleteUser(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation user exist
-	if db.First(&user).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", user.ID),
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
 	// Delete user in database
-	if err := db.Delete(&user).Error; err != nil {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("%s", err),
-		})
+	user, err = service.NewUserService(repository.NewStore(db)).Delete(user.ID)
+	if err != nil {
+		return err
 	}
 
 	// Return response
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    user.ID,
-		Message: fmt.Sprintf("El usuario %s, se elimino correctamente", user.UserName),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgUserDeleted, user.UserName),
 	})
 }
 
 func UploadAvatarUser(c echo.Context) error {
 	// Read form fields
-	idUser := c.FormValue("id")
-	user := models.User{}
-
-	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation user exist
-	if db.First(&user, "id = ?", idUser).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", user.ID),
-		})
-	}
+	idUser, _ := strconv.Atoi(c.FormValue("id"))
 
 	// Source
 	file, err := c.FormFile("picture")
@@ -404,24 +310,20 @@ func UploadAvatarUser(c echo.Context) error {
 	}
 	defer src.Close()
 
-	// Destination
-	ccc := sha256.Sum256([]byte(string(user.ID)))
-	name := fmt.Sprintf("%x%s", ccc, filepath.Ext(file.Filename))
-	avatarSRC := "static/profiles/" + name
-	dst, err := os.Create(avatarSRC)
+	// get connection
+	db, err := getConnection(c)
 	if err != nil {
 		return err
 	}
-	defer dst.Close()
-	user.Avatar = avatarSRC
-
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
+	defer db.Close()
+	files, err := uploadFiles()
+	if err != nil {
 		return err
 	}
 
-	// Update database user
-	if err := db.Model(&user).Update(user).Error; err != nil {
+	// Resize and save the avatar, the previous is removed
+	user, err := service.NewPictureService(repository.NewStore(db), files).SetAvatar(uint(idUser), src)
+	if err != nil {
 		return err
 	}
 
@@ -429,10 +331,36 @@ func UploadAvatarUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
 		Data:    user.ID,
-		Message: fmt.Sprintf("El avatar del usuario %s, se subió correctamente", user.UserName),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgAvatarUploaded, user.UserName),
 	})
 }
 
+func DownloadAvatarUser(c echo.Context) error {
+	// Get data request
+	idUser, _ := strconv.Atoi(c.QueryParam("id"))
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Validation user exist
+	user, err := service.NewUserService(repository.NewStore(db)).Get(uint(idUser))
+	if err != nil {
+		return err
+	}
+	key := user.Avatar
+	if c.QueryParam("thumbnail") == "true" {
+		key = user.AvatarThumbnail
+	}
+	if key == "" {
+		return utilities.NewNotFoundError(user.ID)
+	}
+	return streamFile(c, key, "")
+}
+
 func ResetPasswordUser(c echo.Context) error {
 	// Get data request
 	user := models.User{}
@@ -441,29 +369,21 @@ func ResetPasswordUser(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
-	defer db.Close()
-
-	// Validation user exist
-	if db.First(&user, "id = ?", user.ID).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", user.ID),
-		})
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
 	// Set new password
-	cc := sha256.Sum256([]byte(user.DNI + user.UserName))
-	pwd := fmt.Sprintf("%x", cc)
-	user.Password = pwd
-
-	// Update user in database
-	if err := db.Model(&user).Update(user).Error; err != nil {
+	password, err := service.NewUserService(repository.NewStore(db)).ResetPassword(user.ID)
+	if err != nil {
 		return err
 	}
 
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
-		Message: fmt.Sprintf("La contraseña del usuario se reseto extosamente. ahora su numevacontraseña es %s", user.DNI+user.UserName),
+		Message: utilities.Message(utilities.Language(c), utilities.MsgPasswordReset, password),
 	})
 }
 
@@ -475,50 +395,64 @@ func ChangePasswordUser(c echo.Context) error {
 	}
 
 	// get connection
-	db := config.GetConnection()
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	// Validation user exist
-	aux := models.User{ID: user.ID}
-	if db.First(&aux, "id = ?", aux.ID).RecordNotFound() {
-		return c.JSON(http.StatusOK, utilities.Response{
-			Message: fmt.Sprintf("No se encontró el registro con id %d", aux.ID),
-		})
+	// Change password
+	aux, err := service.NewUserService(repository.NewStore(db)).ChangePassword(user.ID, user.OldPassword, user.Password)
+	if err != nil {
+		return err
 	}
 
-	// Change password
-	if len(user.Password) > 0 {
-		// Validate empty length old password
-		if len(user.OldPassword) == 0 {
-			return c.JSON(http.StatusOK, utilities.Response{
-				Message: "Ingrese la contraseña antigua",
-			})
-		}
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Message: utilities.Message(utilities.Language(c), utilities.MsgPasswordChanged, aux.UserName),
+	})
+}
 
-		// Hash old password
-		ccc := sha256.Sum256([]byte(user.OldPassword))
-		old := fmt.Sprintf("%x", ccc)
+// ChangeLocaleUser set the language of the signed in user, the new token has the locale
+func ChangeLocaleUser(c echo.Context) error {
+	// Get user token authenticate
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*utilities.Claim)
+	currentUser := claims.User
 
-		// validate old password
-		if db.Where("password = ?", old).First(&aux).RecordNotFound() {
-			return c.JSON(http.StatusOK, utilities.Response{
-				Message: "La contraseña antigua es incorrecta",
-			})
-		}
+	// Get data request
+	user := models.User{}
+	if err := c.Bind(&user); err != nil {
+		return err
+	}
 
-		// Set and hash new password
-		cc := sha256.Sum256([]byte(user.Password))
-		pwd := fmt.Sprintf("%x", cc)
-		user.Password = pwd
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	// Update user in database
-	if err := db.Model(&user).Update(user).Error; err != nil {
+	// Change locale
+	user, err = service.NewUserService(repository.NewStore(db)).ChangeLocale(currentUser.ID, user.Locale)
+	if err != nil {
+		return err
+	}
+	user.Password = ""
+
+	// get token key
+	newToken, err := utilities.GenerateJWT(user)
+	if err != nil {
 		return err
 	}
 
+	lang := utilities.UserLanguage(user, utilities.AcceptLanguage(c.Request().Header.Get("Accept-Language")))
 	return c.JSON(http.StatusOK, utilities.Response{
 		Success: true,
-		Message: fmt.Sprintf("La contraseña del usuario %s se cambio exitosamente", aux.UserName),
+		Message: utilities.Message(lang, utilities.MsgLocaleChanged),
+		Data: loginDataResponse{
+			User:  user,
+			Token: newToken,
+		},
 	})
 }
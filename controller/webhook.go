@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
+	"net/http"
+	"time"
+)
+
+// deliverWebhooks send the deliveries in the background with its own connection, the connection of
+// the request is closed with the response. The deliveries not sent are retried by the scheduler
+func deliverWebhooks(l *logger.Logger, ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		db, err := config.GetConnection()
+		if err != nil {
+			l.WithError(err).Errorf("webhooks not delivered")
+			return
+		}
+		defer db.Close()
+		if err := service.NewWebhookService(repository.NewStore(db), nil).Deliver(time.Now(), ids...); err != nil {
+			l.WithError(err).Errorf("webhooks not delivered")
+		}
+	}()
+}
+
+func GetWebhooks(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	webhooks, err := service.NewWebhookService(repository.NewStore(db), nil).List(currentUser)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    webhooks,
+	})
+}
+
+func GetWebhookByID(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	webhook := models.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return err
+	}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	webhook, err = service.NewWebhookService(repository.NewStore(db), nil).Get(currentUser, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    webhook,
+	})
+}
+
+// CreateWebhook the secret is only returned in this response
+func CreateWebhook(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	webhook := models.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Insert webhook in database
+	if err := service.NewWebhookService(repository.NewStore(db), nil).Create(currentUser, &webhook); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    webhook,
+	})
+}
+
+func UpdateWebhook(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	webhook := models.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Update webhook in database
+	if err := service.NewWebhookService(repository.NewStore(db), nil).Update(currentUser, &webhook); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    webhook.ID,
+	})
+}
+
+func DeleteWebhook(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	webhook := models.Webhook{}
+	if err := c.Bind(&webhook); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Delete webhook and its deliveries in database
+	if err := service.NewWebhookService(repository.NewStore(db), nil).Delete(currentUser, webhook.ID); err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.Response{
+		Success: true,
+		Data:    webhook.ID,
+	})
+}
+
+// GetWebhookDeliveries log of the deliveries of the webhook, newest first
+func GetWebhookDeliveries(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestWebhook{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	page := utilities.Request{CurrentPage: request.CurrentPage, Limit: request.Limit}
+
+	// Get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Execute instructions
+	deliveries, total, err := service.NewWebhookService(repository.NewStore(db), nil).Deliveries(currentUser, request.ID, newPage(&page))
+	if err != nil {
+		return err
+	}
+
+	// Return response
+	return c.JSON(http.StatusOK, utilities.ResponsePaginate{
+		Success:     true,
+		Data:        deliveries,
+		Total:       total,
+		CurrentPage: page.CurrentPage,
+	})
+}
+
+// RedeliverWebhook send again the payload of the delivery with the id
+func RedeliverWebhook(c echo.Context) error {
+	// Get user token authenticate
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*utilities.Claim)
+	currentUser := claims.User
+
+	// Get data request
+	request := utilities.RequestWebhook{}
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	// get connection
+	db, err := getConnection(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// New delivery sent in the background
+	delivery, err := service.NewWebhookService(repository.NewStore(db), nil).Redeliver(currentUser, request.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	deliverWebhooks(logger.FromContext(c), []uint{delivery.ID})
+
+	// Return response
+	return c.JSON(http.StatusCreated, utilities.Response{
+		Success: true,
+		Data:    delivery,
+	})
+}
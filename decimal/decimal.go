@@ -0,0 +1,291 @@
+// Package decimal exact decimal numbers for the prices, the quantities and the totals.
+//
+// A Decimal is a fixed point number with Scale decimal places persisted as numeric(18,4).
+// The results with more decimal places are rounded half away from zero, the totals of
+// money are rounded to MoneyScale decimal places with Money.
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale decimal places of the prices, quantities and exchange rates
+const Scale = 4
+
+// MoneyScale decimal places of the totals
+const MoneyScale = 2
+
+// SQLType column type of the decimals
+const SQLType = "numeric(18,4)"
+
+// unit 10^Scale
+const unit = 10000
+
+// limit bound of the values, 14 integer digits of numeric(18,4)
+const limit = int64(1e14) * unit
+
+// ErrRange the value does not fit in numeric(18,4)
+var ErrRange = errors.New("decimal: value out of range")
+
+// Decimal exact decimal number, the zero value is 0
+type Decimal struct {
+	units int64 // value * 10^Scale
+}
+
+// Zero decimal 0
+var Zero = Decimal{}
+
+// NewFromInt decimal of a integer
+func NewFromInt(i int64) Decimal {
+	return Decimal{units: i * unit}
+}
+
+// NewFromFloat decimal of a float rounded to Scale, only for values already approximated
+func NewFromFloat(f float64) Decimal {
+	d, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Zero
+	}
+	return d
+}
+
+// Parse decimal of a string like "-12.345", rounded to Scale
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, fmt.Errorf("decimal: invalid number %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Zero, fmt.Errorf("decimal: invalid number %q", s)
+	}
+	return fromRat(r)
+}
+
+// MustParse decimal of a valid string, panics when it is invalid
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// fromRat round the rational to Scale half away from zero
+func fromRat(r *big.Rat) (Decimal, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt64(unit))
+	units := roundRat(scaled)
+	if !units.IsInt64() || units.Int64() >= limit || units.Int64() <= -limit {
+		return Zero, ErrRange
+	}
+	return Decimal{units: units.Int64()}, nil
+}
+
+// roundRat integer nearest to r, half away from zero
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if new(big.Int).Mul(m, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// rat exact rational of the decimal
+func (d Decimal) rat() *big.Rat {
+	return big.NewRat(d.units, unit)
+}
+
+// inRange decimal of the units, ErrRange when they do not fit in numeric(18,4). The units
+// of two decimals in range never overflow int64 when they are added
+func inRange(units int64) (Decimal, error) {
+	if units >= limit || units <= -limit {
+		return Zero, ErrRange
+	}
+	return Decimal{units: units}, nil
+}
+
+// Add d + e, panics when the result is out of range
+func (d Decimal) Add(e Decimal) Decimal {
+	r, err := d.CheckedAdd(e)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// CheckedAdd d + e, ErrRange when the result is out of range
+func (d Decimal) CheckedAdd(e Decimal) (Decimal, error) {
+	return inRange(d.units + e.units)
+}
+
+// Sub d - e, panics when the result is out of range
+func (d Decimal) Sub(e Decimal) Decimal {
+	r, err := inRange(d.units - e.units)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Neg -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Mul d * e rounded to Scale, panics when the result is out of range
+func (d Decimal) Mul(e Decimal) Decimal {
+	r, err := d.CheckedMul(e)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// CheckedMul d * e rounded to Scale, ErrRange when the result is out of range
+func (d Decimal) CheckedMul(e Decimal) (Decimal, error) {
+	return fromRat(new(big.Rat).Mul(d.rat(), e.rat()))
+}
+
+// Div d / e rounded to Scale, panics when e is 0 or the result is out of range
+func (d Decimal) Div(e Decimal) Decimal {
+	if e.units == 0 {
+		panic("decimal: division by zero")
+	}
+	r, err := fromRat(new(big.Rat).Quo(d.rat(), e.rat()))
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Round d rounded to the decimal places, half away from zero
+func (d Decimal) Round(places int) Decimal {
+	if places >= Scale {
+		return d
+	}
+	step := int64(math.Pow10(Scale - places))
+	q, m := d.units/step, d.units%step
+	if m < 0 {
+		m = -m
+	}
+	if m*2 >= step {
+		if d.units < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Decimal{units: q * step}
+}
+
+// Money d rounded to the decimal places of the totals
+func (d Decimal) Money() Decimal {
+	return d.Round(MoneyScale)
+}
+
+// Cmp -1 when d < e, 0 when d == e and +1 when d > e
+func (d Decimal) Cmp(e Decimal) int {
+	switch {
+	case d.units < e.units:
+		return -1
+	case d.units > e.units:
+		return 1
+	}
+	return 0
+}
+
+// Sign -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.Cmp(Zero)
+}
+
+// IsZero check if d == 0
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// Float64 nearest float of the decimal, only to show it
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+// StringFixed decimal with exactly the decimal places
+func (d Decimal) StringFixed(places int) string {
+	d = d.Round(places)
+	sign := ""
+	units := d.units
+	if units < 0 {
+		sign, units = "-", -units
+	}
+	s := fmt.Sprintf("%s%d.%04d", sign, units/unit, units%unit)
+	if places <= 0 {
+		return s[:strings.Index(s, ".")]
+	}
+	if places < Scale {
+		return s[:len(s)-(Scale-places)]
+	}
+	return s + strings.Repeat("0", places-Scale)
+}
+
+// String decimal without trailing zeros
+func (d Decimal) String() string {
+	s := d.StringFixed(Scale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON the decimal is a json number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON from a json number or string, null is 0
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*d = Zero
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value implements driver.Valuer, the decimal is saved as text to keep it exact
+func (d Decimal) Value() (driver.Value, error) {
+	return d.StringFixed(Scale), nil
+}
+
+// Scan implements sql.Scanner
+func (d *Decimal) Scan(value interface{}) error {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		*d = Zero
+	case int64:
+		*d = NewFromInt(v)
+	case float64:
+		*d = NewFromFloat(v)
+	case []byte:
+		*d, err = Parse(string(v))
+	case string:
+		*d, err = Parse(v)
+	default:
+		err = fmt.Errorf("decimal: can not scan %T", value)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAndString(t *testing.T) {
+	cases := map[string]string{
+		"0":         "0",
+		"12.50":     "12.5",
+		"-3":        "-3",
+		"0.00005":   "0.0001", // half away from zero
+		"-0.00005":  "-0.0001",
+		"0.00004":   "0",
+		"1234.5678": "1234.5678",
+	}
+	for in, want := range cases {
+		d, err := Parse(in)
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if d.String() != want {
+			t.Errorf("%s: expected %s, got %s", in, want, d.String())
+		}
+	}
+	if _, err := Parse("1e20"); err != ErrRange {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, err := Parse("abc"); err == nil {
+		t.Error("expected invalid number")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is exact
+	if s := MustParse("0.1").Add(MustParse("0.2")); s != MustParse("0.3") {
+		t.Errorf("expected 0.3, got %s", s)
+	}
+	// 3 x 1.3333 = 3.9999, the money is rounded to cents
+	p := MustParse("1.3333").Mul(NewFromInt(3))
+	if p.String() != "3.9999" || p.Money().StringFixed(2) != "4.00" {
+		t.Errorf("unexpected product %s %s", p, p.Money().StringFixed(2))
+	}
+	// 118 / 1.18 = 100 and 10 / 3 rounded to Scale
+	if q := MustParse("118").Div(MustParse("1.18")); q.String() != "100" {
+		t.Errorf("expected 100, got %s", q)
+	}
+	if q := NewFromInt(10).Div(NewFromInt(3)); q.String() != "3.3333" {
+		t.Errorf("expected 3.3333, got %s", q)
+	}
+	if r := MustParse("-2.345").Round(2); r.String() != "-2.35" {
+		t.Errorf("expected -2.35, got %s", r)
+	}
+	if MustParse("2").Cmp(MustParse("10")) >= 0 || MustParse("-1").Sign() != -1 || !Zero.IsZero() {
+		t.Error("unexpected comparison")
+	}
+}
+
+func TestRange(t *testing.T) {
+	// The largest price by the largest amount fits, ten of those lines do not
+	max := MustParse("9999999")
+	line, err := max.CheckedMul(max)
+	if err != nil || line.String() != "99999980000001" {
+		t.Fatalf("unexpected line %s %v", line, err)
+	}
+	total := Zero
+	for i := 0; i < 10 && err == nil; i++ {
+		total, err = total.CheckedAdd(line)
+	}
+	if err != ErrRange {
+		t.Fatalf("expected ErrRange, got %s %v", total, err)
+	}
+	if _, err := line.CheckedMul(MustParse("3.8")); err != ErrRange {
+		t.Fatalf("expected ErrRange, got %v", err)
+	}
+}
+
+func TestJSONAndSQL(t *testing.T) {
+	var v struct {
+		Price Decimal `json:"price"`
+		Other Decimal `json:"other"`
+	}
+	if err := json.Unmarshal([]byte(`{"price": 10.25, "other": "3.5"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(v)
+	if string(out) != `{"price":10.25,"other":3.5}` {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	value, _ := v.Price.Value()
+	if value != "10.2500" {
+		t.Errorf("unexpected sql value %v", value)
+	}
+	var d Decimal
+	for _, src := range []interface{}{[]byte("10.25"), "10.25", 10.25} {
+		if err := d.Scan(src); err != nil || d != v.Price {
+			t.Errorf("scan %T: got %s %v", src, d, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package imaging decodes the uploaded pictures and scales them to the standard sizes of the
+// avatars and the logo, without dependencies out of the standard library
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Decoder of the gif pictures
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels largest picture decoded, the small files of huge pictures use a lot of memory
+const MaxPixels = 40 << 20
+
+var (
+	// ErrFormat the content is not a png, jpeg or gif picture
+	ErrFormat = errors.New("imaging: unsupported picture format")
+	// ErrTooLarge the picture has more than MaxPixels
+	ErrTooLarge = errors.New("imaging: picture too large")
+)
+
+// formats types detected from the content of the supported pictures
+var formats = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true}
+
+// Decode the picture, the type is detected from the content and not from the name
+func Decode(data []byte) (image.Image, error) {
+	if !formats[http.DetectContentType(data)] {
+		return nil, ErrFormat
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrFormat
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrFormat
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrFormat
+	}
+	return img, nil
+}
+
+// Fill scale the picture to cover the size and crop the center, for the avatars
+func Fill(img image.Image, width int, height int) image.Image {
+	b := img.Bounds()
+	crop := b
+	if b.Dx()*height > b.Dy()*width {
+		w := b.Dy() * width / height
+		crop.Min.X = b.Min.X + (b.Dx()-w)/2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := b.Dx() * height / width
+		crop.Min.Y = b.Min.Y + (b.Dy()-h)/2
+		crop.Max.Y = crop.Min.Y + h
+	}
+	return scale(img, crop, width, height)
+}
+
+// Fit scale down the picture to fit in the size keeping its proportions, for the logo.
+// The small pictures are not enlarged
+func Fit(img image.Image, width int, height int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width && b.Dy() <= height {
+		return scale(img, b, b.Dx(), b.Dy())
+	}
+	w, h := width, b.Dy()*width/b.Dx()
+	if h > height {
+		w, h = b.Dx()*height/b.Dy(), height
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return scale(img, b, w, h)
+}
+
+// scale the rectangle of the picture to the size, every pixel is the average of the source
+// pixels it covers. The colors are averaged premultiplied so the transparent borders are clean
+func scale(img image.Image, r image.Rectangle, width int, height int) *image.RGBA {
+	src := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(src, src.Bounds(), img, r.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	sw, sh := r.Dx(), r.Dy()
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					sum[0] += int(p[0])
+					sum[1] += int(p[1])
+					sum[2] += int(p[2])
+					sum[3] += int(p[3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			for k := range d {
+				d[k] = uint8((sum[k] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// PNG encode the picture with its transparency
+func PNG(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, img)
+	return buf.Bytes(), err
+}
+
+// JPEG encode the picture over a white background
+func JPEG(img image.Image) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, flat, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), err
+}
@@ -0,0 +1,69 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func picture(width int, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Left half red, right half blue
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestDecode(t *testing.T) {
+	data, err := PNG(picture(10, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+		t.Errorf("unexpected size %v", img.Bounds())
+	}
+	if _, err := Decode([]byte("%PDF-1.4 not a picture")); err != ErrFormat {
+		t.Errorf("expected format error, got %v", err)
+	}
+	// A png header of a huge picture
+	if _, err := Decode(data[:16]); err != ErrFormat {
+		t.Errorf("expected format error for a truncated picture, got %v", err)
+	}
+}
+
+func TestFillAndFit(t *testing.T) {
+	// The square avatar is the center of the wide picture
+	avatar := Fill(picture(400, 100), 50, 50)
+	if b := avatar.Bounds(); b.Dx() != 50 || b.Dy() != 50 {
+		t.Fatalf("unexpected avatar size %v", b)
+	}
+	if r, _, b, _ := avatar.At(0, 25).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("expected red on the left of the avatar")
+	}
+
+	logo := Fit(picture(1000, 200), 500, 250)
+	if b := logo.Bounds(); b.Dx() != 500 || b.Dy() != 100 {
+		t.Errorf("unexpected logo size %v", b)
+	}
+	small := Fit(picture(40, 20), 500, 250)
+	if b := small.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Errorf("the small logo must keep its size, got %v", b)
+	}
+
+	// Every pixel is the average of the pixels it covers
+	half := Fit(picture(4, 1), 2, 1).(*image.RGBA)
+	if c := half.RGBAAt(0, 0); c.R != 255 || c.B != 0 {
+		t.Errorf("unexpected color %v", c)
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// SQLLogger adapter that receives the gorm log calls
+type SQLLogger struct {
+	l *Logger
+}
+
+// SQL return a gorm logger that writes SQL statements with the fields of l
+func (l *Logger) SQL() SQLLogger {
+	return SQLLogger{l: l.WithField("component", "sql")}
+}
+
+// Print implements the gorm logger interface
+// sql entries: "sql", source, duration, statement, vars, rows affected
+// any other:   "log", source, values...
+func (s SQLLogger) Print(values ...interface{}) {
+	if len(values) < 2 {
+		s.l.Errorf("%s", fmt.Sprint(values...))
+		return
+	}
+
+	if values[0] == "sql" && len(values) >= 6 {
+		fields := Fields{
+			"source": values[1],
+			"sql":    values[3],
+			"vars":   fmt.Sprintf("%v", values[4]),
+			"rows":   values[5],
+		}
+		if d, ok := values[2].(time.Duration); ok {
+			fields["duration_ms"] = float64(d.Nanoseconds()) / 1e6
+		}
+		s.l.With(fields).Debugf("sql executed")
+		return
+	}
+
+	if values[0] == "log" {
+		s.l.WithField("source", values[1]).Errorf("%s", fmt.Sprint(values[2:]...))
+		return
+	}
+
+	// gorm errors without log mode arrive as source, error
+	s.l.WithField("source", values[0]).Errorf("%s", fmt.Sprint(values[1:]...))
+}
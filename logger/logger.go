@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level severity of a log entry
+type Level uint8
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARN
+	ERROR
+)
+
+var levelNames = map[Level]string{
+	DEBUG: "debug",
+	INFO:  "info",
+	WARN:  "warn",
+	ERROR: "error",
+}
+
+// String name of the level as written in the json output
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel get level by name, unknown names return INFO
+func ParseLevel(name string) Level {
+	for level, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return level
+		}
+	}
+	return INFO
+}
+
+// Fields extra data attached to every entry of a logger
+type Fields map[string]interface{}
+
+// output shared writer between a logger and all its children
+type output struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// Logger structured logger that writes one json object per line
+type Logger struct {
+	out    *output
+	fields Fields
+}
+
+var std = New(os.Stdout, INFO)
+
+// New create logger writing in w entries with level greater or equal than level
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{
+		out:    &output{w: w, level: level},
+		fields: Fields{},
+	}
+}
+
+// Default return the logger of the application
+func Default() *Logger {
+	return std
+}
+
+// SetLevel change the minimum level of the logger and all its children
+func (l *Logger) SetLevel(level Level) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.level = level
+}
+
+// With return a child logger that adds fields to every entry
+func (l *Logger) With(fields Fields) *Logger {
+	child := &Logger{
+		out:    l.out,
+		fields: make(Fields, len(l.fields)+len(fields)),
+	}
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	for k, v := range fields {
+		child.fields[k] = v
+	}
+	return child
+}
+
+// RequestID return the request id added by the middleware, empty out of a request
+func (l *Logger) RequestID() string {
+	rid, _ := l.fields["request_id"].(string)
+	return rid
+}
+
+// WithField return a child logger that adds a single field to every entry
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.With(Fields{key: value})
+}
+
+// WithError return a child logger with the error message attached
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l
+	}
+	return l.WithField("error", err.Error())
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.write(DEBUG, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.write(INFO, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.write(WARN, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.write(ERROR, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) write(level Level, message string) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	if level < l.out.level {
+		return
+	}
+
+	entry := make(Fields, len(l.fields)+3)
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["message"] = message
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(Fields{"level": ERROR.String(), "message": message, "error": err.Error()})
+	}
+	l.out.w.Write(append(b, '\n'))
+}
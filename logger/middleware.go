@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/gommon/random"
+)
+
+const contextKey = "logger"
+
+// Middleware assign a request id to every request, returns it in the X-Request-ID
+// header and stores in the context a logger that adds it to every entry
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			res := c.Response()
+
+			rid := req.Header.Get(echo.HeaderXRequestID)
+			if rid == "" {
+				rid = random.String(32)
+			}
+			res.Header().Set(echo.HeaderXRequestID, rid)
+
+			l := std.WithField("request_id", rid)
+			c.Set(contextKey, l)
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			l = l.With(Fields{
+				"method":     req.Method,
+				"path":       req.URL.Path, // the query can have tokens
+				"remote_ip":  c.RealIP(),
+				"status":     res.Status,
+				"latency_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+			}).WithError(err)
+			switch {
+			case res.Status >= 500:
+				l.Errorf("request failed")
+			case res.Status >= 400:
+				l.Warnf("request rejected")
+			default:
+				l.Infof("request completed")
+			}
+			return nil
+		}
+	}
+}
+
+// FromContext return the logger of the request, or the default logger when
+// the middleware did not run
+func FromContext(c echo.Context) *Logger {
+	if l, ok := c.Get(contextKey).(*Logger); ok {
+		return l
+	}
+	return std
+}
@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Dir sender of development, the messages are written as .eml files in the directory to open
+// them with a email client instead of sending them
+type Dir struct {
+	From mail.Address
+	Path string
+}
+
+func (d Dir) Send(m Message) error {
+	now := time.Now()
+	raw, err := Build(d.From, m, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.Path, 0755); err != nil {
+		return err
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405"), hex.EncodeToString(b))
+	return ioutil.WriteFile(filepath.Join(d.Path, name), raw, 0644)
+}
@@ -0,0 +1,173 @@
+// Package mailer builds the emails of the application and sends them by SMTP or, in development,
+// writes them to a directory
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment file attached to a email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message email to a recipient with a plain text and a html body, any of them can be empty
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Sender delivers the messages
+type Sender interface {
+	Send(m Message) error
+}
+
+// Build headers and body of the message: multipart/alternative with the text and the html, inside
+// multipart/mixed when there are attachments
+func Build(from mail.Address, m Message, date time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, err
+	}
+	id, err := messageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "Message-ID: %s\r\n", id)
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+
+	header, body, err := buildBody(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) == 0 {
+		for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if v := header.Get(k); v != "" {
+				fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+			}
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return nil, err
+	}
+
+	// Attachments in base64, lines of 76 characters
+	for _, a := range m.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": a.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			if _, err := io.WriteString(part, encoded[:76]+"\r\n"); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := io.WriteString(part, encoded+"\r\n"); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildBody headers and content of the body with the text and the html of the message
+func buildBody(m Message) (textproto.MIMEHeader, []byte, error) {
+	buf := new(bytes.Buffer)
+
+	// Only one of the bodies
+	if m.Text == "" || m.HTML == "" {
+		contentType, content := `text/html; charset="UTF-8"`, m.HTML
+		if m.HTML == "" {
+			contentType, content = `text/plain; charset="UTF-8"`, m.Text
+		}
+		if err := writeQuoted(buf, content); err != nil {
+			return nil, nil, err
+		}
+		return textproto.MIMEHeader{"Content-Type": {contentType}, "Content-Transfer-Encoding": {"quoted-printable"}}, buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(buf)
+	for _, p := range []struct{ contentType, content string }{
+		{`text/plain; charset="UTF-8"`, m.Text},
+		{`text/html; charset="UTF-8"`, m.HTML},
+	} {
+		part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {p.contentType}, "Content-Transfer-Encoding": {"quoted-printable"}})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQuoted(part, p.content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())}}, buf.Bytes(), nil
+}
+
+// writeQuoted write the content in quoted-printable, the long lines of the html are wrapped
+func writeQuoted(w io.Writer, content string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qw, content); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// messageID unique id of a message in the domain of the sender
+func messageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
@@ -0,0 +1,225 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var from = mail.Address{Name: "RW", Address: "from@example.com"}
+
+func TestBuildWithAttachment(t *testing.T) {
+	data := bytes.Repeat([]byte("requirement;"), 20)
+	raw, err := Build(from, Message{
+		To:          "to@example.com",
+		Subject:     "Solicitud de cotización",
+		Text:        "Hola",
+		HTML:        "<p>Hola</p>",
+		Attachments: []Attachment{{Name: "rfq.xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Data: data}},
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Solicitud de cotización" || msg.Header.Get("Message-ID") == "" {
+		t.Fatalf("unexpected headers %q %v", subject, msg.Header)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("unexpected content type %q %v", mediaType, err)
+	}
+
+	// The text and the html are alternatives
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, _ = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected body %q", mediaType)
+	}
+	alternatives := multipart.NewReader(body, params["boundary"])
+	for _, want := range []string{"Hola", "<p>Hola</p>"} {
+		part, err := alternatives.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content, _ := ioutil.ReadAll(part); string(content) != want {
+			t.Fatalf("unexpected alternative %q", content)
+		}
+	}
+
+	attachment, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "rfq.xlsx" {
+		t.Fatalf("unexpected file name %q", attachment.FileName())
+	}
+	// multipart.Part decodes quoted-printable only, the base64 is decoded here
+	encoded, _ := ioutil.ReadAll(attachment)
+	decoded, err := base64.StdEncoding.DecodeString(strings.Replace(string(encoded), "\r\n", "", -1))
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("attachment content differs: %v", err)
+	}
+}
+
+func TestTemplatesAndDir(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "recovery.txt"), []byte("{{define \"subject\"}}Código {{.}}{{end}}\nTu código es {{.}}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "recovery.html"), []byte("<p>Tu código es {{.}}</p>"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "plain.txt"), []byte("{{define \"subject\"}}Plain{{end}}Hola"), 0644)
+
+	m, err := Templates{Dir: dir}.Render("recovery", "<1234>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Subject != "Código <1234>" || m.Text != "Tu código es <1234>\n" || m.HTML != "<p>Tu código es &lt;1234&gt;</p>" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	if m, err := (Templates{Dir: dir}).Render("plain", nil); err != nil || m.HTML != "" || m.Text != "Hola\n" {
+		t.Fatalf("unexpected plain message %+v %v", m, err)
+	}
+	if _, err := (Templates{Dir: dir}).Render("missing", nil); err == nil {
+		t.Fatal("expected error for a missing template")
+	}
+
+	// Development writes the emails
+	out := filepath.Join(dir, "out")
+	m.To = "to@example.com"
+	if err := (Dir{From: from, Path: out}).Send(m); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := ioutil.ReadDir(out)
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), ".eml") {
+		t.Fatalf("expected a .eml file, got %v", files)
+	}
+}
+
+// catcher fake SMTP server with STARTTLS that keeps the received messages
+type catcher struct {
+	listener net.Listener
+	config   *tls.Config
+	messages chan string
+}
+
+func newCatcher(t *testing.T) (*catcher, *tls.Config) {
+	// The test certificate of httptest is valid for example.com
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	c := &catcher{listener: l, config: &tls.Config{Certificates: srv.TLS.Certificates}, messages: make(chan string, 1)}
+	go c.serve()
+	return c, &tls.Config{RootCAs: roots}
+}
+
+func (c *catcher) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.session(conn)
+	}
+}
+
+func (c *catcher) session(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 catcher ESMTP")
+	secure := false
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+		case "EHLO":
+			if secure {
+				tp.PrintfLine("250-catcher\r\n250 AUTH PLAIN")
+			} else {
+				tp.PrintfLine("250-catcher\r\n250 STARTTLS")
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tc := tls.Server(conn, c.config)
+			if tc.Handshake() != nil {
+				return
+			}
+			conn, secure = tc, true
+			tp = textproto.NewConn(tc)
+		case "AUTH":
+			tp.PrintfLine("235 ok")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go")
+			data, _ := ioutil.ReadAll(tp.DotReader())
+			c.messages <- string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 %s", verb)
+		}
+	}
+}
+
+func TestSMTPStartTLS(t *testing.T) {
+	c, trusted := newCatcher(t)
+	m := Message{To: "to@example.com", Subject: "Hola", Text: "Texto con acentos: cotización"}
+
+	// The certificate is verified with the name of the host
+	s := &SMTP{From: from, Server: c.listener.Addr().String(), Host: "example.com", Username: "rw", Password: "secret", Security: SecurityStartTLS, TLSConfig: trusted}
+	if err := s.Send(m); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(<-c.messages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := ioutil.ReadAll(quotedprintable.NewReader(msg.Body))
+	if msg.Header.Get("Subject") != "Hola" || strings.TrimSpace(string(text)) != m.Text {
+		t.Fatalf("unexpected message %v %q", msg.Header, text)
+	}
+
+	// A certificate of other host or not trusted is rejected
+	s.Host = "other.example"
+	if err := s.Send(m); err == nil {
+		t.Fatal("expected error for a certificate of other host")
+	}
+	s.Host, s.TLSConfig = "example.com", nil
+	if err := s.Send(m); err == nil {
+		t.Fatal("expected error for a certificate not trusted")
+	}
+	if len(c.messages) != 0 {
+		t.Fatal("the message was sent without a verified connection")
+	}
+}
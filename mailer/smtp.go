@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// Security of the connection with the SMTP server
+const (
+	SecurityTLS      = "tls"      // Implicit TLS from the start, usually the port 465
+	SecurityStartTLS = "starttls" // Plain connection upgraded with STARTTLS, usually the port 587
+	SecurityNone     = "none"     // Without encryption, only for a local catcher as MailHog
+)
+
+// ErrNoStartTLS the server does not offer STARTTLS, the message is not sent in plain text
+var ErrNoStartTLS = errors.New("mailer: the server does not support STARTTLS")
+
+// SMTP sender through a SMTP server, the certificate of the server is verified against Host
+type SMTP struct {
+	From      mail.Address
+	Server    string // host:port
+	Host      string // Name of the server in its certificate
+	Username  string // Without authentication when it is empty
+	Password  string
+	Security  string
+	TLSConfig *tls.Config // nil verifies with the roots of the system
+	Timeout   time.Duration
+}
+
+// Permanent check if the error is a rejection of the server that fails again when it is retried
+func Permanent(err error) bool {
+	if e, ok := err.(*textproto.Error); ok {
+		return e.Code >= 500
+	}
+	return false
+}
+
+func (s *SMTP) tlsConfig() *tls.Config {
+	if s.TLSConfig != nil {
+		c := s.TLSConfig.Clone()
+		if c.ServerName == "" {
+			c.ServerName = s.Host
+		}
+		return c
+	}
+	return &tls.Config{ServerName: s.Host}
+}
+
+func (s *SMTP) Send(m Message) error {
+	raw, err := Build(s.From, m, time.Now())
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	conn, err := net.DialTimeout("tcp", s.Server, timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if s.Security == SecurityTLS {
+		tc := tls.Client(conn, s.tlsConfig())
+		if err := tc.Handshake(); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tc
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return ErrNoStartTLS
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.From.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates named emails of a directory. The email name has name.txt with the plain text and
+// the subject in a "subject" block, and optionally name.html with the html body
+type Templates struct {
+	Dir string
+}
+
+// Render the email with the data, the recipient is set by the caller
+func (t Templates) Render(name string, data interface{}) (Message, error) {
+	m := Message{}
+	text, err := texttemplate.ParseFiles(filepath.Join(t.Dir, name+".txt"))
+	if err != nil {
+		return m, err
+	}
+	buf := new(bytes.Buffer)
+	if err := text.ExecuteTemplate(buf, "subject", data); err != nil {
+		return m, err
+	}
+	m.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	buf.Reset()
+	if err := text.Execute(buf, data); err != nil {
+		return m, err
+	}
+	m.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	html, err := htmltemplate.ParseFiles(filepath.Join(t.Dir, name+".html"))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	buf.Reset()
+	if err := html.Execute(buf, data); err != nil {
+		return m, err
+	}
+	m.HTML = buf.String()
+	return m, nil
+}
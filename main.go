@@ -4,27 +4,45 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/paulantezana/requirement/api"
 	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/logger"
 	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/scheduler"
+	"github.com/paulantezana/requirement/service"
+	"github.com/paulantezana/requirement/utilities"
 )
 
 func main() {
+	// Logger level
+	log := logger.Default()
+	log.SetLevel(logger.ParseLevel(config.GetConfig().Log.Level))
+
 	e := echo.New()
-	e.Use(middleware.Logger())
+	e.HideBanner = true
+	e.HTTPErrorHandler = utilities.HTTPErrorHandler
+	e.Validator = &utilities.Validator{}
+	e.Use(logger.Middleware())
 	e.Use(middleware.Recover())
 
 	// Initialize migration database
-	migration()
+	if err := migration(); err != nil {
+		log.WithError(err).Errorf("migration failed")
+		os.Exit(1)
+	}
 
 	// COR
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"X-Requested-With", "Content-Type", "Authorization"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.DELETE, echo.PUT},
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{"X-Requested-With", "Content-Type", "Authorization", echo.HeaderXRequestID},
+		ExposeHeaders: []string{echo.HeaderXRequestID},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.DELETE, echo.PUT},
 	}))
 
 	// Static Files =========================================================================
@@ -35,6 +53,14 @@ func main() {
 	api.PublicApi(e)
 	api.ProtectedApi(e)
 
+	// Jobs in background
+	jobs := scheduler.New(time.Minute)
+	jobs.Add("templates", runTemplates)
+	jobs.Add("webhooks", runWebhooks)
+	jobs.Add("emails", runEmails)
+	jobs.Start()
+	defer jobs.Stop()
+
 	// Custom port
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -42,35 +68,71 @@ func main() {
 	}
 
 	// Starting server echo
-	e.Logger.Fatal(e.Start(":" + port))
+	log.WithField("port", port).Infof("server started")
+	if err := e.Start(":" + port); err != nil {
+		log.WithError(err).Errorf("server stopped")
+		os.Exit(1)
+	}
 }
 
-// migration Init migration database
-func migration() {
-	db := config.GetConnection()
+// runTemplates create the requirements of the recurrent templates
+func runTemplates(now time.Time) error {
+	db, err := config.GetConnection()
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
-	db.Debug().AutoMigrate(
-		&models.User{},
-		&models.Quotation{},
-		&models.QuotationDetail{},
-		&models.Product{},
-		&models.Provider{},
-		&models.Requirement{},
-		&models.Require{},
-		&models.Setting{},
-	)
-	db.Model(&models.Requirement{}).AddForeignKey("user_id", "users(id)", "RESTRICT", "RESTRICT")
+	store := repository.NewStore(db)
+	created, err := service.NewTemplateService(store).RunDue(now)
+	for _, requirement := range created {
+		logger.Default().WithField("requirement", requirement.ID).Infof("requirement created by template")
+		// Sent by the webhooks job
+		event := service.Event{Type: models.EventRequirementCreated, ActorID: requirement.UserID, RequirementID: requirement.ID}
+		if _, err := service.NewWebhookService(store, nil).Enqueue(event, now); err != nil {
+			logger.Default().WithError(err).Errorf("webhooks not enqueued")
+		}
+	}
+	return err
+}
 
-	db.Model(&models.Require{}).AddForeignKey("requirement_id", "requirements(id)", "RESTRICT", "RESTRICT")
-	db.Model(&models.Require{}).AddForeignKey("product_id", "products(id)", "RESTRICT", "RESTRICT")
+// runWebhooks send the deliveries of the webhooks due, the first attempts and the retries
+func runWebhooks(now time.Time) error {
+	db, err := config.GetConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
-	db.Model(&models.QuotationDetail{}).AddForeignKey("require_id", "requires(id)", "RESTRICT", "RESTRICT")
-	db.Model(&models.QuotationDetail{}).AddForeignKey("quotation_id", "quotations(id)", "RESTRICT", "RESTRICT")
+	return service.NewWebhookService(repository.NewStore(db), nil).Deliver(now)
+}
 
-	db.Model(&models.Quotation{}).AddForeignKey("user_id", "users(id)", "RESTRICT", "RESTRICT")
-	db.Model(&models.Quotation{}).AddForeignKey("provider_id", "providers(id)", "RESTRICT", "RESTRICT")
-	db.Model(&models.Quotation{}).AddForeignKey("requirement_id", "requirements(id)", "RESTRICT", "RESTRICT")
+// runEmails send the emails of the outbox due, the first attempts and the retries
+func runEmails(now time.Time) error {
+	sender, err := config.GetMailer()
+	if err != nil {
+		return err
+	}
+	db, err := config.GetConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return service.NewOutboxService(repository.NewStore(db), sender).Send(now)
+}
+
+// migration Init migration database
+func migration() error {
+	db, err := config.GetConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := repository.Migrate(db); err != nil {
+		return err
+	}
 
 	// -------------------------------------------------------------
 	// INSERT FIST DATA --------------------------------------------
@@ -100,10 +162,14 @@ func migration() {
 		CompanyName:      "REQUIREMENT WEB",
 		CompanyShortName: "RW",
 		Quotations:       3,
+		Currency:         "PEN",
+		TaxRate:          decimal.NewFromInt(18),
 		Logo:             "static/logo.png",
 	}
 	// Insert database
 	if cg.ID == 0 {
 		db.Create(&co)
 	}
+
+	return nil
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Owners of the attachments
+const (
+	AttachmentRequirement = "requirement"
+	AttachmentQuotation   = "quotation"
+	AttachmentProvider    = "provider"
+	AttachmentComment     = "comment"
+)
+
+// Attachment file attached to a requirement, a quotation, a provider or a comment: technical
+// specs, proformas, legal documents. The content is in the upload directory
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Owner       string    `json:"owner" gorm:"type:varchar(16); index:idx_attachment_owner" validate:"required,oneof=requirement quotation provider comment"`
+	OwnerID     uint      `json:"owner_id" gorm:"index:idx_attachment_owner" validate:"required"`
+	Name        string    `json:"name" gorm:"not null" validate:"required,max=255"` // Original name of the file
+	ContentType string    `json:"content_type" gorm:"type:varchar(128)"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum" gorm:"type:varchar(64)"` // SHA-256 of the content in hex
+	Path        string    `json:"-"`                                // Relative to the upload directory
+	UserID      uint      `json:"user_id"`
+}
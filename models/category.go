@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
+
+// Category group of products, the providers sell all the products of its categories
+type Category struct {
+	ID          uint      `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Name        string    `json:"name" gorm:"type:varchar(128); not null; unique" validate:"required,max=128"`
+	Description string    `json:"description" gorm:"type:varchar(255)" validate:"max=255"`
+	State       bool      `json:"state"`
+}
+
+// ProviderCategory category of products sold by a provider
+type ProviderCategory struct {
+	ID           uint   `json:"id" gorm:"primary_key"`
+	ProviderID   uint   `json:"provider_id" gorm:"unique_index:idx_provider_category"`
+	CategoryID   uint   `json:"category_id" gorm:"unique_index:idx_provider_category" validate:"required"`
+	CategoryName string `json:"category_name" gorm:"-"`
+}
+
+// ProviderProduct product sold by a provider with its code in the catalog of the provider and the
+// last price it quoted. The products quoted are added to the catalog
+type ProviderProduct struct {
+	ID           uint            `json:"id" gorm:"primary_key"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	ProviderID   uint            `json:"provider_id" gorm:"unique_index:idx_provider_product"`
+	ProductID    uint            `json:"product_id" gorm:"unique_index:idx_provider_product" validate:"required"`
+	ProductName  string          `json:"product_name" gorm:"-"`
+	Reference    string          `json:"reference" gorm:"type:varchar(64)" validate:"max=64"`
+	LastPrice    decimal.Decimal `json:"last_price" gorm:"type:numeric(18,4)"`
+	LastCurrency string          `json:"last_currency" gorm:"type:varchar(3)"`
+	LastQuotedAt *time.Time      `json:"last_quoted_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Owners of the comments
+const (
+	CommentRequirement = "requirement"
+	CommentQuotation   = "quotation"
+)
+
+// Comment message of the discussion of a requirement or a quotation. The discussion of the
+// quotations is also in the activity of its requirement
+type Comment struct {
+	ID            uint       `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	EditedAt      *time.Time `json:"edited_at"` // Last change of the body by the author
+	Owner         string     `json:"owner" gorm:"type:varchar(16); index:idx_comment_owner" validate:"required,oneof=requirement quotation"`
+	OwnerID       uint       `json:"owner_id" gorm:"index:idx_comment_owner" validate:"required"`
+	Body          string     `json:"body" gorm:"type:text; not null" validate:"required,max=4000"`
+	RequirementID uint       `json:"requirement_id" gorm:"index"` // Requirement of the discussion, the requirement of the quotation
+	UserID        uint       `json:"user_id"`                     // Author
+	UserName      string     `json:"user_name" gorm:"-"`
+
+	Mentions    []Mention    `json:"mentions"`
+	Attachments []Attachment `json:"attachments" gorm:"-"`
+}
+
+// Mention user named with @user_name in a comment
+type Mention struct {
+	ID        uint   `json:"id" gorm:"primary_key"`
+	CommentID uint   `json:"comment_id" gorm:"index"`
+	UserID    uint   `json:"user_id"`
+	UserName  string `json:"user_name" gorm:"type:varchar(64)"`
+}
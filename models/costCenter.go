@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
+
+// CostCenter area of the company that pays the requirements, with a budget by year
+type CostCenter struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Code      string    `json:"code" gorm:"type:varchar(32); not null; unique" validate:"required,max=32"`
+	Name      string    `json:"name" gorm:"not null" validate:"required,max=255"`
+	State     bool      `json:"state"`
+
+	Budgets []Budget `json:"budgets"`
+}
+
+// Budget amount of a cost center for a year in the base currency of the setting
+type Budget struct {
+	ID           uint            `json:"id" gorm:"primary_key"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Year         uint            `json:"year" gorm:"unique_index:idx_budget" validate:"min=2000,max=9999"`
+	Amount       decimal.Decimal `json:"amount" gorm:"type:numeric(18,4)" validate:"min=0"`
+	CostCenterID uint            `json:"cost_center_id" gorm:"unique_index:idx_budget" validate:"required"`
+}
+
+// Commitment part of the budget of a cost center taken by the award of a requirement: the total
+// with IGV of the winner in the base currency. It is consumed when the requirement is received
+type Commitment struct {
+	ID            uint            `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Date          time.Time       `json:"date"` // Award date, the year of the budget
+	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(18,4)"`
+	ConsumedAt    *time.Time      `json:"consumed_at"` // Reception of the requirement when it was closed
+	CostCenterID  uint            `json:"cost_center_id"`
+	RequirementID uint            `json:"requirement_id" gorm:"unique"`
+	QuotationID   uint            `json:"quotation_id"`
+}
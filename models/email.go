@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// States of the emails of the outbox
+const (
+	EmailPending = "pending" // Waiting for its next attempt
+	EmailSent    = "sent"    // Accepted by the server
+	EmailFailed  = "failed"  // Rejected by the server or all the attempts failed
+)
+
+// Email message of the outbox, sent in the background and retried until the server accepts it
+type Email struct {
+	ID          uint       `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Recipient   string     `json:"recipient" gorm:"type:varchar(128)" validate:"required,email,max=128"`
+	Subject     string     `json:"subject" validate:"required,max=255"`
+	Template    string     `json:"template" gorm:"type:varchar(32)"`   // Name of the template rendered
+	RequestID   string     `json:"request_id" gorm:"type:varchar(64)"` // Request that queued the email, to match the logs
+	Text        string     `json:"text" gorm:"type:text"`
+	HTML        string     `json:"html" gorm:"type:text"`
+	State       string     `json:"state" gorm:"type:varchar(15); index"`
+	Attempts    uint       `json:"attempts"`
+	NextAttempt *time.Time `json:"next_attempt" gorm:"index"` // nil when it is not retried
+	Error       string     `json:"error" gorm:"type:text"`    // Error of the last attempt
+	SentAt      *time.Time `json:"sent_at"`
+
+	Attachments []EmailAttachment `json:"attachments"`
+}
+
+// EmailAttachment file attached to a email of the outbox
+type EmailAttachment struct {
+	ID          uint   `json:"id" gorm:"primary_key"`
+	EmailID     uint   `json:"email_id" gorm:"index"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type" gorm:"type:varchar(128)"`
+	Data        []byte `json:"-"`
+}
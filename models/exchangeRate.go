@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
+
+// ExchangeRate value in the base currency of the setting of one unit of the currency on the date
+type ExchangeRate struct {
+	ID        uint            `json:"id" gorm:"primary_key"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Date      time.Time       `json:"date" gorm:"unique_index:idx_exchange_rate" validate:"required"`
+	Currency  string          `json:"currency" gorm:"type:varchar(3);unique_index:idx_exchange_rate" validate:"required,currency"`
+	Rate      decimal.Decimal `json:"rate" gorm:"type:numeric(18,4)" validate:"gt=0"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Events of the requirements and quotations
+const (
+	EventRequirementCreated  = "requirement.created"
+	EventRequirementRejected = "requirement.rejected"
+	EventRequirementClosed   = "requirement.closed"
+	EventBidsOpened          = "requirement.opened"
+	EventQuotationCreated    = "quotation.created"
+	EventQuotationUpdated    = "quotation.updated"
+	EventQuotationDeleted    = "quotation.deleted"
+	EventQuotationAwarded    = "quotation.awarded"
+	EventCommentMention      = "comment.mention"
+)
+
+// Events all the events, in the order they happen to a requirement
+var Events = []string{
+	EventRequirementCreated,
+	EventQuotationCreated,
+	EventQuotationUpdated,
+	EventQuotationDeleted,
+	EventBidsOpened,
+	EventQuotationAwarded,
+	EventRequirementRejected,
+	EventRequirementClosed,
+	EventCommentMention,
+}
+
+// Notification event of a requirement notified to a user, unread until the user reads it
+type Notification struct {
+	ID            uint       `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Event         string     `json:"event" gorm:"type:varchar(32)"`
+	UserID        uint       `json:"user_id" gorm:"index"` // Recipient
+	ActorID       uint       `json:"actor_id"`             // User that made the change, 0 for the providers in the portal
+	RequirementID uint       `json:"requirement_id"`
+	QuotationID   uint       `json:"quotation_id"`
+	CommentID     uint       `json:"comment_id"`
+	Subject       string     `json:"subject"`              // Name of the requirement when the event happened
+	Message       string     `json:"message" gorm:"-"`     // Text of the event
+	ReadAt        *time.Time `json:"read_at" gorm:"index"` // nil while unread
+}
+
+// NotificationPreference choice of the user to receive the notifications of the event also by email
+type NotificationPreference struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	UserID uint   `json:"user_id" gorm:"unique_index:idx_preference_event"`
+	Event  string `json:"event" gorm:"type:varchar(32); unique_index:idx_preference_event" validate:"required"`
+	Email  bool   `json:"email"`
+}
@@ -6,10 +6,12 @@ type Product struct {
 	ID          uint      `json:"id" gorm:"primary_key"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null" validate:"required,max=255"`
 	UnitMeasure string    `json:"unit_measure"`
 	Type        string    `json:"type"`
 	State       bool      `json:"state"`
+	Exempt      bool      `json:"exempt"` // Exempt of the IGV, its lines are not taxed
+	CategoryID  *uint     `json:"category_id"`
 
 	Requires []Require `json:"requires"`
 }
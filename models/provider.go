@@ -6,14 +6,15 @@ type Provider struct {
 	ID          uint      `json:"id" gorm:"primary_key"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name" gorm:"not null"`
-	RUC         string    `json:"ruc" gorm:"type:varchar(15); not null; unique"`
-	Manager     string    `json:"manager" gorm:"type:varchar(255)"`
-	Email       string    `json:"email" gorm:"type:varchar(64)"`
-	Phone       string    `json:"phone" gorm:"type:varchar(32)"`
-	Address     string    `json:"address" gorm:"type:varchar(255)"`
+	Name        string    `json:"name" gorm:"not null" validate:"required,max=255"`
+	RUC         string    `json:"ruc" gorm:"type:varchar(15); not null; unique" validate:"required,ruc"`
+	Manager     string    `json:"manager" gorm:"type:varchar(255)" validate:"max=255"`
+	Email       string    `json:"email" gorm:"type:varchar(64)" validate:"max=64,email"`
+	Phone       string    `json:"phone" gorm:"type:varchar(32)" validate:"max=32"`
+	Address     string    `json:"address" gorm:"type:varchar(255)" validate:"max=255"`
 	Observation string    `json:"observation"`
 	State       bool      `json:"state"`
 
-	Quotations []Quotation `json:"quotations"`
+	Quotations  []Quotation  `json:"quotations"`
+	Attachments []Attachment `json:"attachments" gorm:"-"` // Listed only in the detail
 }
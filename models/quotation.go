@@ -1,21 +1,31 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
 
 type Quotation struct {
-	ID            uint      `json:"id" gorm:"primary_key"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	EmissionDate  time.Time `json:"emission_date"`
-	Winner        bool      `json:"winner"`         // Final Winner set by admin
-	WinnerLevel   uint      `json:"winner_level"`   // Winner casting calculate system
-	SuggestWinner bool      `json:"suggest_winner"` // Winner suggestion by user
-	DeliverDate   time.Time `json:"deliver_date"`
-	Observation   string    `json:"observation"`
+	ID            uint            `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	EmissionDate  time.Time       `json:"emission_date"`
+	Winner        bool            `json:"winner"`         // Final Winner set by admin
+	WinnerLevel   uint            `json:"winner_level"`   // Winner casting calculate system
+	SuggestWinner bool            `json:"suggest_winner"` // Winner suggestion by user
+	Pending       bool            `json:"pending"`        // Sent by the provider in the portal, not ranked until the buyer approves it
+	Partial       bool            `json:"partial"`        // Some requires are not quoted, ranked after the complete quotations
+	DeliverDate   time.Time       `json:"deliver_date" validate:"required"`
+	Observation   string          `json:"observation"`
+	Currency      string          `json:"currency" gorm:"type:varchar(3)" validate:"currency"` // Base currency of the setting when empty
+	TaxIncluded   *bool           `json:"tax_included"`                                        // Prices with IGV, the default of the setting when empty
+	TaxRate       decimal.Decimal `json:"tax_rate" gorm:"type:numeric(18,4)"`                  // IGV in percent of the setting on the emission date
 
-	ProviderID    uint `json:"provider_id"`
+	ProviderID    uint `json:"provider_id" validate:"required"`
 	UserID        uint `json:"user_id"`
-	RequirementID uint `json:"requirement_id"`
+	RequirementID uint `json:"requirement_id" validate:"required"`
 
-	QuotationDetails []QuotationDetail `json:"quotation_details"`
+	QuotationDetails []QuotationDetail `json:"quotation_details" validate:"min=1,dive"`
+	Attachments      []Attachment      `json:"attachments" gorm:"-"` // Listed only in the detail
 }
@@ -1,14 +1,19 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
 
 type QuotationDetail struct {
-	ID        uint      `json:"id" gorm:"primary_key"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UnitPrice float32   `json:"unit_price" gorm:"not null"`
+	ID        uint            `json:"id" gorm:"primary_key"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	UnitPrice decimal.Decimal `json:"unit_price" gorm:"type:numeric(18,4);not null" validate:"min=0,max=9999999"`
+	NotQuoted bool            `json:"not_quoted"` // The provider does not quote the require, the unit price is 0
 
-	RequireID   uint `json:"require_id"`
+	RequireID   uint `json:"require_id" validate:"required"`
 	QuotationID uint `json:"quotation_id"`
 
 	WinnerLevelProvider uint `json:"winner_level_provider"` // Winner calculate system  by product
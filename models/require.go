@@ -1,17 +1,21 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
 
 type Require struct {
-	ID             uint      `json:"id" gorm:"primary_key"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Amount         float32   `json:"amount" gorm:"not null"`
-	UnitMeasure    string    `json:"unit_measure" gorm:"type:varchar(128)"`
-	SuggestedPrice float32   `json:"suggested_price"`
-	Observation    string    `json:"observation"`
+	ID             uint            `json:"id" gorm:"primary_key"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:numeric(18,4);not null" validate:"gt=0,max=9999999"`
+	UnitMeasure    string          `json:"unit_measure" gorm:"type:varchar(128)" validate:"max=128"`
+	SuggestedPrice decimal.Decimal `json:"suggested_price" gorm:"type:numeric(18,4)" validate:"min=0,max=9999999"`
+	Observation    string          `json:"observation"`
 
-	ProductID     uint `json:"product_id"`
+	ProductID     uint `json:"product_id" validate:"required"`
 	RequirementID uint `json:"requirement_id"`
 
 	QuotationDetails []QuotationDetail `json:"quotation_details"`
@@ -4,19 +4,45 @@ import (
 	"time"
 )
 
+// States of a requirement
+const (
+	RequirementCreated  = "0"
+	RequirementQuoted   = "1"
+	RequirementRejected = "2"
+	RequirementAwarded  = "3"
+	RequirementClosed   = "4"
+)
+
 type Requirement struct {
 	ID             uint      `json:"id" gorm:"primary_key"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
-	Name           string    `json:"name" gorm:"not null"`
-	Place          string    `json:"place" gorm:"type:varchar(128)"`
-	Destination    string    `json:"destination" gorm:"type:varchar(128)"`
+	Name           string    `json:"name" gorm:"not null" validate:"required,max=255"`
+	Place          string    `json:"place" gorm:"type:varchar(128)" validate:"max=128"`
+	Destination    string    `json:"destination" gorm:"type:varchar(128)" validate:"max=128"`
 	EmissionDate   time.Time `json:"emission_date"`
 	ExpirationDate time.Time `json:"expiration_date"`
 	Claimant       string    `json:"claimant"`
 	State          string    `json:"state" gorm:"type:varchar(15)"`
 
-	UserID     uint        `json:"user_id"`
-	Requires   []Require   `json:"requires"`
-	Quotations []Quotation `json:"quotations"`
+	// Sealed bids: the prices are hidden until the expiration date passes or the bids are opened
+	Sealed   bool       `json:"sealed"`
+	OpenedAt *time.Time `json:"opened_at"`
+	OpenedBy uint       `json:"opened_by"` // User that opened the bids, 0 when they were opened by the expiration date
+
+	UserID       uint         `json:"user_id"`
+	CostCenterID uint         `json:"cost_center_id" validate:"required"` // Budget that pays the requirement
+	Requires     []Require    `json:"requires" validate:"dive"`
+	Quotations   []Quotation  `json:"quotations"`
+	Attachments  []Attachment `json:"attachments" gorm:"-"` // Listed only in the detail
+}
+
+// StateChange transition of the state of a requirement, the creation is the change from the empty state
+type StateChange struct {
+	ID            uint      `json:"id" gorm:"primary_key"`
+	CreatedAt     time.Time `json:"created_at"`
+	RequirementID uint      `json:"requirement_id" gorm:"index"`
+	FromState     string    `json:"from_state" gorm:"type:varchar(15)"`
+	ToState       string    `json:"to_state" gorm:"type:varchar(15)"`
+	UserID        uint      `json:"user_id"` // The user that sent the request for the quotations of the portal
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
+
+// QuotationRevision offer of the quotation after each change, the revision 1 is the first offer
+type QuotationRevision struct {
+	ID          uint      `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time `json:"created_at"`
+	Number      uint      `json:"number"`
+	DeliverDate time.Time `json:"deliver_date"`
+	Observation string    `json:"observation"`
+
+	QuotationID uint `json:"quotation_id"`
+	UserID      uint `json:"user_id"` // User that made the change
+
+	RevisionDetails []RevisionDetail `json:"revision_details"`
+}
+
+// RevisionDetail price of a quotation detail in the revision
+type RevisionDetail struct {
+	ID        uint            `json:"id" gorm:"primary_key"`
+	UnitPrice decimal.Decimal `json:"unit_price" gorm:"type:numeric(18,4)"`
+	NotQuoted bool            `json:"not_quoted"`
+
+	QuotationDetailID   uint `json:"quotation_detail_id"`
+	RequireID           uint `json:"require_id"`
+	QuotationRevisionID uint `json:"quotation_revision_id"`
+}
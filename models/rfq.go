@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// States of the request for quotation sent to a provider
+const (
+	RfqSent       = "sent"
+	RfqOpened     = "opened"      // The provider opened the link in the portal
+	RfqQuoted     = "quoted"      // The provider sent its quotation
+	RfqDeclined   = "declined"    // The provider does not quote
+	RfqNoResponse = "no_response" // Not saved, the requirement expired without answer
+)
+
+// Rfq request for quotation of a requirement sent to a provider
+type Rfq struct {
+	ID         uint       `json:"id" gorm:"primary_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	State      string     `json:"state" gorm:"type:varchar(15)"`
+	Email      string     `json:"email" gorm:"type:varchar(64)"`
+	SentAt     time.Time  `json:"sent_at"`
+	OpenedAt   *time.Time `json:"opened_at"`
+	AnsweredAt *time.Time `json:"answered_at"`
+	Reason     string     `json:"reason"` // Reason of the provider to decline
+
+	RequirementID uint `json:"requirement_id"`
+	ProviderID    uint `json:"provider_id"`
+	UserID        uint `json:"user_id"`
+}
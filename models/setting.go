@@ -1,13 +1,20 @@
 package models
 
+import "github.com/paulantezana/requirement/decimal"
+
 type Setting struct {
-	ID               uint   `json:"id" gorm:"primary_key"`
-	CompanyName      string `json:"company_name"`
-	CompanyShortName string `json:"company_short_name"`
-	Email            string `json:"email"`
-	Identification   string `json:"identification"`
-	Logo             string `json:"logo"`
-	City             string `json:"city"`
-	Item             uint   `json:"item"`
-	Quotations       uint   `json:"quotations"`
+	ID               uint            `json:"id" gorm:"primary_key"`
+	CompanyName      string          `json:"company_name"`
+	CompanyShortName string          `json:"company_short_name"`
+	Email            string          `json:"email" validate:"email"`
+	Identification   string          `json:"identification"`
+	Logo             string          `json:"logo"`           // Key of the picture in the storage, changed by its upload
+	LogoThumbnail    string          `json:"logo_thumbnail"` // Key of the small picture of the logo
+	City             string          `json:"city"`
+	Item             uint            `json:"item" validate:"min=1"`
+	Quotations       uint            `json:"quotations" validate:"min=1"`
+	Currency         string          `json:"currency" gorm:"type:varchar(3)" validate:"currency"`         // Base currency of the amounts, PEN by default
+	TaxRate          decimal.Decimal `json:"tax_rate" gorm:"type:numeric(18,4)" validate:"min=0,max=100"` // IGV in percent of the new quotations
+	TaxIncluded      bool            `json:"tax_included"`                                                // Default of the new quotations: prices with IGV
+	BudgetBlock      bool            `json:"budget_block"`                                                // The awards over the available budget are blocked, otherwise only warned
 }
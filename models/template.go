@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+)
+
+// Recurrences of the templates
+const (
+	RecurrenceMonthly = "monthly" // A business day of every month
+)
+
+// RequirementTemplate requirement saved with a name to create it again, by hand or on a schedule
+type RequirementTemplate struct {
+	ID             uint      `json:"id" gorm:"primary_key"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Name           string    `json:"name" gorm:"not null" validate:"required,max=255"` // Name of the template
+	Requirement    string    `json:"requirement" validate:"required,max=255"`          // Name of the requirements created
+	Place          string    `json:"place" gorm:"type:varchar(128)" validate:"max=128"`
+	Destination    string    `json:"destination" gorm:"type:varchar(128)" validate:"max=128"`
+	Claimant       string    `json:"claimant"`
+	Sealed         bool      `json:"sealed"`
+	ExpirationDays uint      `json:"expiration_days" validate:"max=365"` // Expiration date of the requirements created, 0 without expiration date
+
+	// Schedule: with a recurrence the requirement is created on the business day (monday to friday) of the month
+	Recurrence        string     `json:"recurrence" gorm:"type:varchar(15)" validate:"oneof=monthly"`
+	BusinessDay       uint       `json:"business_day" validate:"max=20"` // 1 = first business day, 0 is 1
+	NextRun           *time.Time `json:"next_run"`
+	LastRun           *time.Time `json:"last_run"`
+	LastRequirementID uint       `json:"last_requirement_id"`
+
+	CostCenterID     uint              `json:"cost_center_id" validate:"required"`
+	UserID           uint              `json:"user_id"` // Owner of the requirements created on the schedule
+	TemplateRequires []TemplateRequire `json:"template_requires" validate:"min=1,dive"`
+}
+
+// TemplateRequire product of a requirement template
+type TemplateRequire struct {
+	ID             uint            `json:"id" gorm:"primary_key"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:numeric(18,4);not null" validate:"gt=0,max=9999999"`
+	UnitMeasure    string          `json:"unit_measure" gorm:"type:varchar(128)" validate:"max=128"`
+	SuggestedPrice decimal.Decimal `json:"suggested_price" gorm:"type:numeric(18,4)" validate:"min=0,max=9999999"`
+	Observation    string          `json:"observation"`
+
+	ProductID             uint `json:"product_id" validate:"required"`
+	RequirementTemplateID uint `json:"requirement_template_id"`
+}
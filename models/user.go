@@ -5,20 +5,22 @@ import (
 )
 
 type User struct {
-	ID          uint                   `json:"id" gorm:"primary_key"`
-	DNI         string                 `json:"dni" gorm:" type:varchar(15); unique; not null"`
-	FirstName   string                 `json:"first_name" gorm:"type:varchar(128)"`
-	LastName    string                 `json:"last_name" gorm:"type:varchar(128)"`
-	UserName    string                 `json:"user_name" gorm:"type:varchar(64); unique; not null"`
-	Gender      string                 `json:"gender"`
-	Password    string                 `json:"password" gorm:"type:varchar(64); not null"`
-	OldPassword string                 `json:"old_password" gorm:"-"`
-	Email       string                 `json:"email" gorm:"type:varchar(64); unique; not null"`
-	Avatar      string                 `json:"avatar"`
-	Picture     []multipart.FileHeader `json:"picture" gorm:"-"`
-	Profile     string                 `json:"profile" gorm:"type:varchar(64)"`
-	Key         string                 `json:"key"`
-	State       bool                   `json:"state" gorm:"default:'true'"`
+	ID              uint                   `json:"id" gorm:"primary_key"`
+	DNI             string                 `json:"dni" gorm:" type:varchar(15); unique; not null" validate:"required,dni"`
+	FirstName       string                 `json:"first_name" gorm:"type:varchar(128)" validate:"max=128"`
+	LastName        string                 `json:"last_name" gorm:"type:varchar(128)" validate:"max=128"`
+	UserName        string                 `json:"user_name" gorm:"type:varchar(64); unique; not null" validate:"required,max=64"`
+	Gender          string                 `json:"gender" validate:"oneof=0 1"`
+	Password        string                 `json:"password" gorm:"type:varchar(64); not null"`
+	OldPassword     string                 `json:"old_password" gorm:"-"`
+	Email           string                 `json:"email" gorm:"type:varchar(64); unique; not null" validate:"required,max=64,email"`
+	Avatar          string                 `json:"avatar"`           // Key of the picture in the storage, changed by its upload
+	AvatarThumbnail string                 `json:"avatar_thumbnail"` // Key of the small picture of the avatar
+	Picture         []multipart.FileHeader `json:"picture" gorm:"-"`
+	Profile         string                 `json:"profile" gorm:"type:varchar(64)" validate:"oneof=admin user"`
+	Key             string                 `json:"key"`
+	Locale          string                 `json:"locale" gorm:"type:varchar(8)" validate:"oneof=es en"` // Language of the messages, empty to use the Accept-Language
+	State           bool                   `json:"state" gorm:"default:'true'"`
 
 	Requirements []Requirement `json:"requirements"`
 	Quotations   []Quotation   `json:"quotations"`
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// States of the deliveries of the webhooks
+const (
+	DeliveryPending   = "pending"   // Waiting for its next attempt
+	DeliverySucceeded = "succeeded" // The receiver answered with a 2xx code
+	DeliveryFailed    = "failed"    // All the attempts failed
+)
+
+// Webhook subscription of a external system to the events, the payloads are signed with the secret
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url" gorm:"not null" validate:"required,url,max=255"`
+	Secret    string    `json:"secret,omitempty" gorm:"type:varchar(64)" validate:"max=64"` // Generated when empty, only returned on creation
+	Events    string    `json:"events" validate:"required,max=255"`                         // Events separated by spaces, * for all the events
+	State     bool      `json:"state"`
+	UserID    uint      `json:"user_id"` // Creator
+}
+
+// WebhookDelivery payload of a event sent to a webhook, retried until the receiver accepts it
+type WebhookDelivery struct {
+	ID          uint       `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	WebhookID   uint       `json:"webhook_id" gorm:"index"`
+	Event       string     `json:"event" gorm:"type:varchar(32)"`
+	Payload     string     `json:"payload" gorm:"type:text"`
+	State       string     `json:"state" gorm:"type:varchar(15); index"`
+	Attempts    uint       `json:"attempts"`
+	NextAttempt *time.Time `json:"next_attempt" gorm:"index"` // nil when it is not retried
+	StatusCode  int        `json:"status_code"`               // Code of the last answer, 0 without answer
+	Response    string     `json:"response" gorm:"type:text"` // Start of the last answer or the error of the connection
+	DeliveredAt *time.Time `json:"delivered_at"`
+	Redelivery  uint       `json:"redelivery"` // Delivery sent again by hand
+}
@@ -0,0 +1,120 @@
+// Package realtime delivers the events of the application to the connected clients. The
+// hub is in memory, the clients of a instance only receive the events of that instance
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Buffer messages queued for a subscriber, a subscriber that falls behind is closed and
+// its client reconnects and reloads the data
+const Buffer = 32
+
+// Message event sent to the subscribers of a topic
+type Message struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// WriteTo write the message in the format of the server-sent events
+func (m Message) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(m.Data)
+	if err != nil {
+		return 0, err
+	}
+	n, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", strings.Replace(m.Event, "\n", "", -1), data)
+	return int64(n), err
+}
+
+// RequirementTopic events of a requirement and its quotations
+func RequirementTopic(id uint) string {
+	return fmt.Sprintf("requirement:%d", id)
+}
+
+// UserTopic notifications of a user
+func UserTopic(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+// Hub subscriptions of the clients by topic
+type Hub struct {
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]bool
+}
+
+// NewHub create a hub without subscriptions
+func NewHub() *Hub {
+	return &Hub{topics: make(map[string]map[*Subscription]bool)}
+}
+
+// Subscription messages of the topics, C is closed when the subscription is closed
+type Subscription struct {
+	C <-chan Message
+
+	c      chan Message
+	hub    *Hub
+	topics []string
+	closed bool
+}
+
+// Subscribe receive the messages published in the topics from now
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	c := make(chan Message, Buffer)
+	s := &Subscription{C: c, c: c, hub: h, topics: topics}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*Subscription]bool)
+		}
+		h.topics[topic][s] = true
+	}
+	return s
+}
+
+// Close stop the subscription, it can be called more than once
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.close()
+}
+
+// close remove the subscription from the hub, the hub must be locked
+func (s *Subscription) close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, topic := range s.topics {
+		delete(s.hub.topics[topic], s)
+		if len(s.hub.topics[topic]) == 0 {
+			delete(s.hub.topics, topic)
+		}
+	}
+	close(s.c)
+}
+
+// Publish send the message to the subscribers of the topic without waiting for them
+func (h *Hub) Publish(topic string, m Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.topics[topic] {
+		select {
+		case s.c <- m:
+		default:
+			s.close()
+		}
+	}
+}
+
+// Count subscribers of the topic
+func (h *Hub) Count(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.topics[topic])
+}
@@ -0,0 +1,63 @@
+package realtime
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	hub := NewHub()
+	requirement := hub.Subscribe(RequirementTopic(1), UserTopic(7))
+	other := hub.Subscribe(RequirementTopic(2))
+	defer other.Close()
+
+	hub.Publish(RequirementTopic(1), Message{Event: "quotation.created", Data: map[string]uint{"quotation_id": 3}})
+	hub.Publish(UserTopic(7), Message{Event: "notification"})
+	if m := <-requirement.C; m.Event != "quotation.created" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	if m := <-requirement.C; m.Event != "notification" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	if len(other.C) != 0 {
+		t.Fatalf("expected no messages of other topic, got %d", len(other.C))
+	}
+
+	// Closed subscriptions leave the hub
+	requirement.Close()
+	requirement.Close()
+	if _, ok := <-requirement.C; ok {
+		t.Fatal("expected the channel closed")
+	}
+	if n := hub.Count(RequirementTopic(1)); n != 0 {
+		t.Fatalf("expected no subscribers, got %d", n)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe("topic")
+	for i := 0; i <= Buffer; i++ {
+		hub.Publish("topic", Message{Event: "tick", Data: i})
+	}
+
+	// The full buffer is delivered and then the channel is closed
+	count := 0
+	for range slow.C {
+		count++
+	}
+	if count != Buffer || hub.Count("topic") != 0 {
+		t.Fatalf("expected %d messages and the subscription closed, got %d", Buffer, count)
+	}
+	slow.Close()
+}
+
+func TestWriteTo(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if _, err := (Message{Event: "ranking.changed", Data: map[string]uint{"requirement_id": 1}}).WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "event: ranking.changed\ndata: {\"requirement_id\":1}\n\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
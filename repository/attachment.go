@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func (r attachmentRepository) ListByOwner(owner string, ownerID uint) ([]models.Attachment, error) {
+	attachments := make([]models.Attachment, 0)
+	err := r.db.Where("owner = ? AND owner_id = ?", owner, ownerID).Order("id asc").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r attachmentRepository) Count(owner string, ownerID uint) (uint, error) {
+	var count uint
+	err := r.db.Model(&models.Attachment{}).Where("owner = ? AND owner_id = ?", owner, ownerID).Count(&count).Error
+	return count, err
+}
+
+func (r attachmentRepository) Get(id uint) (models.Attachment, error) {
+	attachment := models.Attachment{}
+	err := r.db.First(&attachment, id).Error
+	return attachment, find(err)
+}
+
+func (r attachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r attachmentRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.Attachment{ID: id}))
+}
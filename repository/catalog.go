@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func (r catalogRepository) ListCategories(providerID uint) ([]models.ProviderCategory, error) {
+	categories := make([]models.ProviderCategory, 0)
+	err := r.db.Table("provider_categories").
+		Select("provider_categories.*, categories.name as category_name").
+		Joins("INNER JOIN categories ON categories.id = provider_categories.category_id").
+		Where("provider_categories.provider_id = ?", providerID).
+		Order("categories.name asc").
+		Scan(&categories).Error
+	return categories, err
+}
+
+func (r catalogRepository) ListProducts(providerID uint) ([]models.ProviderProduct, error) {
+	products := make([]models.ProviderProduct, 0)
+	err := r.db.Table("provider_products").
+		Select("provider_products.*, products.name as product_name").
+		Joins("INNER JOIN products ON products.id = provider_products.product_id").
+		Where("provider_products.provider_id = ?", providerID).
+		Order("products.name asc").
+		Scan(&products).Error
+	return products, err
+}
+
+func (r catalogRepository) ListByCategories(categoryIDs []uint) ([]models.ProviderCategory, error) {
+	categories := make([]models.ProviderCategory, 0)
+	err := r.db.Where("category_id IN (?)", categoryIDs).Find(&categories).Error
+	return categories, err
+}
+
+func (r catalogRepository) ListByProducts(productIDs []uint) ([]models.ProviderProduct, error) {
+	products := make([]models.ProviderProduct, 0)
+	err := r.db.Where("product_id IN (?)", productIDs).Find(&products).Error
+	return products, err
+}
+
+func (r catalogRepository) ReplaceCategories(providerID uint, categories []models.ProviderCategory) error {
+	if err := r.db.Where("provider_id = ?", providerID).Delete(&models.ProviderCategory{}).Error; err != nil {
+		return err
+	}
+	for k := range categories {
+		categories[k].ID = 0
+		categories[k].ProviderID = providerID
+		if err := r.db.Create(&categories[k]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r catalogRepository) ReplaceProducts(providerID uint, products []models.ProviderProduct) error {
+	if err := r.db.Where("provider_id = ?", providerID).Delete(&models.ProviderProduct{}).Error; err != nil {
+		return err
+	}
+	for k := range products {
+		products[k].ID = 0
+		products[k].ProviderID = providerID
+		if err := r.db.Create(&products[k]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r catalogRepository) SavePrice(price *models.ProviderProduct) error {
+	current := models.ProviderProduct{}
+	err := find(r.db.Where("provider_id = ? AND product_id = ?", price.ProviderID, price.ProductID).First(&current).Error)
+	if err == ErrNotFound {
+		price.ID = 0
+		return r.db.Create(price).Error
+	}
+	if err != nil {
+		return err
+	}
+	price.ID = current.ID
+	price.Reference = current.Reference
+	if current.LastQuotedAt != nil && price.LastQuotedAt != nil && price.LastQuotedAt.Before(*current.LastQuotedAt) {
+		return nil
+	}
+	return r.db.Model(&models.ProviderProduct{ID: current.ID}).Updates(map[string]interface{}{
+		"last_price":     price.LastPrice,
+		"last_currency":  price.LastCurrency,
+		"last_quoted_at": price.LastQuotedAt,
+	}).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func (r categoryRepository) List(page Page) ([]models.Category, uint, error) {
+	var total uint
+	categories := make([]models.Category, 0)
+	err := paginate(r.db.Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Order("name asc"), page, &categories, &total)
+	return categories, total, err
+}
+
+func (r categoryRepository) Get(id uint) (models.Category, error) {
+	category := models.Category{}
+	err := r.db.First(&category, id).Error
+	return category, find(err)
+}
+
+func (r categoryRepository) ListByIDs(ids []uint) ([]models.Category, error) {
+	categories := make([]models.Category, 0)
+	err := r.db.Where("id IN (?)", ids).Find(&categories).Error
+	return categories, err
+}
+
+func (r categoryRepository) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r categoryRepository) Update(category *models.Category) error {
+	return affected(r.db.Model(&models.Category{ID: category.ID}).Updates(*category))
+}
+
+func (r categoryRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Category{ID: id}).UpdateColumns(fields))
+}
+
+func (r categoryRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.Category{ID: id}))
+}
+
+func (r categoryRepository) CountUses(id uint) (uint, error) {
+	var products, providers uint
+	if err := r.db.Model(&models.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+		return 0, err
+	}
+	err := r.db.Model(&models.ProviderCategory{}).Where("category_id = ?", id).Count(&providers).Error
+	return products + providers, err
+}
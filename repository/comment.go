@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// commentMentions preload the mentions of the comments in the order they were written
+func commentMentions(db *gorm.DB) *gorm.DB {
+	return db.Order("mentions.id asc")
+}
+
+func (r commentRepository) ListByOwner(owner string, ownerID uint) ([]models.Comment, error) {
+	comments := make([]models.Comment, 0)
+	err := r.db.Preload("Mentions", commentMentions).Where("owner = ? AND owner_id = ?", owner, ownerID).
+		Order("created_at asc, id asc").Find(&comments).Error
+	return comments, err
+}
+
+func (r commentRepository) ListByRequirement(requirementID uint) ([]models.Comment, error) {
+	comments := make([]models.Comment, 0)
+	err := r.db.Preload("Mentions", commentMentions).Where("requirement_id = ?", requirementID).
+		Order("created_at asc, id asc").Find(&comments).Error
+	return comments, err
+}
+
+func (r commentRepository) Get(id uint) (models.Comment, error) {
+	comment := models.Comment{}
+	err := r.db.Preload("Mentions", commentMentions).First(&comment, id).Error
+	return comment, find(err)
+}
+
+func (r commentRepository) Count(owner string, ownerID uint) (uint, error) {
+	var count uint
+	err := r.db.Model(&models.Comment{}).Where("owner = ? AND owner_id = ?", owner, ownerID).Count(&count).Error
+	return count, err
+}
+
+func (r commentRepository) Create(comment *models.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+func (r commentRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Comment{ID: id}).UpdateColumns(fields))
+}
+
+// ReplaceMentions remove the mentions of the comment and insert mentions, must run inside a transaction
+func (r commentRepository) ReplaceMentions(id uint, mentions []models.Mention) error {
+	if err := r.db.Where("comment_id = ?", id).Delete(&models.Mention{}).Error; err != nil {
+		return err
+	}
+	for k := range mentions {
+		mentions[k].ID = 0
+		mentions[k].CommentID = id
+		if err := r.db.Create(&mentions[k]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r commentRepository) Delete(id uint) error {
+	if err := r.db.Where("comment_id = ?", id).Delete(&models.Mention{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.Comment{ID: id}))
+}
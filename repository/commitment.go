@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type commitmentRepository struct {
+	db *gorm.DB
+}
+
+func (r commitmentRepository) List(costCenterID uint, year int) ([]models.Commitment, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	db := r.db.Where("date >= ? AND date < ?", from, from.AddDate(1, 0, 0))
+	if costCenterID != 0 {
+		db = db.Where("cost_center_id = ?", costCenterID)
+	}
+	commitments := make([]models.Commitment, 0)
+	err := db.Order("date asc, id asc").Find(&commitments).Error
+	return commitments, err
+}
+
+func (r commitmentRepository) GetByRequirement(requirementID uint) (models.Commitment, error) {
+	commitment := models.Commitment{}
+	err := r.db.Where("requirement_id = ?", requirementID).First(&commitment).Error
+	return commitment, find(err)
+}
+
+func (r commitmentRepository) Save(commitment *models.Commitment) error {
+	current, err := r.GetByRequirement(commitment.RequirementID)
+	if err == ErrNotFound {
+		commitment.ID = 0
+		return r.db.Create(commitment).Error
+	}
+	if err != nil {
+		return err
+	}
+	commitment.ID = current.ID
+	return r.db.Save(commitment).Error
+}
+
+func (r commitmentRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Commitment{ID: id}).UpdateColumns(fields))
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type costCenterRepository struct {
+	db *gorm.DB
+}
+
+// budgets order of the budgets of the cost centers
+func budgets(db *gorm.DB) *gorm.DB {
+	return db.Order("budgets.year desc")
+}
+
+func (r costCenterRepository) List(page Page) ([]models.CostCenter, uint, error) {
+	var total uint
+	costCenters := make([]models.CostCenter, 0)
+	err := paginate(r.db.Preload("Budgets", budgets).
+		Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Or("lower(code) LIKE lower(?)", like(page.Search)).
+		Order("code asc"), page, &costCenters, &total)
+	return costCenters, total, err
+}
+
+func (r costCenterRepository) Get(id uint) (models.CostCenter, error) {
+	costCenter := models.CostCenter{}
+	err := r.db.Preload("Budgets", budgets).First(&costCenter, id).Error
+	return costCenter, find(err)
+}
+
+func (r costCenterRepository) Create(costCenter *models.CostCenter) error {
+	return r.db.Create(costCenter).Error
+}
+
+func (r costCenterRepository) Update(costCenter *models.CostCenter) error {
+	return affected(r.db.Model(&models.CostCenter{ID: costCenter.ID}).Updates(*costCenter))
+}
+
+func (r costCenterRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.CostCenter{ID: id}).UpdateColumns(fields))
+}
+
+func (r costCenterRepository) Delete(id uint) error {
+	if err := r.db.Where("cost_center_id = ?", id).Delete(&models.Budget{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.CostCenter{ID: id}))
+}
+
+func (r costCenterRepository) GetBudget(costCenterID uint, year uint) (models.Budget, error) {
+	budget := models.Budget{}
+	err := r.db.Where("cost_center_id = ? AND year = ?", costCenterID, year).First(&budget).Error
+	return budget, find(err)
+}
+
+func (r costCenterRepository) SaveBudget(budget *models.Budget) error {
+	current, err := r.GetBudget(budget.CostCenterID, budget.Year)
+	if err == ErrNotFound {
+		budget.ID = 0
+		return r.db.Create(budget).Error
+	}
+	if err != nil {
+		return err
+	}
+	budget.ID = current.ID
+	return r.db.Model(&models.Budget{ID: current.ID}).UpdateColumn("amount", budget.Amount).Error
+}
+
+func (r costCenterRepository) CountRequirements(id uint) (uint, error) {
+	var count uint
+	err := r.db.Model(&models.Requirement{}).Where("cost_center_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type emailRepository struct {
+	db *gorm.DB
+}
+
+// attachmentNames the attachments without its content
+func attachmentNames(db *gorm.DB) *gorm.DB {
+	return db.Select("id, email_id, name, content_type")
+}
+
+func (r emailRepository) List(state string, page Page) ([]models.Email, uint, error) {
+	var total uint
+	emails := make([]models.Email, 0)
+	// The bodies can have codes of the users, they are not listed
+	db := r.db.Select("id, created_at, updated_at, recipient, subject, template, request_id, state, attempts, next_attempt, error, sent_at").
+		Preload("Attachments", attachmentNames).Where("lower(recipient) LIKE lower(?)", like(page.Search))
+	if state != "" {
+		db = db.Where("state = ?", state)
+	}
+	err := paginate(db.Order("id desc"), page, &emails, &total)
+	return emails, total, err
+}
+
+func (r emailRepository) Get(id uint) (models.Email, error) {
+	email := models.Email{}
+	err := r.db.Preload("Attachments").First(&email, id).Error
+	return email, find(err)
+}
+
+func (r emailRepository) Create(email *models.Email) error {
+	return r.db.Create(email).Error
+}
+
+func (r emailRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Email{ID: id}).UpdateColumns(fields))
+}
+
+func (r emailRepository) ListDue(now time.Time, ids []uint, limit uint) ([]models.Email, error) {
+	emails := make([]models.Email, 0)
+	db := r.db.Select("id").Where("state = ? AND next_attempt <= ?", models.EmailPending, now)
+	if ids != nil {
+		db = db.Where("id IN (?)", ids)
+	}
+	err := db.Order("next_attempt asc, id asc").Limit(limit).Find(&emails).Error
+	return emails, err
+}
+
+func (r emailRepository) Claim(id uint, now time.Time, until time.Time) (bool, error) {
+	db := r.db.Model(&models.Email{}).
+		Where("id = ? AND state = ? AND next_attempt <= ?", id, models.EmailPending, now).
+		UpdateColumn("next_attempt", until)
+	return db.RowsAffected == 1, db.Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func (r exchangeRateRepository) List(page Page) ([]models.ExchangeRate, uint, error) {
+	var total uint
+	rates := make([]models.ExchangeRate, 0)
+	err := paginate(r.db.Where("lower(currency) LIKE lower(?)", like(page.Search)).
+		Order("date desc, currency asc"), page, &rates, &total)
+	return rates, total, err
+}
+
+func (r exchangeRateRepository) Get(id uint) (models.ExchangeRate, error) {
+	rate := models.ExchangeRate{}
+	err := r.db.First(&rate, id).Error
+	return rate, find(err)
+}
+
+func (r exchangeRateRepository) Find(currency string, date time.Time) (models.ExchangeRate, error) {
+	rate := models.ExchangeRate{}
+	err := r.db.Where("currency = ? AND date <= ?", currency, date).
+		Order("date desc").First(&rate).Error
+	return rate, find(err)
+}
+
+func (r exchangeRateRepository) Save(rate *models.ExchangeRate) error {
+	current := models.ExchangeRate{}
+	err := r.db.Where("currency = ? AND date = ?", rate.Currency, rate.Date).First(&current).Error
+	if gorm.IsRecordNotFoundError(err) {
+		rate.ID = 0
+		return r.db.Create(rate).Error
+	}
+	if err != nil {
+		return err
+	}
+	rate.ID = current.ID
+	return r.db.Model(&models.ExchangeRate{ID: current.ID}).UpdateColumn("rate", rate.Rate).Error
+}
+
+func (r exchangeRateRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.ExchangeRate{ID: id}))
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+)
+
+// Migrate create or update the tables of all the models
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Quotation{},
+		&models.QuotationDetail{},
+		&models.Product{},
+		&models.Provider{},
+		&models.Requirement{},
+		&models.Require{},
+		&models.Setting{},
+		&models.Rfq{},
+		&models.QuotationRevision{},
+		&models.RevisionDetail{},
+		&models.ExchangeRate{},
+		&models.CostCenter{},
+		&models.Budget{},
+		&models.Commitment{},
+		&models.RequirementTemplate{},
+		&models.TemplateRequire{},
+		&models.Attachment{},
+		&models.StateChange{},
+		&models.Comment{},
+		&models.Mention{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Email{},
+		&models.EmailAttachment{},
+		&models.Category{},
+		&models.ProviderCategory{},
+		&models.ProviderProduct{},
+	).Error; err != nil {
+		return err
+	}
+
+	// Column types and foreign keys are changed with ALTER TABLE, only supported by postgres
+	if db.Dialect().GetName() != "postgres" {
+		return nil
+	}
+
+	// The prices and quantities created as real are changed to exact decimals
+	columns := []struct {
+		model interface{}
+		field string
+	}{
+		{&models.Require{}, "amount"},
+		{&models.Require{}, "suggested_price"},
+		{&models.QuotationDetail{}, "unit_price"},
+		{&models.RevisionDetail{}, "unit_price"},
+		{&models.ExchangeRate{}, "rate"},
+		{&models.Budget{}, "amount"},
+		{&models.Commitment{}, "amount"},
+		{&models.TemplateRequire{}, "amount"},
+		{&models.TemplateRequire{}, "suggested_price"},
+		{&models.ProviderProduct{}, "last_price"},
+	}
+	for _, c := range columns {
+		if err := db.Model(c.model).ModifyColumn(c.field, decimal.SQLType).Error; err != nil {
+			return err
+		}
+	}
+
+	keys := []struct {
+		model interface{}
+		field string
+		dest  string
+	}{
+		{&models.Requirement{}, "user_id", "users(id)"},
+		{&models.Require{}, "requirement_id", "requirements(id)"},
+		{&models.Require{}, "product_id", "products(id)"},
+		{&models.QuotationDetail{}, "require_id", "requires(id)"},
+		{&models.QuotationDetail{}, "quotation_id", "quotations(id)"},
+		{&models.Quotation{}, "user_id", "users(id)"},
+		{&models.Quotation{}, "provider_id", "providers(id)"},
+		{&models.Quotation{}, "requirement_id", "requirements(id)"},
+		{&models.Rfq{}, "requirement_id", "requirements(id)"},
+		{&models.Rfq{}, "provider_id", "providers(id)"},
+		{&models.Rfq{}, "user_id", "users(id)"},
+		{&models.QuotationRevision{}, "quotation_id", "quotations(id)"},
+		{&models.QuotationRevision{}, "user_id", "users(id)"},
+		{&models.RevisionDetail{}, "quotation_revision_id", "quotation_revisions(id)"},
+		{&models.RevisionDetail{}, "quotation_detail_id", "quotation_details(id)"},
+		{&models.Requirement{}, "cost_center_id", "cost_centers(id)"},
+		{&models.Budget{}, "cost_center_id", "cost_centers(id)"},
+		{&models.Commitment{}, "cost_center_id", "cost_centers(id)"},
+		{&models.Commitment{}, "requirement_id", "requirements(id)"},
+		{&models.Commitment{}, "quotation_id", "quotations(id)"},
+		{&models.RequirementTemplate{}, "cost_center_id", "cost_centers(id)"},
+		{&models.RequirementTemplate{}, "user_id", "users(id)"},
+		{&models.TemplateRequire{}, "requirement_template_id", "requirement_templates(id)"},
+		{&models.TemplateRequire{}, "product_id", "products(id)"},
+		{&models.Attachment{}, "user_id", "users(id)"},
+		{&models.StateChange{}, "requirement_id", "requirements(id)"},
+		{&models.Comment{}, "requirement_id", "requirements(id)"},
+		{&models.Comment{}, "user_id", "users(id)"},
+		{&models.Mention{}, "comment_id", "comments(id)"},
+		{&models.Mention{}, "user_id", "users(id)"},
+		{&models.Notification{}, "user_id", "users(id)"},
+		{&models.NotificationPreference{}, "user_id", "users(id)"},
+		{&models.WebhookDelivery{}, "webhook_id", "webhooks(id)"},
+		{&models.EmailAttachment{}, "email_id", "emails(id)"},
+		{&models.Product{}, "category_id", "categories(id)"},
+		{&models.ProviderCategory{}, "provider_id", "providers(id)"},
+		{&models.ProviderCategory{}, "category_id", "categories(id)"},
+		{&models.ProviderProduct{}, "provider_id", "providers(id)"},
+		{&models.ProviderProduct{}, "product_id", "products(id)"},
+	}
+	for _, k := range keys {
+		if err := db.Model(k.model).AddForeignKey(k.field, k.dest, "RESTRICT", "RESTRICT").Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
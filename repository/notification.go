@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// unread notifications of the user, all of them when unread is false
+func (r notificationRepository) of(userID uint, unread bool) *gorm.DB {
+	db := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unread {
+		db = db.Where("read_at IS NULL")
+	}
+	return db
+}
+
+func (r notificationRepository) List(userID uint, unread bool, page Page) ([]models.Notification, uint, error) {
+	var total uint
+	notifications := make([]models.Notification, 0)
+	err := paginate(r.of(userID, unread).Order("id desc"), page, &notifications, &total)
+	return notifications, total, err
+}
+
+func (r notificationRepository) CountUnread(userID uint) (uint, error) {
+	var count uint
+	err := r.of(userID, true).Count(&count).Error
+	return count, err
+}
+
+func (r notificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r notificationRepository) MarkRead(userID uint, ids []uint, at time.Time) error {
+	db := r.of(userID, true)
+	if ids != nil {
+		db = db.Where("id IN (?)", ids)
+	}
+	return db.UpdateColumn("read_at", at).Error
+}
+
+func (r notificationRepository) ListPreferences(userID uint) ([]models.NotificationPreference, error) {
+	preferences := make([]models.NotificationPreference, 0)
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r notificationRepository) SavePreference(preference *models.NotificationPreference) error {
+	current := models.NotificationPreference{}
+	err := find(r.db.Where("user_id = ? AND event = ?", preference.UserID, preference.Event).First(&current).Error)
+	if err == ErrNotFound {
+		preference.ID = 0
+		return r.db.Create(preference).Error
+	}
+	if err != nil {
+		return err
+	}
+	preference.ID = current.ID
+	return r.db.Model(&models.NotificationPreference{ID: current.ID}).UpdateColumn("email", preference.Email).Error
+}
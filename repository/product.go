@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+func (r productRepository) List(page Page) ([]models.Product, uint, error) {
+	var total uint
+	products := make([]models.Product, 0)
+	err := paginate(r.db.Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Order("id desc"), page, &products, &total)
+	return products, total, err
+}
+
+func (r productRepository) Get(id uint) (models.Product, error) {
+	product := models.Product{}
+	err := r.db.First(&product, id).Error
+	return product, find(err)
+}
+
+func (r productRepository) ListByIDs(ids []uint) ([]models.Product, error) {
+	products := make([]models.Product, 0)
+	err := r.db.Where("id IN (?)", ids).Find(&products).Error
+	return products, err
+}
+
+func (r productRepository) Create(product *models.Product) error {
+	return r.db.Create(product).Error
+}
+
+func (r productRepository) Update(product *models.Product) error {
+	return affected(r.db.Model(&models.Product{ID: product.ID}).Updates(*product))
+}
+
+func (r productRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Product{ID: id}).UpdateColumns(fields))
+}
+
+func (r productRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.Product{ID: id}))
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type providerRepository struct {
+	db *gorm.DB
+}
+
+func (r providerRepository) List(page Page) ([]models.Provider, uint, error) {
+	var total uint
+	providers := make([]models.Provider, 0)
+	err := paginate(r.db.Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Or("ruc LIKE ?", like(page.Search)).
+		Order("id desc"), page, &providers, &total)
+	return providers, total, err
+}
+
+func (r providerRepository) Get(id uint) (models.Provider, error) {
+	provider := models.Provider{}
+	err := r.db.First(&provider, id).Error
+	return provider, find(err)
+}
+
+func (r providerRepository) GetByRUC(ruc string) (models.Provider, error) {
+	provider := models.Provider{}
+	err := r.db.Where("ruc = ?", ruc).First(&provider).Error
+	return provider, find(err)
+}
+
+func (r providerRepository) ListByIDs(ids []uint) ([]models.Provider, error) {
+	providers := make([]models.Provider, 0)
+	err := r.db.Where("id IN (?)", ids).Find(&providers).Error
+	return providers, err
+}
+
+func (r providerRepository) Create(provider *models.Provider) error {
+	return r.db.Create(provider).Error
+}
+
+func (r providerRepository) Update(provider *models.Provider) error {
+	return affected(r.db.Model(&models.Provider{ID: provider.ID}).Updates(*provider))
+}
+
+func (r providerRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Provider{ID: id}).UpdateColumns(fields))
+}
+
+func (r providerRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.Provider{ID: id}))
+}
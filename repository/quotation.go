@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type quotationRepository struct {
+	db *gorm.DB
+}
+
+// details preload the details of the quotations in the order of the requires
+func details(db *gorm.DB) *gorm.DB {
+	return db.Order("require_id asc, id asc")
+}
+
+func (r quotationRepository) ListByRequirement(requirementID uint) ([]models.Quotation, error) {
+	quotations := make([]models.Quotation, 0)
+	err := r.db.Preload("QuotationDetails", details).
+		Where("requirement_id = ?", requirementID).
+		Order("winner_level asc, id asc").
+		Find(&quotations).Error
+	return quotations, err
+}
+
+func (r quotationRepository) ListWinners() ([]models.Quotation, error) {
+	quotations := make([]models.Quotation, 0)
+	err := r.db.Preload("QuotationDetails", details).
+		Where("winner = ?", true).
+		Order("emission_date desc, id desc").
+		Find(&quotations).Error
+	return quotations, err
+}
+
+func (r quotationRepository) Get(id uint) (models.Quotation, error) {
+	quotation := models.Quotation{}
+	err := r.db.Preload("QuotationDetails", details).First(&quotation, id).Error
+	return quotation, find(err)
+}
+
+func (r quotationRepository) Count(requirementID uint) (uint, error) {
+	var count uint
+	err := r.db.Model(&models.Quotation{}).Where("requirement_id = ?", requirementID).Count(&count).Error
+	return count, err
+}
+
+func (r quotationRepository) CountAll() (uint, error) {
+	var count uint
+	err := r.db.Model(&models.Quotation{}).Count(&count).Error
+	return count, err
+}
+
+func (r quotationRepository) EmissionDates(currency string) ([]time.Time, error) {
+	dates := make([]time.Time, 0)
+	err := r.db.Model(&models.Quotation{}).Where("currency = ?", currency).Pluck("emission_date", &dates).Error
+	return dates, err
+}
+
+func (r quotationRepository) Records(providerIDs []uint) ([]ProviderRecord, error) {
+	records := make([]ProviderRecord, 0)
+	awarded := r.db.Model(&models.Quotation{}).Select("requirement_id").Where("winner = ?", true).SubQuery()
+	err := r.db.Model(&models.Quotation{}).
+		Select("provider_id, count(*) as quotations, sum(case when winner = ? then 1 else 0 end) as won", true).
+		Where("provider_id IN (?) AND pending = ? AND requirement_id IN ?", providerIDs, false, awarded).
+		Group("provider_id").
+		Scan(&records).Error
+	return records, err
+}
+
+func (r quotationRepository) Create(quotation *models.Quotation) error {
+	return r.db.Create(quotation).Error
+}
+
+func (r quotationRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Quotation{ID: id}).UpdateColumns(fields))
+}
+
+func (r quotationRepository) UpdateDetailFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.QuotationDetail{ID: id}).UpdateColumns(fields))
+}
+
+func (r quotationRepository) ResetWinner(requirementID uint) error {
+	return r.db.Model(&models.Quotation{}).Where("requirement_id = ?", requirementID).
+		UpdateColumn("winner", false).Error
+}
+
+// Delete remove the quotation with its details, must run inside a transaction
+func (r quotationRepository) Delete(id uint) error {
+	if err := r.db.Where("quotation_id = ?", id).Delete(&models.QuotationDetail{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.Quotation{ID: id}))
+}
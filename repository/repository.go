@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+)
+
+// ErrNotFound returned when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Page search term and limits of a list query, Limit = 0 means without limit
+type Page struct {
+	Search string
+	Offset uint
+	Limit  uint
+}
+
+// RequireLine require of a requirement with the name of the product
+type RequireLine struct {
+	ID             uint
+	Amount         decimal.Decimal
+	UnitMeasure    string
+	SuggestedPrice decimal.Decimal
+	Observation    string
+	ProductID      uint
+	ProductName    string
+	Exempt         bool // The product is exempt of the IGV
+	RequirementID  uint
+}
+
+// ProviderRecord quotations of a provider in the awarded requirements and how many won
+type ProviderRecord struct {
+	ProviderID uint
+	Quotations uint
+	Won        uint
+}
+
+// Store gives access to all the repositories, the repositories returned by the
+// store passed to fn in Transaction run inside the same transaction
+type Store interface {
+	Users() UserRepository
+	Providers() ProviderRepository
+	Products() ProductRepository
+	Requirements() RequirementRepository
+	Requires() RequireRepository
+	Quotations() QuotationRepository
+	Settings() SettingRepository
+	Rfqs() RfqRepository
+	Revisions() RevisionRepository
+	ExchangeRates() ExchangeRateRepository
+	CostCenters() CostCenterRepository
+	Commitments() CommitmentRepository
+	Templates() TemplateRepository
+	Attachments() AttachmentRepository
+	Comments() CommentRepository
+	Notifications() NotificationRepository
+	Webhooks() WebhookRepository
+	Emails() EmailRepository
+	Categories() CategoryRepository
+	Catalog() CatalogRepository
+
+	// Transaction run fn atomically, the changes are discarded when fn returns a error.
+	// Inside a transaction fn runs in the same transaction
+	Transaction(fn func(tx Store) error) error
+}
+
+// UserRepository users persistence
+type UserRepository interface {
+	List(page Page) ([]models.User, uint, error)
+	Get(id uint) (models.User, error)
+	GetByLogin(login string) (models.User, error) // by user name or email
+	GetByEmail(email string) (models.User, error)
+	ListByIDs(ids []uint) ([]models.User, error)
+	ListByNames(names []string) ([]models.User, error) // by user name
+	Create(user *models.User) error
+	Update(user *models.User) error // only the non zero fields
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error
+}
+
+// ProviderRepository providers persistence
+type ProviderRepository interface {
+	List(page Page) ([]models.Provider, uint, error)
+	Get(id uint) (models.Provider, error)
+	GetByRUC(ruc string) (models.Provider, error)
+	ListByIDs(ids []uint) ([]models.Provider, error)
+	Create(provider *models.Provider) error
+	Update(provider *models.Provider) error // only the non zero fields
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error
+}
+
+// ProductRepository products persistence
+type ProductRepository interface {
+	List(page Page) ([]models.Product, uint, error)
+	Get(id uint) (models.Product, error)
+	ListByIDs(ids []uint) ([]models.Product, error)
+	Create(product *models.Product) error
+	Update(product *models.Product) error // only the non zero fields
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error
+}
+
+// CategoryRepository categories of the products persistence
+type CategoryRepository interface {
+	List(page Page) ([]models.Category, uint, error) // ordered by name
+	Get(id uint) (models.Category, error)
+	ListByIDs(ids []uint) ([]models.Category, error)
+	Create(category *models.Category) error
+	Update(category *models.Category) error // only the non zero fields
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error
+	CountUses(id uint) (uint, error) // products and providers of the category
+}
+
+// CatalogRepository categories and products sold by the providers persistence
+type CatalogRepository interface {
+	ListCategories(providerID uint) ([]models.ProviderCategory, error) // with the names, ordered by name
+	ListProducts(providerID uint) ([]models.ProviderProduct, error)    // with the names, ordered by name
+	ListByCategories(categoryIDs []uint) ([]models.ProviderCategory, error)
+	ListByProducts(productIDs []uint) ([]models.ProviderProduct, error)
+	ReplaceCategories(providerID uint, categories []models.ProviderCategory) error
+	ReplaceProducts(providerID uint, products []models.ProviderProduct) error
+	SavePrice(price *models.ProviderProduct) error // create or replace the last price of the product of the provider when it is not older, the reference is kept
+}
+
+// RequirementRepository requirements persistence
+type RequirementRepository interface {
+	List(page Page) ([]models.Requirement, uint, error)
+	Get(id uint) (models.Requirement, error)
+	Create(requirement *models.Requirement) error // with its requires
+	Update(requirement *models.Requirement) error // only the non zero fields, without requires and quotations
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error                                              // with its state changes
+	ListStateChanges(requirementID uint) ([]models.StateChange, error) // ordered by date
+	AddStateChange(change *models.StateChange) error
+}
+
+// RequireRepository requires (lines) of the requirements persistence
+type RequireRepository interface {
+	ListByRequirement(requirementID uint) ([]RequireLine, error)
+	Get(id uint) (models.Require, error)
+	Delete(id uint) error
+	DeleteByRequirement(requirementID uint) error
+}
+
+// QuotationRepository quotations and its details persistence
+type QuotationRepository interface {
+	ListByRequirement(requirementID uint) ([]models.Quotation, error) // with details, ordered by winner level
+	ListWinners() ([]models.Quotation, error)                         // with details, the winners of all the requirements
+	Get(id uint) (models.Quotation, error)                            // with details
+	Count(requirementID uint) (uint, error)
+	CountAll() (uint, error)                              // quotations of all the requirements
+	EmissionDates(currency string) ([]time.Time, error)   // of the quotations in the currency
+	Records(providerIDs []uint) ([]ProviderRecord, error) // quotations and wins of the providers in the awarded requirements
+	Create(quotation *models.Quotation) error             // with its details
+	UpdateFields(id uint, fields map[string]interface{}) error
+	UpdateDetailFields(id uint, fields map[string]interface{}) error
+	ResetWinner(requirementID uint) error
+	Delete(id uint) error
+}
+
+// RevisionRepository revisions of the offer of the quotations persistence
+type RevisionRepository interface {
+	ListByQuotation(quotationID uint) ([]models.QuotationRevision, error) // with details, ordered by number
+	Create(revision *models.QuotationRevision) error                      // with its details
+	DeleteByQuotation(quotationID uint) error
+}
+
+// RfqRepository requests for quotation sent to the providers persistence
+type RfqRepository interface {
+	ListByRequirement(requirementID uint) ([]models.Rfq, error)
+	GetLast(requirementID uint, providerID uint) (models.Rfq, error) // last sent to the provider
+	Create(rfq *models.Rfq) error
+	UpdateFields(id uint, fields map[string]interface{}) error
+}
+
+// ExchangeRateRepository exchange rates to the base currency persistence
+type ExchangeRateRepository interface {
+	List(page Page) ([]models.ExchangeRate, uint, error)
+	Get(id uint) (models.ExchangeRate, error)
+	Find(currency string, date time.Time) (models.ExchangeRate, error) // last rate on or before the date
+	Save(rate *models.ExchangeRate) error                              // create or replace the rate of the date
+	Delete(id uint) error
+}
+
+// CostCenterRepository cost centers and its budgets by year persistence
+type CostCenterRepository interface {
+	List(page Page) ([]models.CostCenter, uint, error) // with budgets
+	Get(id uint) (models.CostCenter, error)            // with budgets
+	Create(costCenter *models.CostCenter) error
+	Update(costCenter *models.CostCenter) error // only the non zero fields
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error // with its budgets
+	GetBudget(costCenterID uint, year uint) (models.Budget, error)
+	SaveBudget(budget *models.Budget) error // create or replace the budget of the year
+	CountRequirements(id uint) (uint, error)
+}
+
+// CommitmentRepository budget committed by the awarded requirements persistence
+type CommitmentRepository interface {
+	List(costCenterID uint, year int) ([]models.Commitment, error) // awarded in the year, costCenterID = 0 means all
+	GetByRequirement(requirementID uint) (models.Commitment, error)
+	Save(commitment *models.Commitment) error // create or replace the commitment of the requirement
+	UpdateFields(id uint, fields map[string]interface{}) error
+}
+
+// TemplateRepository requirement templates and its products persistence
+type TemplateRepository interface {
+	List(page Page) ([]models.RequirementTemplate, uint, error)  // with requires
+	ListDue(now time.Time) ([]models.RequirementTemplate, error) // with requires, scheduled to run before now
+	Get(id uint) (models.RequirementTemplate, error)             // with requires
+	Create(template *models.RequirementTemplate) error           // with its requires
+	Update(template *models.RequirementTemplate) error           // only the non zero fields, without requires
+	UpdateFields(id uint, fields map[string]interface{}) error
+	ReplaceRequires(id uint, requires []models.TemplateRequire) error
+	Advance(id uint, from time.Time, to *time.Time) error // ErrNotFound when other process already moved the run
+	Delete(id uint) error                                 // with its requires
+}
+
+// AttachmentRepository metadata of the attached files, the owner is the kind of record
+type AttachmentRepository interface {
+	ListByOwner(owner string, ownerID uint) ([]models.Attachment, error) // ordered by upload
+	Count(owner string, ownerID uint) (uint, error)
+	Get(id uint) (models.Attachment, error)
+	Create(attachment *models.Attachment) error
+	Delete(id uint) error
+}
+
+// CommentRepository comments of the requirements and quotations and its mentions persistence
+type CommentRepository interface {
+	ListByOwner(owner string, ownerID uint) ([]models.Comment, error) // with mentions, ordered by date
+	ListByRequirement(requirementID uint) ([]models.Comment, error)   // with mentions, of the requirement and its quotations
+	Get(id uint) (models.Comment, error)                              // with mentions
+	Count(owner string, ownerID uint) (uint, error)
+	Create(comment *models.Comment) error // with its mentions
+	UpdateFields(id uint, fields map[string]interface{}) error
+	ReplaceMentions(id uint, mentions []models.Mention) error
+	Delete(id uint) error // with its mentions
+}
+
+// NotificationRepository notifications of the users and its email preferences persistence
+type NotificationRepository interface {
+	List(userID uint, unread bool, page Page) ([]models.Notification, uint, error) // newest first, only the unread when unread is true
+	CountUnread(userID uint) (uint, error)
+	Create(notification *models.Notification) error
+	MarkRead(userID uint, ids []uint, at time.Time) error // ids = nil marks all the notifications of the user
+	ListPreferences(userID uint) ([]models.NotificationPreference, error)
+	SavePreference(preference *models.NotificationPreference) error // create or replace the preference of the event
+}
+
+// WebhookRepository webhook subscriptions and the log of its deliveries persistence
+type WebhookRepository interface {
+	List() ([]models.Webhook, error)
+	ListActive() ([]models.Webhook, error)
+	Get(id uint) (models.Webhook, error)
+	Create(webhook *models.Webhook) error
+	UpdateFields(id uint, fields map[string]interface{}) error
+	Delete(id uint) error // with its deliveries
+
+	ListDeliveries(webhookID uint, page Page) ([]models.WebhookDelivery, uint, error) // newest first
+	ListDue(now time.Time, ids []uint, limit uint) ([]models.WebhookDelivery, error)  // pending with the next attempt before now, oldest first, ids = nil for all
+	GetDelivery(id uint) (models.WebhookDelivery, error)
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	UpdateDeliveryFields(id uint, fields map[string]interface{}) error
+	ClaimDelivery(id uint, now time.Time, until time.Time) (bool, error) // move the next attempt of the due delivery to until, false when it was claimed before
+}
+
+// EmailRepository outbox of the emails persistence
+type EmailRepository interface {
+	List(state string, page Page) ([]models.Email, uint, error) // newest first, with the names of the attachments, state = "" for all
+	Get(id uint) (models.Email, error)                          // with the attachments
+	Create(email *models.Email) error                           // with its attachments
+	UpdateFields(id uint, fields map[string]interface{}) error
+	ListDue(now time.Time, ids []uint, limit uint) ([]models.Email, error) // ids of the pending emails with the next attempt before now, ids = nil for all
+	Claim(id uint, now time.Time, until time.Time) (bool, error)           // move the next attempt of the due email to until, false when it was claimed before
+}
+
+// SettingRepository global setting persistence, there is only one setting
+type SettingRepository interface {
+	Get() (models.Setting, error) // zero setting when it was not created
+	Save(setting *models.Setting) error
+}
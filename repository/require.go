@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type requireRepository struct {
+	db *gorm.DB
+}
+
+func (r requireRepository) ListByRequirement(requirementID uint) ([]RequireLine, error) {
+	lines := make([]RequireLine, 0)
+	err := r.db.Table("requires").
+		Select("requires.id, requires.amount, requires.unit_measure, requires.suggested_price, requires.observation, requires.product_id, products.name as product_name, products.exempt, requires.requirement_id").
+		Joins("INNER JOIN products on requires.product_id = products.id").
+		Where("requires.requirement_id = ?", requirementID).
+		Order("requires.id asc").
+		Scan(&lines).Error
+	return lines, err
+}
+
+func (r requireRepository) Get(id uint) (models.Require, error) {
+	require := models.Require{}
+	err := r.db.First(&require, id).Error
+	return require, find(err)
+}
+
+func (r requireRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.Require{ID: id}))
+}
+
+func (r requireRepository) DeleteByRequirement(requirementID uint) error {
+	return r.db.Where("requirement_id = ?", requirementID).Delete(&models.Require{}).Error
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type requirementRepository struct {
+	db *gorm.DB
+}
+
+func (r requirementRepository) List(page Page) ([]models.Requirement, uint, error) {
+	var total uint
+	requirements := make([]models.Requirement, 0)
+	err := paginate(r.db.Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Or("lower(destination) LIKE lower(?)", like(page.Search)).
+		Or("lower(claimant) LIKE lower(?)", like(page.Search)).
+		Order("id desc"), page, &requirements, &total)
+	return requirements, total, err
+}
+
+func (r requirementRepository) Get(id uint) (models.Requirement, error) {
+	requirement := models.Requirement{}
+	err := r.db.First(&requirement, id).Error
+	return requirement, find(err)
+}
+
+func (r requirementRepository) Create(requirement *models.Requirement) error {
+	return r.db.Create(requirement).Error
+}
+
+func (r requirementRepository) Update(requirement *models.Requirement) error {
+	return affected(r.db.Model(&models.Requirement{ID: requirement.ID}).
+		Omit("Requires", "Quotations").Updates(*requirement))
+}
+
+func (r requirementRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Requirement{ID: id}).UpdateColumns(fields))
+}
+
+func (r requirementRepository) Delete(id uint) error {
+	if err := r.db.Where("requirement_id = ?", id).Delete(&models.StateChange{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.Requirement{ID: id}))
+}
+
+func (r requirementRepository) ListStateChanges(requirementID uint) ([]models.StateChange, error) {
+	changes := make([]models.StateChange, 0)
+	err := r.db.Where("requirement_id = ?", requirementID).Order("created_at asc, id asc").Find(&changes).Error
+	return changes, err
+}
+
+func (r requirementRepository) AddStateChange(change *models.StateChange) error {
+	return r.db.Create(change).Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type revisionRepository struct {
+	db *gorm.DB
+}
+
+// revisionDetails preload the details of the revisions in the order of the requires
+func revisionDetails(db *gorm.DB) *gorm.DB {
+	return db.Order("require_id asc, id asc")
+}
+
+func (r revisionRepository) ListByQuotation(quotationID uint) ([]models.QuotationRevision, error) {
+	revisions := make([]models.QuotationRevision, 0)
+	err := r.db.Preload("RevisionDetails", revisionDetails).
+		Where("quotation_id = ?", quotationID).
+		Order("number asc").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r revisionRepository) Create(revision *models.QuotationRevision) error {
+	return r.db.Create(revision).Error
+}
+
+// DeleteByQuotation remove the revisions with its details, must run inside a transaction
+func (r revisionRepository) DeleteByQuotation(quotationID uint) error {
+	revisions := r.db.Model(&models.QuotationRevision{}).Select("id").Where("quotation_id = ?", quotationID).QueryExpr()
+	if err := r.db.Where("quotation_revision_id IN (?)", revisions).Delete(&models.RevisionDetail{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("quotation_id = ?", quotationID).Delete(&models.QuotationRevision{}).Error
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type rfqRepository struct {
+	db *gorm.DB
+}
+
+func (r rfqRepository) ListByRequirement(requirementID uint) ([]models.Rfq, error) {
+	rfqs := make([]models.Rfq, 0)
+	err := r.db.Where("requirement_id = ?", requirementID).Order("id asc").Find(&rfqs).Error
+	return rfqs, err
+}
+
+func (r rfqRepository) GetLast(requirementID uint, providerID uint) (models.Rfq, error) {
+	rfq := models.Rfq{}
+	err := r.db.Where("requirement_id = ? AND provider_id = ?", requirementID, providerID).
+		Order("id desc").First(&rfq).Error
+	return rfq, find(err)
+}
+
+func (r rfqRepository) Create(rfq *models.Rfq) error {
+	return r.db.Create(rfq).Error
+}
+
+func (r rfqRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Rfq{ID: id}).UpdateColumns(fields))
+}
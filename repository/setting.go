@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func (r settingRepository) Get() (models.Setting, error) {
+	setting := models.Setting{}
+	err := r.db.First(&setting).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return setting, nil
+	}
+	return setting, err
+}
+
+func (r settingRepository) Save(setting *models.Setting) error {
+	if setting.ID == 0 {
+		current, err := r.Get()
+		if err != nil {
+			return err
+		}
+		setting.ID = current.ID
+	}
+	if setting.ID == 0 {
+		return r.db.Create(setting).Error
+	}
+	return r.db.Model(&models.Setting{ID: setting.ID}).Updates(*setting).Error
+}
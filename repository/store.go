@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// store gorm implementation of Store
+type store struct {
+	db *gorm.DB
+	tx bool // inside a transaction
+}
+
+// NewStore create the store of the repositories over a gorm connection
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) Users() UserRepository                 { return userRepository{s.db} }
+func (s *store) Providers() ProviderRepository         { return providerRepository{s.db} }
+func (s *store) Products() ProductRepository           { return productRepository{s.db} }
+func (s *store) Requirements() RequirementRepository   { return requirementRepository{s.db} }
+func (s *store) Requires() RequireRepository           { return requireRepository{s.db} }
+func (s *store) Quotations() QuotationRepository       { return quotationRepository{s.db} }
+func (s *store) Settings() SettingRepository           { return settingRepository{s.db} }
+func (s *store) Rfqs() RfqRepository                   { return rfqRepository{s.db} }
+func (s *store) Revisions() RevisionRepository         { return revisionRepository{s.db} }
+func (s *store) ExchangeRates() ExchangeRateRepository { return exchangeRateRepository{s.db} }
+func (s *store) CostCenters() CostCenterRepository     { return costCenterRepository{s.db} }
+func (s *store) Commitments() CommitmentRepository     { return commitmentRepository{s.db} }
+func (s *store) Templates() TemplateRepository         { return templateRepository{s.db} }
+func (s *store) Attachments() AttachmentRepository     { return attachmentRepository{s.db} }
+func (s *store) Comments() CommentRepository           { return commentRepository{s.db} }
+func (s *store) Notifications() NotificationRepository { return notificationRepository{s.db} }
+func (s *store) Webhooks() WebhookRepository           { return webhookRepository{s.db} }
+func (s *store) Emails() EmailRepository               { return emailRepository{s.db} }
+func (s *store) Categories() CategoryRepository        { return categoryRepository{s.db} }
+func (s *store) Catalog() CatalogRepository            { return catalogRepository{s.db} }
+
+func (s *store) Transaction(fn func(tx Store) error) (err error) {
+	// The nested transactions run in the outer transaction
+	if s.tx {
+		return fn(s)
+	}
+
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(&store{db: tx, tx: true}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// find translate the gorm not found error
+func find(err error) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// affected return ErrNotFound when the statement did not change any row
+func affected(db *gorm.DB) error {
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// paginate apply the limits of the page and count the total of records
+func paginate(db *gorm.DB, page Page, out interface{}, total *uint) error {
+	if page.Limit == 0 {
+		return db.Find(out).Count(total).Error
+	}
+	return db.Offset(page.Offset).Limit(page.Limit).Find(out).
+		Offset(-1).Limit(-1).Count(total).Error
+}
+
+// like pattern of a case insensitive search
+func like(search string) string {
+	return "%" + search + "%"
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type templateRepository struct {
+	db *gorm.DB
+}
+
+// templateRequires preload the products of the templates in the order they were added
+func templateRequires(db *gorm.DB) *gorm.DB {
+	return db.Order("template_requires.id asc")
+}
+
+func (r templateRepository) List(page Page) ([]models.RequirementTemplate, uint, error) {
+	var total uint
+	templates := make([]models.RequirementTemplate, 0)
+	err := paginate(r.db.Preload("TemplateRequires", templateRequires).
+		Where("lower(name) LIKE lower(?)", like(page.Search)).
+		Or("lower(requirement) LIKE lower(?)", like(page.Search)).
+		Order("name asc"), page, &templates, &total)
+	return templates, total, err
+}
+
+func (r templateRepository) ListDue(now time.Time) ([]models.RequirementTemplate, error) {
+	templates := make([]models.RequirementTemplate, 0)
+	err := r.db.Preload("TemplateRequires", templateRequires).
+		Where("recurrence <> '' AND next_run IS NOT NULL AND next_run <= ?", now).
+		Order("next_run asc").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r templateRepository) Get(id uint) (models.RequirementTemplate, error) {
+	template := models.RequirementTemplate{}
+	err := r.db.Preload("TemplateRequires", templateRequires).First(&template, id).Error
+	return template, find(err)
+}
+
+func (r templateRepository) Create(template *models.RequirementTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r templateRepository) Update(template *models.RequirementTemplate) error {
+	return affected(r.db.Model(&models.RequirementTemplate{ID: template.ID}).
+		Omit("TemplateRequires").Updates(*template))
+}
+
+func (r templateRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.RequirementTemplate{ID: id}).UpdateColumns(fields))
+}
+
+// ReplaceRequires remove the products of the template and insert requires, must run inside a transaction
+func (r templateRepository) ReplaceRequires(id uint, requires []models.TemplateRequire) error {
+	if err := r.db.Where("requirement_template_id = ?", id).Delete(&models.TemplateRequire{}).Error; err != nil {
+		return err
+	}
+	for k := range requires {
+		requires[k].ID = 0
+		requires[k].RequirementTemplateID = id
+		if err := r.db.Create(&requires[k]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Advance move the next run of the template only when it is still from, so a single
+// process creates the requirement of the run
+func (r templateRepository) Advance(id uint, from time.Time, to *time.Time) error {
+	return affected(r.db.Model(&models.RequirementTemplate{}).
+		Where("id = ? AND next_run = ?", id, from).
+		UpdateColumn("next_run", to))
+}
+
+// Delete remove the template with its products, must run inside a transaction
+func (r templateRepository) Delete(id uint) error {
+	if err := r.db.Where("requirement_template_id = ?", id).Delete(&models.TemplateRequire{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.RequirementTemplate{ID: id}))
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r userRepository) List(page Page) ([]models.User, uint, error) {
+	var total uint
+	users := make([]models.User, 0)
+	err := paginate(r.db.Where("lower(user_name) LIKE lower(?)", like(page.Search)).
+		Or("lower(dni) LIKE lower(?)", like(page.Search)).
+		Or("lower(last_name) LIKE lower(?)", like(page.Search)).
+		Or("lower(first_name) LIKE lower(?)", like(page.Search)).
+		Order("id desc"), page, &users, &total)
+	return users, total, err
+}
+
+func (r userRepository) Get(id uint) (models.User, error) {
+	user := models.User{}
+	err := r.db.First(&user, id).Error
+	return user, find(err)
+}
+
+func (r userRepository) GetByLogin(login string) (models.User, error) {
+	user := models.User{}
+	err := r.db.Where("user_name = ?", login).Or("email = ?", login).First(&user).Error
+	return user, find(err)
+}
+
+func (r userRepository) GetByEmail(email string) (models.User, error) {
+	user := models.User{}
+	err := r.db.Where("email = ?", email).First(&user).Error
+	return user, find(err)
+}
+
+func (r userRepository) ListByIDs(ids []uint) ([]models.User, error) {
+	users := make([]models.User, 0)
+	err := r.db.Where("id IN (?)", ids).Find(&users).Error
+	return users, err
+}
+
+func (r userRepository) ListByNames(names []string) ([]models.User, error) {
+	users := make([]models.User, 0)
+	err := r.db.Where("user_name IN (?)", names).Find(&users).Error
+	return users, err
+}
+
+func (r userRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r userRepository) Update(user *models.User) error {
+	return affected(r.db.Model(&models.User{ID: user.ID}).Updates(*user))
+}
+
+func (r userRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.User{ID: id}).UpdateColumns(fields))
+}
+
+func (r userRepository) Delete(id uint) error {
+	return affected(r.db.Delete(&models.User{ID: id}))
+}
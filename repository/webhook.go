@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/paulantezana/requirement/models"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func (r webhookRepository) List() ([]models.Webhook, error) {
+	webhooks := make([]models.Webhook, 0)
+	err := r.db.Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r webhookRepository) ListActive() ([]models.Webhook, error) {
+	webhooks := make([]models.Webhook, 0)
+	err := r.db.Where("state = ?", true).Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r webhookRepository) Get(id uint) (models.Webhook, error) {
+	webhook := models.Webhook{}
+	err := r.db.First(&webhook, id).Error
+	return webhook, find(err)
+}
+
+func (r webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r webhookRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.Webhook{ID: id}).UpdateColumns(fields))
+}
+
+func (r webhookRepository) Delete(id uint) error {
+	if err := r.db.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return affected(r.db.Delete(&models.Webhook{ID: id}))
+}
+
+func (r webhookRepository) ListDeliveries(webhookID uint, page Page) ([]models.WebhookDelivery, uint, error) {
+	var total uint
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := paginate(r.db.Where("webhook_id = ?", webhookID).Order("id desc"), page, &deliveries, &total)
+	return deliveries, total, err
+}
+
+func (r webhookRepository) ListDue(now time.Time, ids []uint, limit uint) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+	db := r.db.Where("state = ? AND next_attempt <= ?", models.DeliveryPending, now)
+	if ids != nil {
+		db = db.Where("id IN (?)", ids)
+	}
+	err := db.Order("next_attempt asc, id asc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r webhookRepository) GetDelivery(id uint) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{}
+	err := r.db.First(&delivery, id).Error
+	return delivery, find(err)
+}
+
+func (r webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r webhookRepository) UpdateDeliveryFields(id uint, fields map[string]interface{}) error {
+	return affected(r.db.Model(&models.WebhookDelivery{ID: id}).UpdateColumns(fields))
+}
+
+func (r webhookRepository) ClaimDelivery(id uint, now time.Time, until time.Time) (bool, error) {
+	db := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND state = ? AND next_attempt <= ?", id, models.DeliveryPending, now).
+		UpdateColumn("next_attempt", until)
+	return db.RowsAffected == 1, db.Error
+}
@@ -0,0 +1,77 @@
+// Package scheduler runs jobs of the application in the same process at regular intervals
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paulantezana/requirement/logger"
+)
+
+// Job work done on every tick with the time of the tick
+type Job func(now time.Time) error
+
+type entry struct {
+	name string
+	job  Job
+}
+
+// Scheduler runs its jobs one after other on every interval until it is stopped
+type Scheduler struct {
+	interval time.Duration
+	jobs     []entry
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New scheduler that runs its jobs every interval
+func New(interval time.Duration) *Scheduler {
+	return &Scheduler{interval: interval, stop: make(chan struct{})}
+}
+
+// Add the job with a name for the logs, before Start
+func (s *Scheduler) Add(name string, job Job) {
+	s.jobs = append(s.jobs, entry{name: name, job: job})
+}
+
+// Start run the jobs now and on every interval in the background
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.run(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.run(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop the scheduler and wait for the running jobs
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// run the jobs, a failed or panicked job does not stop the others
+func (s *Scheduler) run(now time.Time) {
+	for _, e := range s.jobs {
+		func() {
+			log := logger.Default().WithField("job", e.name)
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("job panic: %v", r)
+				}
+			}()
+			if err := e.job(now); err != nil {
+				log.WithError(err).Errorf("job failed")
+			}
+		}()
+	}
+}
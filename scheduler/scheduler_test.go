@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	var runs int32
+	s := New(10 * time.Millisecond)
+	s.Add("failed", func(time.Time) error { return errors.New("failed") })
+	s.Add("panic", func(time.Time) error { panic("panic") })
+	s.Add("count", func(time.Time) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	s.Start()
+	time.Sleep(35 * time.Millisecond)
+	s.Stop()
+
+	// The first run is at the start, the failed jobs do not stop the others
+	if n := atomic.LoadInt32(&runs); n < 2 {
+		t.Errorf("expected at least 2 runs, got %d", n)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// Files storage where the content of the attachments is saved and max size of a file in bytes
+type Files struct {
+	Storage storage.Storage
+	MaxSize int64
+}
+
+// read the content of a upload up to the max size, the storage needs its size
+func (f Files) read(content io.Reader) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, io.LimitReader(content, f.MaxSize+1)); err != nil {
+		return nil, err
+	}
+	if int64(buf.Len()) > f.MaxSize {
+		return nil, utilities.NewError(http.StatusRequestEntityTooLarge, utilities.ErrFileTooLarge, f.MaxSize>>20)
+	}
+	return buf.Bytes(), nil
+}
+
+// fileType content type saved for a extension and the types detected from the content accepted
+// for it. The old office documents are not detected, they are only binary content
+type fileType struct {
+	contentType string
+	detected    []string
+}
+
+const (
+	detectedText = "text/plain; charset=utf-8"
+	detectedZip  = "application/zip"
+	detectedBin  = "application/octet-stream"
+)
+
+// fileTypes accepted extensions of the attachments
+var fileTypes = map[string]fileType{
+	".pdf":  {"application/pdf", []string{"application/pdf"}},
+	".png":  {"image/png", []string{"image/png"}},
+	".jpg":  {"image/jpeg", []string{"image/jpeg"}},
+	".jpeg": {"image/jpeg", []string{"image/jpeg"}},
+	".txt":  {"text/plain; charset=utf-8", []string{detectedText}},
+	".csv":  {"text/csv; charset=utf-8", []string{detectedText}},
+	".zip":  {"application/zip", []string{detectedZip}},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", []string{detectedZip}},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", []string{detectedZip}},
+	".doc":  {"application/msword", []string{detectedBin}},
+	".xls":  {"application/vnd.ms-excel", []string{detectedBin}},
+}
+
+// AttachmentService files attached to the requirements, the quotations and the providers
+type AttachmentService interface {
+	List(owner string, ownerID uint) ([]models.Attachment, error)
+	// Upload save the content of a file attached by the user, the type is checked by the
+	// extension of the name and by the content
+	Upload(userID uint, attachment *models.Attachment, content io.Reader) error
+	// Open the content of the attachment to download it, the attachments of the quotations are
+	// hidden while the bids are sealed. The caller closes the content
+	Open(id uint) (models.Attachment, io.ReadCloser, error)
+	// Link signed URL to download the attachment without authentication, with the checks of Open
+	Link(id uint) (string, error)
+	// Delete the attachment, only by the user that uploaded it or by a administrator
+	Delete(user models.User, id uint) error
+}
+
+type attachmentService struct {
+	store repository.Store
+	files Files
+}
+
+// NewAttachmentService create the attachment service over the store, with the content in the files
+func NewAttachmentService(store repository.Store, files Files) AttachmentService {
+	return &attachmentService{store: store, files: files}
+}
+
+// attachments of the record to list them in its detail
+func attachments(store repository.Store, owner string, ownerID uint) ([]models.Attachment, error) {
+	return store.Attachments().ListByOwner(owner, ownerID)
+}
+
+// attached conflict when the record has attachments, they are deleted first
+func attached(store repository.Store, owner string, ownerID uint) error {
+	count, err := store.Attachments().Count(owner, ownerID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	return nil
+}
+
+func (s *attachmentService) List(owner string, ownerID uint) ([]models.Attachment, error) {
+	return attachments(s.store, owner, ownerID)
+}
+
+// owner check that the record of the attachment exists, the files of a comment are attached by its author
+func (s *attachmentService) owner(userID uint, attachment models.Attachment) ([]utilities.FieldError, error) {
+	var err error
+	switch attachment.Owner {
+	case models.AttachmentComment:
+		var comment models.Comment
+		comment, err = s.store.Comments().Get(attachment.OwnerID)
+		if err == nil && comment.UserID != userID {
+			return nil, utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+		}
+	case models.AttachmentRequirement:
+		_, err = s.store.Requirements().Get(attachment.OwnerID)
+	case models.AttachmentQuotation:
+		_, err = s.store.Quotations().Get(attachment.OwnerID)
+	case models.AttachmentProvider:
+		_, err = s.store.Providers().Get(attachment.OwnerID)
+	default:
+		return nil, nil
+	}
+	switch {
+	case err == repository.ErrNotFound:
+		return []utilities.FieldError{reference("owner_id", attachment.OwnerID)}, nil
+	case err != nil:
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *attachmentService) Upload(userID uint, attachment *models.Attachment, content io.Reader) error {
+	attachment.Name = filepath.Base(strings.Replace(attachment.Name, "\\", "/", -1))
+	if attachment.Name == "." || attachment.Name == "/" {
+		attachment.Name = ""
+	}
+	details := utilities.ValidateStruct(attachment)
+	owner, err := s.owner(userID, *attachment)
+	if err != nil {
+		return err
+	}
+	if err := invalid(append(details, owner...)); err != nil {
+		return err
+	}
+
+	ext := strings.ToLower(filepath.Ext(attachment.Name))
+	kind, ok := fileTypes[ext]
+	if !ok {
+		return utilities.NewError(http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	}
+
+	data, err := s.files.read(content)
+	if err != nil {
+		return err
+	}
+	// The type detected from the content must be the type of the extension
+	if !accepted(kind, http.DetectContentType(data)) {
+		return utilities.NewError(http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	attachment.ID = 0
+	attachment.Path = fmt.Sprintf("%s/%d/%s%s", attachment.Owner, attachment.OwnerID, name, ext)
+	attachment.ContentType = kind.contentType
+	attachment.Size = int64(len(data))
+	attachment.Checksum = hex.EncodeToString(sum[:])
+	attachment.UserID = userID
+	if err := s.files.Storage.Put(attachment.Path, bytes.NewReader(data), attachment.Size, attachment.ContentType); err != nil {
+		return err
+	}
+	if err := s.store.Attachments().Create(attachment); err != nil {
+		s.files.Storage.Delete(attachment.Path)
+		return err
+	}
+	return nil
+}
+
+// accepted check if the detected content type is one of the types of the extension
+func accepted(kind fileType, detected string) bool {
+	for _, d := range kind.detected {
+		if d == detected {
+			return true
+		}
+	}
+	return false
+}
+
+// randomName unique name of a saved file, not guessable from the record
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// readable get the attachment that can be downloaded: the proformas have the prices of the
+// sealed bids, the files of the quotations and its comments are downloaded after the opening
+func (s *attachmentService) readable(id uint) (models.Attachment, error) {
+	attachment, err := s.store.Attachments().Get(id)
+	if err != nil {
+		return attachment, notFound(err, id)
+	}
+	quotationID := attachment.OwnerID
+	switch attachment.Owner {
+	case models.AttachmentQuotation:
+	case models.AttachmentComment:
+		comment, err := s.store.Comments().Get(attachment.OwnerID)
+		if err != nil {
+			return attachment, notFound(err, attachment.OwnerID)
+		}
+		if comment.Owner != models.CommentQuotation {
+			return attachment, nil
+		}
+		quotationID = comment.OwnerID
+	default:
+		return attachment, nil
+	}
+	quotation, err := s.store.Quotations().Get(quotationID)
+	if err != nil {
+		return attachment, notFound(err, quotationID)
+	}
+	isSealed, err := unseal(s.store, quotation.RequirementID)
+	if err != nil {
+		return attachment, err
+	}
+	if isSealed {
+		return attachment, utilities.NewError(http.StatusConflict, utilities.ErrSealed, quotation.RequirementID)
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) Open(id uint) (models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.readable(id)
+	if err != nil {
+		return attachment, nil, err
+	}
+	content, err := s.files.Storage.Get(attachment.Path)
+	if err == storage.ErrNotFound {
+		return attachment, nil, utilities.NewNotFoundError(id)
+	}
+	return attachment, content, err
+}
+
+func (s *attachmentService) Link(id uint) (string, error) {
+	attachment, err := s.readable(id)
+	if err != nil {
+		return "", err
+	}
+	return s.files.Storage.URL(attachment.Path, attachment.Name)
+}
+
+func (s *attachmentService) Delete(user models.User, id uint) error {
+	attachment, err := s.store.Attachments().Get(id)
+	if err != nil {
+		return notFound(err, id)
+	}
+	if user.Profile != "admin" && user.ID != attachment.UserID {
+		return utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+	}
+	if err := s.store.Attachments().Delete(id); err != nil {
+		return notFound(err, id)
+	}
+
+	// The record is the reference, a file not removed is only wasted space
+	s.files.Storage.Delete(attachment.Path)
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+const pdf = "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n"
+
+func TestAttachmentUpload(t *testing.T) {
+	f := newFixture(t)
+	attachments := NewAttachmentService(f.store, Files{Storage: storage.NewLocal(t.TempDir(), "/files", []byte("secret"), time.Minute), MaxSize: 1024})
+
+	spec := models.Attachment{Owner: models.AttachmentRequirement, OwnerID: f.requirement.ID, Name: "C:\\specs\\Specs.PDF"}
+	f.must(attachments.Upload(f.user.ID, &spec, strings.NewReader(pdf)))
+	sum := sha256.Sum256([]byte(pdf))
+	if spec.Name != "Specs.PDF" || spec.ContentType != "application/pdf" || spec.Size != int64(len(pdf)) || spec.Checksum != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected attachment %+v", spec)
+	}
+
+	// Listed in the detail of the requirement, the content is the uploaded file
+	requirement, err := NewRequirementService(f.store).Get(f.requirement.ID)
+	f.must(err)
+	if len(requirement.Attachments) != 1 || requirement.Attachments[0].ID != spec.ID {
+		t.Fatalf("expected the attachment in the requirement, got %+v", requirement.Attachments)
+	}
+	_, content, err := attachments.Open(spec.ID)
+	f.must(err)
+	data, err := ioutil.ReadAll(content)
+	content.Close()
+	f.must(err)
+	if string(data) != pdf {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	// A executable renamed to pdf, a unknown extension and a big file
+	fake := models.Attachment{Owner: models.AttachmentRequirement, OwnerID: f.requirement.ID, Name: "specs.pdf"}
+	expectError(t, attachments.Upload(f.user.ID, &fake, strings.NewReader("MZ\x90\x00\x03\x00\x00\x00")), http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	fake.Name = "setup.exe"
+	expectError(t, attachments.Upload(f.user.ID, &fake, strings.NewReader(pdf)), http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	fake.Name = "big.pdf"
+	expectError(t, attachments.Upload(f.user.ID, &fake, strings.NewReader(pdf+strings.Repeat(" ", 1024))), http.StatusRequestEntityTooLarge, utilities.ErrFileTooLarge)
+	fake = models.Attachment{Owner: models.AttachmentProvider, OwnerID: 99, Name: "contract.pdf"}
+	expectError(t, attachments.Upload(f.user.ID, &fake, strings.NewReader(pdf)), http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// The requirement is deleted after its attachments, only by the uploader or a administrator
+	expectError(t, NewRequirementService(f.store).Delete(f.requirement.ID), http.StatusConflict, utilities.ErrInUse)
+	expectError(t, attachments.Delete(models.User{ID: f.user.ID + 1, Profile: "user"}, spec.ID), http.StatusForbidden, utilities.ErrForbidden)
+	f.must(attachments.Delete(models.User{ID: f.user.ID, Profile: "user"}, spec.ID))
+	f.must(NewRequirementService(f.store).Delete(f.requirement.ID))
+}
+
+func TestAttachmentSealedQuotation(t *testing.T) {
+	f := newFixture(t)
+	f.must(NewRequirementService(f.store).Update(f.sealed()))
+	quotation := f.quote(0, 5, 10)
+
+	attachments := NewAttachmentService(f.store, Files{Storage: storage.NewLocal(t.TempDir(), "/files", []byte("secret"), time.Minute), MaxSize: 1024})
+	proforma := models.Attachment{Owner: models.AttachmentQuotation, OwnerID: quotation.ID, Name: "proforma.pdf"}
+	f.must(attachments.Upload(f.user.ID, &proforma, strings.NewReader(pdf)))
+
+	// The proforma has the prices, it is downloaded after the opening
+	_, _, err := attachments.Open(proforma.ID)
+	expectError(t, err, http.StatusConflict, utilities.ErrSealed)
+	_, err = attachments.Link(proforma.ID)
+	expectError(t, err, http.StatusConflict, utilities.ErrSealed)
+	f.must(NewAwardService(f.store).OpenBids(f.user, f.requirement.ID))
+	_, content, err := attachments.Open(proforma.ID)
+	f.must(err)
+	content.Close()
+	link, err := attachments.Link(proforma.ID)
+	f.must(err)
+	if !strings.HasPrefix(link, "/files/quotation/") {
+		t.Errorf("unexpected link %s", link)
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// AwardService ranking of the quotations and award of the requirements
+type AwardService interface {
+	// Rank the quotations of the requirement, the cheapest quotation gets winner level 1,
+	// the partial quotations go after the complete quotations
+	Rank(requirementID uint) error
+	// Award set the winner quotation, quotationID = 0 awards the first of the ranking. The total
+	// of the winner is committed in the budget of the cost center of the requirement
+	Award(userID uint, requirementID uint, quotationID uint) (Awarded, error)
+	// OpenBids reveal the prices of a sealed requirement before its expiration date, the opening is recorded
+	OpenBids(user models.User, requirementID uint) error
+	// Ranking ranked quotations of the requirement by winner level, empty while the bids are sealed
+	Ranking(requirementID uint) ([]Ranked, error)
+}
+
+// Ranked position of a quotation in the ranking of its requirement
+type Ranked struct {
+	QuotationID uint `json:"quotation_id"`
+	ProviderID  uint `json:"provider_id"`
+	WinnerLevel uint `json:"winner_level"`
+	Winner      bool `json:"winner"`
+}
+
+// Awarded winner quotation of a requirement with the check of the budget of its cost center,
+// an award over the budget is only warned when the setting does not block it
+type Awarded struct {
+	QuotationID uint `json:"quotation_id"`
+	BudgetCheck
+}
+
+type awardService struct {
+	store repository.Store
+}
+
+// NewAwardService create the award service over the store
+func NewAwardService(store repository.Store) AwardService {
+	return &awardService{store: store}
+}
+
+// sealed check if the prices of the quotations of the requirement are still hidden, the bids
+// are revealed by the opening or at the end of the expiration day
+func sealed(requirement models.Requirement) bool {
+	if !requirement.Sealed || requirement.OpenedAt != nil {
+		return false
+	}
+	return requirement.ExpirationDate.IsZero() || LinkExpiration(requirement).After(time.Now())
+}
+
+// unseal check if the bids of the requirement are still sealed, the bids whose expiration
+// date passed are opened, recorded without user, and ranked
+func unseal(store repository.Store, requirementID uint) (bool, error) {
+	requirement, err := store.Requirements().Get(requirementID)
+	if err != nil {
+		return false, notFound(err, requirementID)
+	}
+	if sealed(requirement) {
+		return true, nil
+	}
+	if requirement.Sealed && requirement.OpenedAt == nil {
+		return false, openBids(store, requirement, 0)
+	}
+	return false, nil
+}
+
+// openBids record the opening of the bids of the requirement, rank its quotations and save
+// their prices in the catalogs of the providers
+func openBids(store repository.Store, requirement models.Requirement, userID uint) error {
+	fields := map[string]interface{}{"opened_at": time.Now(), "opened_by": userID}
+	if err := store.Requirements().UpdateFields(requirement.ID, fields); err != nil {
+		return notFound(err, requirement.ID)
+	}
+	quotations, err := store.Quotations().ListByRequirement(requirement.ID)
+	if err != nil {
+		return err
+	}
+	for _, q := range quotations {
+		if err := recordPrices(store, q.ID, q.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return NewAwardService(store).Rank(requirement.ID)
+}
+
+func (s *awardService) Rank(requirementID uint) error {
+	// The sealed bids are ranked on the opening
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return notFound(err, requirementID)
+	}
+	if sealed(requirement) {
+		return nil
+	}
+
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return err
+	}
+	lines, err := requires(s.store, requirementID)
+	if err != nil {
+		return err
+	}
+
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return err
+	}
+
+	// Only the approved quotations with prices are ranked, by its total with IGV in the base currency
+	ranked := make([]models.Quotation, 0, len(quotations))
+	totals := make(map[uint]decimal.Decimal, len(quotations))
+	for _, q := range quotations {
+		if q.Pending || len(q.QuotationDetails) == 0 {
+			continue
+		}
+		rate, err := conv.rate(q)
+		if err != nil {
+			return err
+		}
+		amounts, err := taxes(q, lines)
+		if err != nil {
+			return err
+		}
+		if totals[q.ID], err = convert(amounts.Total, rate); err != nil {
+			return err
+		}
+		ranked = append(ranked, q)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Partial != ranked[j].Partial {
+			return !ranked[i].Partial
+		}
+		return totals[ranked[i].ID].Cmp(totals[ranked[j].ID]) < 0
+	})
+
+	for k, q := range ranked {
+		if err := s.store.Quotations().UpdateFields(q.ID, map[string]interface{}{"winner_level": uint(k) + 1}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *awardService) Award(userID uint, requirementID uint, quotationID uint) (Awarded, error) {
+	awarded := Awarded{}
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return awarded, notFound(err, requirementID)
+	}
+	if !CanChangeState(requirement.State, models.RequirementAwarded) {
+		return awarded, invalidState(requirementID)
+	}
+	if isSealed, err := unseal(s.store, requirementID); err != nil || isSealed {
+		if err != nil {
+			return awarded, err
+		}
+		return awarded, utilities.NewError(http.StatusConflict, utilities.ErrSealed, requirementID)
+	}
+
+	// Automatic calculate: the first of the ranking
+	if quotationID == 0 {
+		if err := s.Rank(requirementID); err != nil {
+			return awarded, err
+		}
+		quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+		if err != nil {
+			return awarded, err
+		}
+		for _, q := range quotations {
+			if !q.Pending && q.WinnerLevel == 1 {
+				quotationID = q.ID
+				break
+			}
+		}
+		if quotationID == 0 {
+			return awarded, utilities.NewError(http.StatusConflict, utilities.ErrNoQuotations, requirementID)
+		}
+	}
+
+	quotation, err := s.store.Quotations().Get(quotationID)
+	if err != nil || quotation.RequirementID != requirementID {
+		if err != nil && err != repository.ErrNotFound {
+			return awarded, err
+		}
+		return awarded, utilities.NewNotFoundError(quotationID)
+	}
+	if quotation.Pending {
+		return awarded, utilities.NewError(http.StatusConflict, utilities.ErrQuotationPending, quotationID)
+	}
+	awarded.QuotationID = quotationID
+
+	// Budget of the cost center, the requirements created before the cost centers are not controlled
+	if requirement.CostCenterID != 0 {
+		if awarded.BudgetCheck, err = checkBudget(s.store, requirement, quotation); err != nil {
+			return awarded, err
+		}
+		setting, err := s.store.Settings().Get()
+		if err != nil {
+			return awarded, err
+		}
+		if awarded.OverBudget && setting.BudgetBlock {
+			return awarded, utilities.NewError(http.StatusConflict, utilities.ErrBudgetExceeded,
+				awarded.Amount.StringFixed(decimal.MoneyScale), awarded.Available.StringFixed(decimal.MoneyScale))
+		}
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quotations().ResetWinner(requirementID); err != nil {
+			return err
+		}
+		if err := tx.Quotations().UpdateFields(quotationID, map[string]interface{}{"winner": true}); err != nil {
+			return notFound(err, quotationID)
+		}
+		if requirement.CostCenterID != 0 {
+			if err := commit(tx, requirement, quotationID, awarded.Amount); err != nil {
+				return err
+			}
+		}
+		return changeState(tx, requirement, models.RequirementAwarded, userID)
+	})
+	return awarded, err
+}
+
+func (s *awardService) OpenBids(user models.User, requirementID uint) error {
+	// Only the administrators open the bids before the expiration date
+	if user.Profile != "admin" {
+		return utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+	}
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return notFound(err, requirementID)
+	}
+	if !requirement.Sealed {
+		return invalidState(requirementID)
+	}
+	if requirement.OpenedAt != nil {
+		return nil
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		return openBids(tx, requirement, user.ID)
+	})
+}
+
+func (s *awardService) Ranking(requirementID uint) ([]Ranked, error) {
+	ranking := make([]Ranked, 0)
+	isSealed, err := unseal(s.store, requirementID)
+	if err != nil || isSealed {
+		return ranking, err
+	}
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quotations {
+		if q.WinnerLevel == 0 {
+			continue
+		}
+		ranking = append(ranking, Ranked{QuotationID: q.ID, ProviderID: q.ProviderID, WinnerLevel: q.WinnerLevel, Winner: q.Winner})
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].WinnerLevel < ranking[j].WinnerLevel
+	})
+	return ranking, nil
+}
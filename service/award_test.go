@@ -0,0 +1,231 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestRankOrdersByTotal(t *testing.T) {
+	f := newFixture(t)
+
+	// totals: 10*5 + 2*10 = 70, 10*3 + 2*30 = 90, 10*4 + 2*5 = 50
+	a := f.quote(0, 5, 10)
+	b := f.quote(1, 3, 30)
+	c := f.quote(2, 4, 5)
+
+	want := map[uint]uint{c.ID: 1, a.ID: 2, b.ID: 3}
+	quotations, err := NewQuotationService(f.store).List(f.requirement.ID)
+	f.must(err)
+	if len(quotations) != 3 {
+		t.Fatalf("expected 3 quotations, got %d", len(quotations))
+	}
+	for _, q := range quotations {
+		if q.WinnerLevel != want[q.ID] {
+			t.Errorf("quotation %d: expected winner level %d, got %d", q.ID, want[q.ID], q.WinnerLevel)
+		}
+	}
+	if quotations[0].ID != c.ID || quotations[0].Summation != dec(50) {
+		t.Errorf("expected quotation %d first with 50, got %d with %v", c.ID, quotations[0].ID, quotations[0].Summation)
+	}
+}
+
+func TestRankAfterUpdate(t *testing.T) {
+	f := newFixture(t)
+	a := f.quote(0, 5, 10)
+	b := f.quote(1, 6, 10)
+
+	// b becomes the cheapest
+	b.QuotationDetails[0].UnitPrice = dec(1)
+	f.must(NewQuotationService(f.store).Update(f.user.ID, &models.Quotation{ID: b.ID, DeliverDate: b.DeliverDate, QuotationDetails: b.QuotationDetails}))
+
+	for id, level := range map[uint]uint{a.ID: 2, b.ID: 1} {
+		q, err := f.store.Quotations().Get(id)
+		f.must(err)
+		if q.WinnerLevel != level {
+			t.Errorf("quotation %d: expected winner level %d, got %d", id, level, q.WinnerLevel)
+		}
+	}
+}
+
+func TestAwardAutomatic(t *testing.T) {
+	f := newFixture(t)
+	f.quote(0, 5, 10)
+	cheapest := f.quote(1, 1, 1)
+
+	awarded, err := NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	if awarded.QuotationID != cheapest.ID {
+		t.Fatalf("expected winner %d, got %d", cheapest.ID, awarded.QuotationID)
+	}
+	if state := f.state(); state != models.RequirementAwarded {
+		t.Fatalf("expected state %s, got %s", models.RequirementAwarded, state)
+	}
+
+	order, err := NewQuotationService(f.store).PurchaseOrder(f.requirement.ID)
+	f.must(err)
+	if order.Provider.ID != f.providers[1].ID || len(order.PurchaseOrder) != 2 {
+		t.Fatalf("unexpected purchase order %+v", order)
+	}
+}
+
+func TestAwardManualReplacesWinner(t *testing.T) {
+	f := newFixture(t)
+	a := f.quote(0, 5, 10)
+	b := f.quote(1, 1, 1)
+
+	award := NewAwardService(f.store)
+	_, err := award.Award(f.user.ID, f.requirement.ID, b.ID)
+	f.must(err)
+	_, err = award.Award(f.user.ID, f.requirement.ID, a.ID)
+	f.must(err)
+
+	for id, winner := range map[uint]bool{a.ID: true, b.ID: false} {
+		q, err := f.store.Quotations().Get(id)
+		f.must(err)
+		if q.Winner != winner {
+			t.Errorf("quotation %d: expected winner %v", id, winner)
+		}
+	}
+}
+
+func TestAwardErrors(t *testing.T) {
+	f := newFixture(t)
+	award := NewAwardService(f.store)
+
+	// Without quotations the requirement can not be awarded
+	_, err := award.Award(f.user.ID, f.requirement.ID, 0)
+	expectError(t, err, http.StatusConflict, utilities.ErrInvalidState)
+
+	f.quote(0, 5, 10)
+
+	// Quotation of other requirement
+	_, err = award.Award(f.user.ID, f.requirement.ID, 999)
+	expectError(t, err, http.StatusNotFound, utilities.ErrNotFound)
+
+	_, err = NewQuotationService(f.store).PurchaseOrder(f.requirement.ID)
+	expectError(t, err, http.StatusNotFound, utilities.ErrNoWinner)
+}
+
+func TestSealedBids(t *testing.T) {
+	f := newFixture(t)
+	f.must(NewRequirementService(f.store).Update(f.sealed()))
+	a := f.quote(0, 5, 10)
+	b := f.quote(1, 1, 1)
+
+	// Prices and ranking hidden until the opening
+	quotations := NewQuotationService(f.store)
+	list, err := quotations.List(f.requirement.ID)
+	f.must(err)
+	for _, q := range list {
+		if !q.Sealed || q.Summation != dec(0) || q.WinnerLevel != 0 {
+			t.Fatalf("expected sealed quotation, got %+v", q)
+		}
+	}
+	view, err := quotations.Detail(a.ID)
+	f.must(err)
+	if view.QuotationDetails[0].UnitPrice != dec(0) {
+		t.Fatalf("expected hidden unit price, got %v", view.QuotationDetails[0].UnitPrice)
+	}
+	table, err := quotations.ComparativeTable(f.requirement.ID, false)
+	f.must(err)
+	if !table.Sealed || table.CTResponseQuotations[0].UnitPrice != dec(0) {
+		t.Fatalf("expected sealed comparative table, got %+v", table)
+	}
+
+	award := NewAwardService(f.store)
+	_, err = award.Award(f.user.ID, f.requirement.ID, 0)
+	expectError(t, err, http.StatusConflict, utilities.ErrSealed)
+	err = award.OpenBids(models.User{ID: f.user.ID, Profile: "user"}, f.requirement.ID)
+	expectError(t, err, http.StatusForbidden, utilities.ErrForbidden)
+
+	// The opening reveals and ranks the quotations
+	f.must(award.OpenBids(f.user, f.requirement.ID))
+	requirement, err := f.store.Requirements().Get(f.requirement.ID)
+	f.must(err)
+	if requirement.OpenedAt == nil || requirement.OpenedBy != f.user.ID {
+		t.Fatalf("expected opening recorded by %d, got %v %d", f.user.ID, requirement.OpenedAt, requirement.OpenedBy)
+	}
+	list, err = quotations.List(f.requirement.ID)
+	f.must(err)
+	for _, q := range list {
+		if q.Sealed || (q.ID == b.ID && (q.WinnerLevel != 1 || q.Summation != dec(12))) {
+			t.Fatalf("expected revealed quotation, got %+v", q)
+		}
+	}
+}
+
+func TestSealedBidsOpenAtExpiration(t *testing.T) {
+	f := newFixture(t)
+	f.must(NewRequirementService(f.store).Update(f.sealed()))
+	f.quote(0, 5, 10)
+	cheapest := f.quote(1, 1, 1)
+
+	// The expiration day ended
+	f.must(f.store.Requirements().UpdateFields(f.requirement.ID, map[string]interface{}{"expiration_date": time.Now().AddDate(0, 0, -1)}))
+	awarded, err := NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	if awarded.QuotationID != cheapest.ID {
+		t.Fatalf("expected winner %d, got %d", cheapest.ID, awarded.QuotationID)
+	}
+	requirement, err := f.store.Requirements().Get(f.requirement.ID)
+	f.must(err)
+	if requirement.OpenedAt == nil || requirement.OpenedBy != 0 {
+		t.Fatalf("expected opening recorded without user, got %v %d", requirement.OpenedAt, requirement.OpenedBy)
+	}
+}
+
+func TestRankingHiddenWhileSealed(t *testing.T) {
+	f := newFixture(t)
+	f.must(NewRequirementService(f.store).Update(f.sealed()))
+	a := f.quote(0, 5, 10)
+	b := f.quote(1, 1, 1)
+
+	award := NewAwardService(f.store)
+	ranking, err := award.Ranking(f.requirement.ID)
+	f.must(err)
+	if len(ranking) != 0 {
+		t.Fatalf("expected empty ranking while sealed, got %+v", ranking)
+	}
+
+	f.must(award.OpenBids(f.user, f.requirement.ID))
+	ranking, err = award.Ranking(f.requirement.ID)
+	f.must(err)
+	if len(ranking) != 2 || ranking[0].QuotationID != b.ID || ranking[1].QuotationID != a.ID || ranking[1].WinnerLevel != 2 {
+		t.Fatalf("unexpected ranking %+v", ranking)
+	}
+}
+
+func TestSealQuotedRequirement(t *testing.T) {
+	f := newFixture(t)
+	f.quote(0, 5, 10)
+
+	err := NewRequirementService(f.store).Update(f.sealed())
+	expectError(t, err, http.StatusConflict, utilities.ErrInvalidState)
+}
+
+func TestSealedExpirationFixed(t *testing.T) {
+	f := newFixture(t)
+	requirements := NewRequirementService(f.store)
+	f.must(requirements.Update(f.sealed()))
+
+	// A past expiration would open the bids without an administrator
+	past := f.sealed()
+	past.ExpirationDate = time.Now().AddDate(0, 0, -1)
+	expectError(t, requirements.Update(past), http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// The expiration does not change once the bids are received
+	f.quote(0, 5, 10)
+	later := f.sealed()
+	later.ExpirationDate = time.Now().AddDate(0, 1, 0)
+	expectError(t, requirements.Update(later), http.StatusConflict, utilities.ErrInvalidState)
+	f.must(requirements.Update(f.sealed()))
+}
+
+// sealed requirement of the fixture with the sealed bids mode
+func (f *fixture) sealed() *models.Requirement {
+	return &models.Requirement{ID: f.requirement.ID, Name: f.requirement.Name, CostCenterID: f.costCenter.ID, Sealed: true}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// BudgetMonth amounts of the budget of a cost center in a month
+type BudgetMonth struct {
+	Month     int             `json:"month"`
+	Committed decimal.Decimal `json:"committed"` // Awarded in the month
+	Consumed  decimal.Decimal `json:"consumed"`  // Received in the month
+}
+
+// BudgetExecution budget of a cost center in a year and its execution by month. The consumed
+// amounts were committed first, so the available budget is the budget minus the committed amounts
+type BudgetExecution struct {
+	CostCenterID uint            `json:"cost_center_id"`
+	Code         string          `json:"code"`
+	Name         string          `json:"name"`
+	Year         int             `json:"year"`
+	Currency     string          `json:"currency"`
+	Budget       decimal.Decimal `json:"budget"`
+	Committed    decimal.Decimal `json:"committed"`
+	Consumed     decimal.Decimal `json:"consumed"`
+	Available    decimal.Decimal `json:"available"` // Negative when the budget was exceeded
+
+	Months []BudgetMonth `json:"months"`
+}
+
+// BudgetCheck available budget of the cost center of a requirement before its award
+type BudgetCheck struct {
+	Amount     decimal.Decimal `json:"amount"`      // To commit by the award
+	Available  decimal.Decimal `json:"available"`   // Without the award
+	OverBudget bool            `json:"over_budget"` // The amount exceeds the available budget
+}
+
+// CostCenterService cost centers, its budgets by year and the execution of the budgets
+type CostCenterService interface {
+	List(page repository.Page) ([]models.CostCenter, uint, error)
+	Get(id uint) (models.CostCenter, error)
+	Create(costCenter *models.CostCenter) error
+	Update(costCenter *models.CostCenter) error
+	Delete(id uint) error
+	// SaveBudget create the budget or replace the budget of the cost center in the year
+	SaveBudget(budget *models.Budget) error
+	// Execution of the budgets of all the cost centers in the year
+	Execution(year int) ([]BudgetExecution, error)
+}
+
+type costCenterService struct {
+	store repository.Store
+}
+
+// NewCostCenterService create the cost center service over the store
+func NewCostCenterService(store repository.Store) CostCenterService {
+	return &costCenterService{store: store}
+}
+
+func (s *costCenterService) List(page repository.Page) ([]models.CostCenter, uint, error) {
+	return s.store.CostCenters().List(page)
+}
+
+func (s *costCenterService) Get(id uint) (models.CostCenter, error) {
+	costCenter, err := s.store.CostCenters().Get(id)
+	return costCenter, notFound(err, id)
+}
+
+func (s *costCenterService) Create(costCenter *models.CostCenter) error {
+	if err := invalid(utilities.ValidateStruct(costCenter)); err != nil {
+		return err
+	}
+	// The budgets are saved one by one with SaveBudget
+	costCenter.Budgets = nil
+	return s.store.CostCenters().Create(costCenter)
+}
+
+func (s *costCenterService) Update(costCenter *models.CostCenter) error {
+	if err := invalid(utilities.ValidateStruct(costCenter)); err != nil {
+		return err
+	}
+	costCenter.Budgets = nil
+	if err := s.store.CostCenters().Update(costCenter); err != nil {
+		return notFound(err, costCenter.ID)
+	}
+	if !costCenter.State {
+		return notFound(s.store.CostCenters().UpdateFields(costCenter.ID, map[string]interface{}{"state": false}), costCenter.ID)
+	}
+	return nil
+}
+
+func (s *costCenterService) Delete(id uint) error {
+	// Cost centers of requirements can only be disabled
+	count, err := s.store.CostCenters().CountRequirements(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		return notFound(tx.CostCenters().Delete(id), id)
+	})
+}
+
+func (s *costCenterService) SaveBudget(budget *models.Budget) error {
+	details := utilities.ValidateStruct(budget)
+	if budget.CostCenterID != 0 {
+		_, err := s.store.CostCenters().Get(budget.CostCenterID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference("cost_center_id", budget.CostCenterID))
+		case err != nil:
+			return err
+		}
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+	return s.store.CostCenters().SaveBudget(budget)
+}
+
+func (s *costCenterService) Execution(year int) ([]BudgetExecution, error) {
+	costCenters, _, err := s.store.CostCenters().List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	commitments, err := s.store.Commitments().List(0, year)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]BudgetExecution, 0, len(costCenters))
+	for _, cc := range costCenters {
+		execution := BudgetExecution{
+			CostCenterID: cc.ID,
+			Code:         cc.Code,
+			Name:         cc.Name,
+			Year:         year,
+			Currency:     baseCurrency(setting),
+			Months:       make([]BudgetMonth, 12),
+		}
+		for _, b := range cc.Budgets {
+			if int(b.Year) == year {
+				execution.Budget = b.Amount
+			}
+		}
+		for k := range execution.Months {
+			execution.Months[k].Month = k + 1
+		}
+		for _, c := range commitments {
+			if c.CostCenterID != cc.ID {
+				continue
+			}
+			month := &execution.Months[c.Date.Month()-1]
+			if err := accumulate(c.Amount, &month.Committed, &execution.Committed); err != nil {
+				return nil, err
+			}
+			if c.ConsumedAt != nil {
+				// Received the next year, it is consumed at the end of the year of the budget
+				if c.ConsumedAt.Year() == year {
+					month = &execution.Months[c.ConsumedAt.Month()-1]
+				} else {
+					month = &execution.Months[11]
+				}
+				if err := accumulate(c.Amount, &month.Consumed, &execution.Consumed); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if execution.Available, err = execution.Budget.CheckedAdd(execution.Committed.Neg()); err != nil {
+			return nil, amountRange(err)
+		}
+		report = append(report, execution)
+	}
+	return report, nil
+}
+
+// checkBudget amount of the award of the quotation and the available budget of the cost center
+// of the requirement in the current year, without the previous award of the requirement
+func checkBudget(store repository.Store, requirement models.Requirement, quotation models.Quotation) (BudgetCheck, error) {
+	check := BudgetCheck{}
+	lines, err := requires(store, requirement.ID)
+	if err != nil {
+		return check, err
+	}
+	conv, err := newConverter(store)
+	if err != nil {
+		return check, err
+	}
+	rate, err := conv.rate(quotation)
+	if err != nil {
+		return check, err
+	}
+	amounts, err := taxes(quotation, lines)
+	if err != nil {
+		return check, err
+	}
+	if check.Amount, err = convert(amounts.Total, rate); err != nil {
+		return check, err
+	}
+
+	year := time.Now().Year()
+	budget, err := store.CostCenters().GetBudget(requirement.CostCenterID, uint(year))
+	if err != nil && err != repository.ErrNotFound {
+		return check, err
+	}
+	commitments, err := store.Commitments().List(requirement.CostCenterID, year)
+	if err != nil {
+		return check, err
+	}
+	check.Available = budget.Amount
+	for _, c := range commitments {
+		if c.RequirementID != requirement.ID {
+			if err := accumulate(c.Amount.Neg(), &check.Available); err != nil {
+				return check, err
+			}
+		}
+	}
+	check.OverBudget = check.Amount.Cmp(check.Available) > 0
+	return check, nil
+}
+
+// accumulate add the amount to the totals, ErrAmountRange when a total does not fit
+func accumulate(amount decimal.Decimal, totals ...*decimal.Decimal) error {
+	for _, total := range totals {
+		sum, err := total.CheckedAdd(amount)
+		if err != nil {
+			return amountRange(err)
+		}
+		*total = sum
+	}
+	return nil
+}
+
+// commit take the amount of the award from the budget of the cost center of the requirement,
+// it replaces the commitment of a previous award
+func commit(store repository.Store, requirement models.Requirement, quotationID uint, amount decimal.Decimal) error {
+	return store.Commitments().Save(&models.Commitment{
+		Date:          time.Now(),
+		Amount:        amount,
+		CostCenterID:  requirement.CostCenterID,
+		RequirementID: requirement.ID,
+		QuotationID:   quotationID,
+	})
+}
+
+// consume mark the commitment of the requirement as consumed by its reception
+func consume(store repository.Store, requirementID uint) error {
+	commitment, err := store.Commitments().GetByRequirement(requirementID)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return store.Commitments().UpdateFields(commitment.ID, map[string]interface{}{"consumed_at": time.Now()})
+}
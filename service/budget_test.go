@@ -0,0 +1,85 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// budget save the budget of the cost center of the fixture in the current year
+func (f *fixture) budget(amount float64) {
+	f.t.Helper()
+	budget := models.Budget{Year: uint(time.Now().Year()), Amount: dec(amount), CostCenterID: f.costCenter.ID}
+	f.must(NewCostCenterService(f.store).SaveBudget(&budget))
+}
+
+func TestBudgetCommitment(t *testing.T) {
+	f := newFixture(t)
+	f.budget(100)
+	f.quote(0, 5, 10) // 10 x 5 + 2 x 10 = 70
+
+	awarded, err := NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	if awarded.OverBudget || awarded.Amount != dec(70) || awarded.Available != dec(100) {
+		t.Fatalf("unexpected budget check %+v", awarded.BudgetCheck)
+	}
+	f.must(NewRequirementService(f.store).Close(f.user.ID, f.requirement.ID))
+
+	report, err := NewCostCenterService(f.store).Execution(time.Now().Year())
+	f.must(err)
+	if len(report) != 1 {
+		t.Fatalf("expected 1 cost center, got %d", len(report))
+	}
+	execution := report[0]
+	if execution.Budget != dec(100) || execution.Committed != dec(70) || execution.Consumed != dec(70) || execution.Available != dec(30) {
+		t.Fatalf("unexpected execution %+v", execution)
+	}
+	month := execution.Months[time.Now().Month()-1]
+	if month.Committed != dec(70) || month.Consumed != dec(70) {
+		t.Fatalf("unexpected month %+v", month)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	f := newFixture(t)
+	f.budget(50)
+	f.quote(0, 5, 10)
+	award := NewAwardService(f.store)
+
+	// Blocked by the setting
+	setting, err := f.store.Settings().Get()
+	f.must(err)
+	f.must(f.store.Settings().Save(&models.Setting{ID: setting.ID, BudgetBlock: true}))
+	_, err = award.Award(f.user.ID, f.requirement.ID, 0)
+	expectError(t, err, http.StatusConflict, utilities.ErrBudgetExceeded)
+	if state := f.state(); state != models.RequirementQuoted {
+		t.Fatalf("expected state %s, got %s", models.RequirementQuoted, state)
+	}
+
+	// Only warned
+	f.must(f.db.Model(&setting).UpdateColumn("budget_block", false).Error)
+	awarded, err := award.Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	if !awarded.OverBudget || awarded.Available != dec(50) {
+		t.Fatalf("expected a warning, got %+v", awarded.BudgetCheck)
+	}
+	commitment, err := f.store.Commitments().GetByRequirement(f.requirement.ID)
+	f.must(err)
+	if commitment.Amount != dec(70) {
+		t.Fatalf("expected 70 committed, got %s", commitment.Amount)
+	}
+}
+
+func TestBudgetExecutionRange(t *testing.T) {
+	f := newFixture(t)
+
+	// Each commitment fits, their sum does not
+	for _, id := range []uint{1001, 1002} {
+		f.must(f.store.Commitments().Save(&models.Commitment{Date: time.Now(), Amount: dec(60000000000000), CostCenterID: f.costCenter.ID, RequirementID: id}))
+	}
+	_, err := NewCostCenterService(f.store).Execution(time.Now().Year())
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrAmountRange)
+}
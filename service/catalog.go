@@ -0,0 +1,304 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// ProviderCatalog categories and products sold by a provider
+type ProviderCatalog struct {
+	ProviderID uint                      `json:"provider_id"`
+	Categories []models.ProviderCategory `json:"categories"`
+	Products   []models.ProviderProduct  `json:"products"`
+}
+
+// SuggestedProvider provider that sells products of a requirement. The coverage is the part of
+// the requires it sells and the win rate the part of its quotations that won in the awarded
+// requirements
+type SuggestedProvider struct {
+	ProviderID   uint    `json:"provider_id"`
+	ProviderName string  `json:"provider_name"`
+	Email        string  `json:"email"`
+	RequireIDs   []uint  `json:"require_ids"` // Requires of products sold by the provider
+	Coverage     float64 `json:"coverage"`    // From 0 to 1
+	Quotations   uint    `json:"quotations"`
+	Won          uint    `json:"won"`
+	WinRate      float64 `json:"win_rate"` // From 0 to 1, 0 without quotations
+	Quoted       bool    `json:"quoted"`   // The provider already quoted the requirement
+}
+
+// CatalogService what the providers sell, to know whom to ask for quotations
+type CatalogService interface {
+	// Get the categories and the products sold by the provider
+	Get(providerID uint) (ProviderCatalog, error)
+	// Save replace the categories and the products of the provider, the last prices of the
+	// products kept do not change
+	Save(catalog *ProviderCatalog) error
+	// Suggest the active providers that sell products of the requirement, by its own product or
+	// by its category, ranked by coverage of the requires and then by win rate
+	Suggest(requirementID uint) ([]SuggestedProvider, error)
+}
+
+type catalogService struct {
+	store repository.Store
+}
+
+// NewCatalogService create the catalog service over the store
+func NewCatalogService(store repository.Store) CatalogService {
+	return &catalogService{store: store}
+}
+
+func (s *catalogService) Get(providerID uint) (ProviderCatalog, error) {
+	catalog := ProviderCatalog{ProviderID: providerID}
+	if _, err := s.store.Providers().Get(providerID); err != nil {
+		return catalog, notFound(err, providerID)
+	}
+	var err error
+	if catalog.Categories, err = s.store.Catalog().ListCategories(providerID); err != nil {
+		return catalog, err
+	}
+	catalog.Products, err = s.store.Catalog().ListProducts(providerID)
+	return catalog, err
+}
+
+func (s *catalogService) Save(catalog *ProviderCatalog) error {
+	if _, err := s.store.Providers().Get(catalog.ProviderID); err != nil {
+		return notFound(err, catalog.ProviderID)
+	}
+
+	// Validate data: existing categories and products without repeating them
+	details := make([]utilities.FieldError, 0)
+	seen := make(map[uint]bool)
+	for i, pc := range catalog.Categories {
+		field := fmt.Sprintf("categories[%d].category_id", i)
+		if _, err := s.store.Categories().Get(pc.CategoryID); err == repository.ErrNotFound {
+			details = append(details, reference(field, pc.CategoryID))
+		} else if err != nil {
+			return err
+		}
+		if seen[pc.CategoryID] {
+			details = append(details, utilities.FieldError{Field: field, Code: utilities.FieldRepeated, Params: []interface{}{pc.CategoryID}})
+		}
+		seen[pc.CategoryID] = true
+	}
+	seen = make(map[uint]bool)
+	for i, pp := range catalog.Products {
+		for _, fe := range utilities.ValidateStruct(&pp) {
+			fe.Field = fmt.Sprintf("products[%d].%s", i, fe.Field)
+			details = append(details, fe)
+		}
+		field := fmt.Sprintf("products[%d].product_id", i)
+		if _, err := s.store.Products().Get(pp.ProductID); err == repository.ErrNotFound {
+			details = append(details, reference(field, pp.ProductID))
+		} else if err != nil {
+			return err
+		}
+		if seen[pp.ProductID] {
+			details = append(details, utilities.FieldError{Field: field, Code: utilities.FieldRepeated, Params: []interface{}{pp.ProductID}})
+		}
+		seen[pp.ProductID] = true
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	// The last prices are recorded by the quotations, not by the user
+	current, err := s.store.Catalog().ListProducts(catalog.ProviderID)
+	if err != nil {
+		return err
+	}
+	prices := make(map[uint]models.ProviderProduct, len(current))
+	for _, pp := range current {
+		prices[pp.ProductID] = pp
+	}
+	for k := range catalog.Products {
+		last := prices[catalog.Products[k].ProductID]
+		catalog.Products[k].LastPrice = last.LastPrice
+		catalog.Products[k].LastCurrency = last.LastCurrency
+		catalog.Products[k].LastQuotedAt = last.LastQuotedAt
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Catalog().ReplaceCategories(catalog.ProviderID, catalog.Categories); err != nil {
+			return err
+		}
+		return tx.Catalog().ReplaceProducts(catalog.ProviderID, catalog.Products)
+	})
+}
+
+func (s *catalogService) Suggest(requirementID uint) ([]SuggestedProvider, error) {
+	if _, err := s.store.Requirements().Get(requirementID); err != nil {
+		return nil, notFound(err, requirementID)
+	}
+	lines, err := s.store.Requires().ListByRequirement(requirementID)
+	if err != nil || len(lines) == 0 {
+		return []SuggestedProvider{}, err
+	}
+
+	// Providers of every product and of every category
+	productIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	products, err := s.store.Products().ListByIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[uint]uint, len(products)) // category of the product
+	categoryIDs := make([]uint, 0, len(products))
+	for _, p := range products {
+		if p.CategoryID != nil {
+			categories[p.ID] = *p.CategoryID
+			categoryIDs = append(categoryIDs, *p.CategoryID)
+		}
+	}
+	sellers := make(map[uint]map[uint]bool) // providers of the product
+	byProduct, err := s.store.Catalog().ListByProducts(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, pp := range byProduct {
+		if sellers[pp.ProductID] == nil {
+			sellers[pp.ProductID] = make(map[uint]bool)
+		}
+		sellers[pp.ProductID][pp.ProviderID] = true
+	}
+	byCategory := make(map[uint][]uint) // providers of the category
+	if len(categoryIDs) > 0 {
+		provided, err := s.store.Catalog().ListByCategories(categoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, pc := range provided {
+			byCategory[pc.CategoryID] = append(byCategory[pc.CategoryID], pc.ProviderID)
+		}
+	}
+
+	// Requires covered by every provider
+	covered := make(map[uint][]uint)
+	for _, line := range lines {
+		providers := make(map[uint]bool)
+		for id := range sellers[line.ProductID] {
+			providers[id] = true
+		}
+		if category, ok := categories[line.ProductID]; ok {
+			for _, id := range byCategory[category] {
+				providers[id] = true
+			}
+		}
+		for id := range providers {
+			covered[id] = append(covered[id], line.ID)
+		}
+	}
+	if len(covered) == 0 {
+		return []SuggestedProvider{}, nil
+	}
+	ids := make([]uint, 0, len(covered))
+	for id := range covered {
+		ids = append(ids, id)
+	}
+	providers, err := s.store.Providers().ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.store.Quotations().Records(ids)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[uint]repository.ProviderRecord, len(records))
+	for _, r := range records {
+		history[r.ProviderID] = r
+	}
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	quoted := make(map[uint]bool, len(quotations))
+	for _, q := range quotations {
+		quoted[q.ProviderID] = true
+	}
+
+	suggested := make([]SuggestedProvider, 0, len(providers))
+	for _, p := range providers {
+		if !p.State {
+			continue
+		}
+		requires := covered[p.ID]
+		sort.Slice(requires, func(i, j int) bool { return requires[i] < requires[j] })
+		record := history[p.ID]
+		suggestion := SuggestedProvider{
+			ProviderID:   p.ID,
+			ProviderName: p.Name,
+			Email:        p.Email,
+			RequireIDs:   requires,
+			Coverage:     float64(len(requires)) / float64(len(lines)),
+			Quotations:   record.Quotations,
+			Won:          record.Won,
+			Quoted:       quoted[p.ID],
+		}
+		if record.Quotations > 0 {
+			suggestion.WinRate = float64(record.Won) / float64(record.Quotations)
+		}
+		suggested = append(suggested, suggestion)
+	}
+	sort.Slice(suggested, func(i, j int) bool {
+		a, b := suggested[i], suggested[j]
+		if len(a.RequireIDs) != len(b.RequireIDs) {
+			return len(a.RequireIDs) > len(b.RequireIDs)
+		}
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.ProviderID < b.ProviderID
+	})
+	return suggested, nil
+}
+
+// recordPrices save the prices of the quotation as the last prices quoted by the provider, the
+// products quoted are added to its catalog. The quotations of the portal count once approved and
+// the sealed bids once opened
+func recordPrices(tx repository.Store, quotationID uint, at time.Time) error {
+	quotation, err := tx.Quotations().Get(quotationID)
+	if err != nil {
+		return notFound(err, quotationID)
+	}
+	if quotation.Pending {
+		return nil
+	}
+	requirement, err := tx.Requirements().Get(quotation.RequirementID)
+	if err != nil {
+		return notFound(err, quotation.RequirementID)
+	}
+	if sealed(requirement) {
+		return nil
+	}
+	lines, err := tx.Requires().ListByRequirement(quotation.RequirementID)
+	if err != nil {
+		return err
+	}
+	products := make(map[uint]uint, len(lines)) // product of the require
+	for _, line := range lines {
+		products[line.ID] = line.ProductID
+	}
+	for _, qd := range quotation.QuotationDetails {
+		if qd.NotQuoted || products[qd.RequireID] == 0 {
+			continue
+		}
+		price := models.ProviderProduct{
+			ProviderID:   quotation.ProviderID,
+			ProductID:    products[qd.RequireID],
+			LastPrice:    qd.UnitPrice,
+			LastCurrency: quotation.Currency,
+			LastQuotedAt: &at,
+		}
+		if err := tx.Catalog().SavePrice(&price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestSuggestedProviders(t *testing.T) {
+	f := newFixture(t)
+	catalog := NewCatalogService(f.store)
+	products := NewProductService(f.store)
+
+	// The first product is sold by the providers of its category
+	category := models.Category{Name: "Paper", State: true}
+	f.must(NewCategoryService(f.store).Create(&category))
+	paper, err := products.Get(f.requirement.Requires[0].ProductID)
+	f.must(err)
+	missing := uint(99)
+	paper.CategoryID = &missing
+	expectError(t, products.Update(&paper), http.StatusUnprocessableEntity, utilities.ErrValidation)
+	paper.CategoryID = &category.ID
+	f.must(products.Update(&paper))
+
+	toner := f.requirement.Requires[1].ProductID
+	repeated := ProviderCatalog{ProviderID: f.providers[1].ID, Products: []models.ProviderProduct{{ProductID: toner}, {ProductID: toner}}}
+	expectError(t, catalog.Save(&repeated), http.StatusUnprocessableEntity, utilities.ErrValidation)
+	f.must(catalog.Save(&ProviderCatalog{ProviderID: f.providers[0].ID, Categories: []models.ProviderCategory{{CategoryID: category.ID}}}))
+	f.must(catalog.Save(&ProviderCatalog{ProviderID: f.providers[1].ID, Products: []models.ProviderProduct{{ProductID: toner, Reference: "TN-1"}}}))
+
+	suggested, err := catalog.Suggest(f.requirement.ID)
+	f.must(err)
+	if len(suggested) != 2 || suggested[0].ProviderID != f.providers[0].ID || suggested[0].Coverage != 0.5 || suggested[1].RequireIDs[0] != f.requirement.Requires[1].ID {
+		t.Fatalf("unexpected suggestions %+v", suggested)
+	}
+
+	// The quoted products join the catalog with its last price, the winners rank first
+	quotation := f.quote(1, 5, 10)
+	f.must(f.store.Quotations().UpdateFields(quotation.ID, map[string]interface{}{"winner": true}))
+	suggested, err = catalog.Suggest(f.requirement.ID)
+	f.must(err)
+	first := suggested[0]
+	if first.ProviderID != f.providers[1].ID || first.Coverage != 1 || first.WinRate != 1 || !first.Quoted || suggested[1].WinRate != 0 {
+		t.Fatalf("unexpected suggestions %+v", suggested)
+	}
+	sold, err := catalog.Get(f.providers[1].ID)
+	f.must(err)
+	if len(sold.Products) != 2 {
+		t.Fatalf("expected the quoted products in the catalog, got %+v", sold.Products)
+	}
+	for _, pp := range sold.Products {
+		if pp.ProductID == toner && (pp.Reference != "TN-1" || pp.LastPrice.Cmp(dec(10)) != 0 || pp.LastQuotedAt == nil) {
+			t.Fatalf("unexpected last price %+v", pp)
+		}
+	}
+
+	// The user edits the catalog but not the last prices
+	f.must(catalog.Save(&ProviderCatalog{ProviderID: f.providers[1].ID, Products: []models.ProviderProduct{{ProductID: toner, Reference: "TN-2", LastPrice: dec(1)}}}))
+	sold, err = catalog.Get(f.providers[1].ID)
+	f.must(err)
+	if len(sold.Products) != 1 || sold.Products[0].Reference != "TN-2" || sold.Products[0].LastPrice.Cmp(dec(10)) != 0 {
+		t.Fatalf("unexpected catalog %+v", sold.Products)
+	}
+
+	// A category in use can only be disabled
+	expectError(t, NewCategoryService(f.store).Delete(category.ID), http.StatusConflict, utilities.ErrInUse)
+}
+
+func TestCatalogSealedPrices(t *testing.T) {
+	f := newFixture(t)
+	f.must(NewRequirementService(f.store).Update(f.sealed()))
+	f.quote(0, 5, 10)
+	catalog := NewCatalogService(f.store)
+
+	// The prices of the sealed bids are not in the catalog until the opening
+	sold, err := catalog.Get(f.providers[0].ID)
+	f.must(err)
+	if len(sold.Products) != 0 {
+		t.Fatalf("expected no prices, got %+v", sold.Products)
+	}
+	f.must(NewAwardService(f.store).OpenBids(f.user, f.requirement.ID))
+	sold, err = catalog.Get(f.providers[0].ID)
+	f.must(err)
+	if len(sold.Products) != 2 || sold.Products[0].LastPrice.IsZero() || sold.Products[0].LastQuotedAt == nil {
+		t.Fatalf("unexpected prices %+v", sold.Products)
+	}
+}
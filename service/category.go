@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// CategoryService categories of the products, the providers sell categories of products
+type CategoryService interface {
+	List(page repository.Page) ([]models.Category, uint, error)
+	Get(id uint) (models.Category, error)
+	Create(category *models.Category) error
+	Update(category *models.Category) error
+	Delete(id uint) error
+}
+
+type categoryService struct {
+	store repository.Store
+}
+
+// NewCategoryService create the category service over the store
+func NewCategoryService(store repository.Store) CategoryService {
+	return &categoryService{store: store}
+}
+
+func (s *categoryService) List(page repository.Page) ([]models.Category, uint, error) {
+	return s.store.Categories().List(page)
+}
+
+func (s *categoryService) Get(id uint) (models.Category, error) {
+	category, err := s.store.Categories().Get(id)
+	return category, notFound(err, id)
+}
+
+func (s *categoryService) Create(category *models.Category) error {
+	if err := invalid(utilities.ValidateStruct(category)); err != nil {
+		return err
+	}
+	return s.store.Categories().Create(category)
+}
+
+func (s *categoryService) Update(category *models.Category) error {
+	if err := invalid(utilities.ValidateStruct(category)); err != nil {
+		return err
+	}
+	if err := s.store.Categories().Update(category); err != nil {
+		return notFound(err, category.ID)
+	}
+	if !category.State {
+		return notFound(s.store.Categories().UpdateFields(category.ID, map[string]interface{}{"state": false}), category.ID)
+	}
+	return nil
+}
+
+func (s *categoryService) Delete(id uint) error {
+	// Categories of products or providers can only be disabled
+	count, err := s.store.Categories().CountUses(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	return notFound(s.store.Categories().Delete(id), id)
+}
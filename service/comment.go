@@ -0,0 +1,327 @@
+package service
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// Types of the entries of the activity
+const (
+	ActivityComment = "comment"
+	ActivityState   = "state"
+)
+
+// Activity entry of the activity of a requirement: a comment or a change of state
+type Activity struct {
+	Type      string              `json:"type"`
+	CreatedAt time.Time           `json:"created_at"`
+	UserID    uint                `json:"user_id"`
+	UserName  string              `json:"user_name"`
+	Comment   *models.Comment     `json:"comment,omitempty"`
+	State     *models.StateChange `json:"state,omitempty"`
+}
+
+// CommentService discussion of the requirements and quotations. The users named with
+// @user_name are mentioned, the new mentions are returned to notify them
+type CommentService interface {
+	List(owner string, ownerID uint) ([]models.Comment, error)
+	Create(user models.User, comment *models.Comment) ([]models.User, error)
+	// Update the body of a comment of the user
+	Update(user models.User, comment *models.Comment) ([]models.User, error)
+	// Delete a comment of the user, the administrators delete any comment
+	Delete(user models.User, id uint) error
+
+	// Activity comments of the requirement and its quotations with the changes of its state, ordered by date
+	Activity(requirementID uint) ([]Activity, error)
+}
+
+type commentService struct {
+	store repository.Store
+}
+
+// NewCommentService create the comment service over the store
+func NewCommentService(store repository.Store) CommentService {
+	return &commentService{store: store}
+}
+
+// mentionPattern @user_name in the body of a comment
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([\w.\-]+)`)
+
+// mentionedNames user names mentioned in the body, without duplicates
+func mentionedNames(body string) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// The dot at the end is the end of the sentence
+		name := strings.TrimRight(match[1], ".")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// commented conflict when the record has comments, they are deleted first
+func commented(store repository.Store, owner string, ownerID uint) error {
+	count, err := store.Comments().Count(owner, ownerID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	return nil
+}
+
+// userNames user name of every user by id
+func userNames(store repository.Store, ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	users, err := store.Users().ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		names[user.ID] = user.UserName
+	}
+	return names, nil
+}
+
+// complete set the name of the authors and the attachments of the comments
+func (s *commentService) complete(comments []models.Comment) error {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.UserID)
+	}
+	names, err := userNames(s.store, ids)
+	if err != nil {
+		return err
+	}
+	for k := range comments {
+		comments[k].UserName = names[comments[k].UserID]
+		if comments[k].Attachments, err = attachments(s.store, models.AttachmentComment, comments[k].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *commentService) List(owner string, ownerID uint) ([]models.Comment, error) {
+	comments, err := s.store.Comments().ListByOwner(owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return comments, s.complete(comments)
+}
+
+// requirementOf requirement of the discussion of the record
+func (s *commentService) requirementOf(comment models.Comment) (uint, []utilities.FieldError, error) {
+	switch comment.Owner {
+	case models.CommentRequirement:
+		requirement, err := s.store.Requirements().Get(comment.OwnerID)
+		if err == nil {
+			return requirement.ID, nil, nil
+		}
+		if err != repository.ErrNotFound {
+			return 0, nil, err
+		}
+	case models.CommentQuotation:
+		quotation, err := s.store.Quotations().Get(comment.OwnerID)
+		if err == nil {
+			return quotation.RequirementID, nil, nil
+		}
+		if err != repository.ErrNotFound {
+			return 0, nil, err
+		}
+	default:
+		return 0, nil, nil
+	}
+	return 0, []utilities.FieldError{reference("owner_id", comment.OwnerID)}, nil
+}
+
+// mentions active users named in the body, the author is not mentioned
+func (s *commentService) mentions(author uint, body string) ([]models.Mention, []models.User, error) {
+	mentions := make([]models.Mention, 0)
+	mentioned := make([]models.User, 0)
+	names := mentionedNames(body)
+	if len(names) == 0 {
+		return mentions, mentioned, nil
+	}
+	users, err := s.store.Users().ListByNames(names)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, user := range users {
+		if !user.State || user.ID == author {
+			continue
+		}
+		mentions = append(mentions, models.Mention{UserID: user.ID, UserName: user.UserName})
+		mentioned = append(mentioned, user)
+	}
+	return mentions, mentioned, nil
+}
+
+func (s *commentService) Create(user models.User, comment *models.Comment) ([]models.User, error) {
+	comment.Body = strings.TrimSpace(comment.Body)
+	details := utilities.ValidateStruct(comment)
+	requirementID, owner, err := s.requirementOf(*comment)
+	if err != nil {
+		return nil, err
+	}
+	if err := invalid(append(details, owner...)); err != nil {
+		return nil, err
+	}
+
+	mentions, mentioned, err := s.mentions(user.ID, comment.Body)
+	if err != nil {
+		return nil, err
+	}
+	comment.ID = 0
+	comment.EditedAt = nil
+	comment.RequirementID = requirementID
+	comment.UserID = user.ID
+	comment.UserName = user.UserName
+	comment.Mentions = mentions
+	comment.Attachments = make([]models.Attachment, 0)
+	if err := s.store.Comments().Create(comment); err != nil {
+		return nil, err
+	}
+	return mentioned, nil
+}
+
+// own get the comment when the user is its author
+func (s *commentService) own(user models.User, id uint) (models.Comment, error) {
+	comment, err := s.store.Comments().Get(id)
+	if err != nil {
+		return comment, notFound(err, id)
+	}
+	if comment.UserID != user.ID {
+		return comment, utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+	}
+	return comment, nil
+}
+
+func (s *commentService) Update(user models.User, comment *models.Comment) ([]models.User, error) {
+	current, err := s.own(user, comment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the body changes
+	current.Body = strings.TrimSpace(comment.Body)
+	if err := invalid(utilities.ValidateStruct(&current)); err != nil {
+		return nil, err
+	}
+	mentions, mentioned, err := s.mentions(user.ID, current.Body)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Comments().UpdateFields(current.ID, map[string]interface{}{"body": current.Body, "edited_at": now}); err != nil {
+			return notFound(err, current.ID)
+		}
+		return tx.Comments().ReplaceMentions(current.ID, mentions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The users mentioned before were already notified
+	notify := make([]models.User, 0, len(mentioned))
+	for _, u := range mentioned {
+		before := false
+		for _, m := range current.Mentions {
+			before = before || m.UserID == u.ID
+		}
+		if !before {
+			notify = append(notify, u)
+		}
+	}
+
+	*comment = current
+	comment.EditedAt = &now
+	comment.Mentions = mentions
+	comment.UserName = user.UserName
+	if comment.Attachments, err = attachments(s.store, models.AttachmentComment, comment.ID); err != nil {
+		return nil, err
+	}
+	return notify, nil
+}
+
+func (s *commentService) Delete(user models.User, id uint) error {
+	comment, err := s.store.Comments().Get(id)
+	if err != nil {
+		return notFound(err, id)
+	}
+	if user.Profile != "admin" && user.ID != comment.UserID {
+		return utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+	}
+	if err := attached(s.store, models.AttachmentComment, id); err != nil {
+		return err
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		return notFound(tx.Comments().Delete(id), id)
+	})
+}
+
+func (s *commentService) Activity(requirementID uint) ([]Activity, error) {
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return nil, notFound(err, requirementID)
+	}
+	comments, err := s.store.Comments().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.complete(comments); err != nil {
+		return nil, err
+	}
+	changes, err := s.store.Requirements().ListStateChanges(requirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The requirements created before the record of the changes start with its creation
+	if len(changes) == 0 || changes[0].FromState != "" {
+		changes = append([]models.StateChange{{
+			RequirementID: requirement.ID,
+			CreatedAt:     requirement.CreatedAt,
+			ToState:       models.RequirementCreated,
+			UserID:        requirement.UserID,
+		}}, changes...)
+	}
+
+	activity := make([]Activity, 0, len(comments)+len(changes))
+	ids := make([]uint, 0, cap(activity))
+	for k := range changes {
+		activity = append(activity, Activity{Type: ActivityState, CreatedAt: changes[k].CreatedAt, UserID: changes[k].UserID, State: &changes[k]})
+		ids = append(ids, changes[k].UserID)
+	}
+	for k := range comments {
+		activity = append(activity, Activity{Type: ActivityComment, CreatedAt: comments[k].CreatedAt, UserID: comments[k].UserID, Comment: &comments[k]})
+		ids = append(ids, comments[k].UserID)
+	}
+	sort.SliceStable(activity, func(i, j int) bool {
+		return activity[i].CreatedAt.Before(activity[j].CreatedAt)
+	})
+
+	names, err := userNames(s.store, ids)
+	if err != nil {
+		return nil, err
+	}
+	for k := range activity {
+		activity[k].UserName = names[activity[k].UserID]
+	}
+	return activity, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestMentionedNames(t *testing.T) {
+	names := mentionedNames("@ana and @luis.perez, please check. Thanks @ana. mail@example.com @")
+	if want := []string{"ana", "luis.perez"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+}
+
+func TestComments(t *testing.T) {
+	f := newFixture(t)
+	ana := models.User{DNI: "87654321", UserName: "ana", Email: "ana@example.com", Password: "ana", Profile: "user", State: true}
+	f.must(NewUserService(f.store).Create(&ana))
+	comments := NewCommentService(f.store)
+
+	// The mentioned users are returned to notify them, the author is not mentioned
+	comment := models.Comment{Owner: models.CommentRequirement, OwnerID: f.requirement.ID, Body: "@ana @admin @nobody please quote"}
+	mentioned, err := comments.Create(f.user, &comment)
+	f.must(err)
+	if len(mentioned) != 1 || mentioned[0].ID != ana.ID || len(comment.Mentions) != 1 {
+		t.Fatalf("expected ana mentioned, got %+v", mentioned)
+	}
+
+	// A comment of a quotation is in the discussion of its requirement
+	quotation := f.quote(0, 5, 10)
+	reply := models.Comment{Owner: models.CommentQuotation, OwnerID: quotation.ID, Body: "Price includes delivery"}
+	_, err = comments.Create(ana, &reply)
+	f.must(err)
+	if reply.RequirementID != f.requirement.ID {
+		t.Fatalf("expected requirement %d, got %d", f.requirement.ID, reply.RequirementID)
+	}
+	_, err = comments.Create(ana, &models.Comment{Owner: models.CommentQuotation, OwnerID: 999, Body: "Hi"})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// Only the author edits, only the new mentions are notified
+	edit := models.Comment{ID: comment.ID, Body: "@ana please quote"}
+	_, err = comments.Update(ana, &edit)
+	expectError(t, err, http.StatusForbidden, utilities.ErrForbidden)
+	mentioned, err = comments.Update(f.user, &edit)
+	f.must(err)
+	if len(mentioned) != 0 || edit.EditedAt == nil || edit.Body != "@ana please quote" {
+		t.Fatalf("unexpected edition %+v, mentioned %+v", edit, mentioned)
+	}
+
+	// The activity merges the comments and the changes of state
+	_, err = NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	activity, err := comments.Activity(f.requirement.ID)
+	f.must(err)
+	types := make([]string, 0)
+	for _, a := range activity {
+		types = append(types, a.Type)
+	}
+	want := []string{ActivityState, ActivityComment, ActivityState, ActivityComment, ActivityState}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("expected %v, got %v", want, types)
+	}
+	if awarded := activity[4].State; awarded.FromState != models.RequirementQuoted || awarded.ToState != models.RequirementAwarded || activity[4].UserName != "admin" {
+		t.Errorf("unexpected award %+v", activity[4])
+	}
+
+	// The commented records are deleted after its comments, the users delete their own comments
+	expectError(t, NewQuotationService(f.store).Delete(quotation.ID), http.StatusConflict, utilities.ErrInUse)
+	expectError(t, comments.Delete(models.User{ID: ana.ID, Profile: "user"}, comment.ID), http.StatusForbidden, utilities.ErrForbidden)
+	f.must(comments.Delete(ana, reply.ID))
+	f.must(comments.Delete(f.user, comment.ID))
+	list, err := comments.List(models.CommentRequirement, f.requirement.ID)
+	f.must(err)
+	if len(list) != 0 {
+		t.Fatalf("expected no comments, got %d", len(list))
+	}
+}
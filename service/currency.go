@@ -0,0 +1,293 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// DefaultCurrency base currency when the setting does not define it
+const DefaultCurrency = "PEN"
+
+// rateDate layout of the dates of the exchange rates
+const rateDate = "2006-01-02"
+
+// baseCurrency currency of the amounts converted: ranking, comparative table and statistics
+func baseCurrency(setting models.Setting) string {
+	if setting.Currency == "" {
+		return DefaultCurrency
+	}
+	return setting.Currency
+}
+
+// rateDay the rates are by day, saved at midnight UTC
+func rateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// converter exchange rates of the quotations to the base currency, cached by currency and day
+type converter struct {
+	store repository.Store
+	base  string
+	rates map[string]decimal.Decimal
+}
+
+// newConverter converter to the base currency of the setting
+func newConverter(store repository.Store) (*converter, error) {
+	setting, err := store.Settings().Get()
+	if err != nil {
+		return nil, err
+	}
+	return &converter{store: store, base: baseCurrency(setting), rates: make(map[string]decimal.Decimal)}, nil
+}
+
+// currency of the quotation, the base currency when it is empty
+func (c *converter) currency(quotation models.Quotation) string {
+	if quotation.Currency == "" {
+		return c.base
+	}
+	return quotation.Currency
+}
+
+// rate of the currency of the quotation on its emission date
+func (c *converter) rate(quotation models.Quotation) (decimal.Decimal, error) {
+	return c.lookup(c.currency(quotation), quotation.EmissionDate)
+}
+
+// lookup last rate of the currency on or before the date, 1 for the base currency
+func (c *converter) lookup(currency string, date time.Time) (decimal.Decimal, error) {
+	if currency == c.base {
+		return decimal.NewFromInt(1), nil
+	}
+	day := rateDay(date)
+	key := currency + day.Format(rateDate)
+	if rate, ok := c.rates[key]; ok {
+		return rate, nil
+	}
+	rate, err := c.store.ExchangeRates().Find(currency, day)
+	if err == repository.ErrNotFound {
+		return decimal.Zero, utilities.NewError(http.StatusConflict, utilities.ErrNoExchangeRate, currency, day.Format(rateDate))
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	c.rates[key] = rate.Rate
+	return rate.Rate, nil
+}
+
+// ExchangeRateService exchange rates to the base currency by date
+type ExchangeRateService interface {
+	List(page repository.Page) ([]models.ExchangeRate, uint, error)
+	// Save create the rate or replace the rate of the currency on the date, the rates that would
+	// change the conversion of quotations already emitted are in use
+	Save(rate *models.ExchangeRate) error
+	// Delete the rate when no quotation is converted with it
+	Delete(id uint) error
+	// Import validate and save all the rates, nothing is saved when one fails or is in use
+	Import(rates []models.ExchangeRate) error
+}
+
+type exchangeRateService struct {
+	store repository.Store
+}
+
+// NewExchangeRateService create the exchange rate service over the store
+func NewExchangeRateService(store repository.Store) ExchangeRateService {
+	return &exchangeRateService{store: store}
+}
+
+func (s *exchangeRateService) List(page repository.Page) ([]models.ExchangeRate, uint, error) {
+	return s.store.ExchangeRates().List(page)
+}
+
+// validate return the violations of the rate, the base currency has no rate
+func (s *exchangeRateService) validate(rate models.ExchangeRate, base string) []utilities.FieldError {
+	details := utilities.ValidateStruct(&rate)
+	if rate.Currency == base {
+		details = append(details, utilities.FieldError{Field: "currency", Code: utilities.FieldInvalid})
+	}
+	return details
+}
+
+func (s *exchangeRateService) Save(rate *models.ExchangeRate) error {
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return err
+	}
+	if err := invalid(s.validate(*rate, baseCurrency(setting))); err != nil {
+		return err
+	}
+	rate.Date = rateDay(rate.Date)
+	if err := changeRate(s.store, *rate); err != nil {
+		return err
+	}
+	return s.store.ExchangeRates().Save(rate)
+}
+
+func (s *exchangeRateService) Delete(id uint) error {
+	rate, err := s.store.ExchangeRates().Get(id)
+	if err != nil {
+		return notFound(err, id)
+	}
+	if err := rateInUse(s.store, rate.Currency, rate.Date); err != nil {
+		return err
+	}
+	return notFound(s.store.ExchangeRates().Delete(id), id)
+}
+
+// changeRate check that saving the rate does not change the conversion of the quotations, the
+// same value of the day changes nothing
+func changeRate(store repository.Store, rate models.ExchangeRate) error {
+	current, err := store.ExchangeRates().Find(rate.Currency, rate.Date)
+	switch {
+	case err == nil && current.Date.Equal(rate.Date) && current.Rate == rate.Rate:
+		return nil
+	case err != nil && err != repository.ErrNotFound:
+		return err
+	}
+	return rateInUse(store, rate.Currency, rate.Date)
+}
+
+// rateInUse check that no quotation is converted with the rate of the currency of the day, or
+// with a previous rate that a rate of the day would replace: the quotations of the currency
+// emitted on the day or later, until the next rate of the currency
+func rateInUse(store repository.Store, currency string, day time.Time) error {
+	dates, err := store.Quotations().EmissionDates(currency)
+	if err != nil {
+		return err
+	}
+	used := make(map[time.Time]bool)
+	for _, date := range dates {
+		emitted := rateDay(date)
+		if emitted.Before(day) {
+			continue
+		}
+		if _, ok := used[emitted]; !ok {
+			current, err := store.ExchangeRates().Find(currency, emitted)
+			switch {
+			case err == repository.ErrNotFound:
+				used[emitted] = false
+			case err != nil:
+				return err
+			default:
+				used[emitted] = !current.Date.After(day)
+			}
+		}
+		if used[emitted] {
+			return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+		}
+	}
+	return nil
+}
+
+// CheckBaseCurrency check that the base currency of the setting can change to currency, the
+// totals of the quotations are converted to the base currency so it is fixed once there are
+// quotations
+func CheckBaseCurrency(store repository.Store, currency string) error {
+	setting, err := store.Settings().Get()
+	if err != nil {
+		return err
+	}
+	if currency == "" || currency == baseCurrency(setting) {
+		return nil
+	}
+	count, err := store.Quotations().CountAll()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	return nil
+}
+
+func (s *exchangeRateService) Import(rates []models.ExchangeRate) error {
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return err
+	}
+
+	// Validate all the rates, the field is prefixed with the index of the rate
+	details := make([]utilities.FieldError, 0)
+	for k := range rates {
+		for _, fe := range s.validate(rates[k], baseCurrency(setting)) {
+			fe.Field = fmt.Sprintf("rates[%d].%s", k, fe.Field)
+			details = append(details, fe)
+		}
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		for k := range rates {
+			rates[k].Date = rateDay(rates[k].Date)
+			if err := changeRate(tx, rates[k]); err != nil {
+				return err
+			}
+			if err := tx.ExchangeRates().Save(&rates[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ParseRates read the rates of a CSV file with the columns date (YYYY-MM-DD), currency and
+// rate separated by comma or semicolon, the first row can be a header
+func ParseRates(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := bufio.NewReader(r)
+	first, err := reader.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	records := csv.NewReader(reader)
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+	if line := strings.SplitN(string(first), "\n", 2)[0]; strings.Contains(line, ";") {
+		records.Comma = ';'
+	}
+
+	rates := make([]models.ExchangeRate, 0)
+	details := make([]utilities.FieldError, 0)
+	for row := 1; ; row++ {
+		cols, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, utilities.NewValidationError(utilities.FieldError{Field: fmt.Sprintf("rows[%d]", row), Code: utilities.FieldInvalid})
+		}
+		for len(cols) < 3 {
+			cols = append(cols, "")
+		}
+
+		date, dateErr := time.Parse(rateDate, strings.TrimSpace(cols[0]))
+		if dateErr != nil && row == 1 {
+			continue // header
+		}
+		rate, rateErr := decimal.Parse(strings.Replace(cols[2], ",", ".", 1))
+		if dateErr != nil {
+			details = append(details, utilities.FieldError{Field: fmt.Sprintf("rows[%d].date", row), Code: utilities.FieldInvalid})
+		}
+		if rateErr != nil {
+			details = append(details, utilities.FieldError{Field: fmt.Sprintf("rows[%d].rate", row), Code: utilities.FieldInvalid})
+		}
+		rates = append(rates, models.ExchangeRate{
+			Date:     date,
+			Currency: strings.ToUpper(strings.TrimSpace(cols[1])),
+			Rate:     rate,
+		})
+	}
+	return rates, invalid(details)
+}
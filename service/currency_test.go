@@ -0,0 +1,119 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(strings.NewReader("fecha;moneda;tipo\n2026-01-02;usd;3,75\n2026-01-03;EUR;4.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[0].Currency != "USD" || rates[0].Rate != dec(3.75) || rates[1].Date.Day() != 3 {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+
+	_, err = ParseRates(strings.NewReader("2026-01-02,USD,3.75\n2026-13-01,USD,x\n"))
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+	if d := err.(*utilities.Error).Details; len(d) != 2 || d[0].Field != "rows[2].date" || d[1].Field != "rows[2].rate" {
+		t.Fatalf("unexpected details %+v", d)
+	}
+}
+
+func TestExchangeRateImport(t *testing.T) {
+	f := newFixture(t)
+	rates := NewExchangeRateService(f.store)
+	yesterday := time.Now().AddDate(0, 0, -1)
+
+	// The base currency has no rate
+	err := rates.Import([]models.ExchangeRate{{Date: yesterday, Currency: "USD", Rate: dec(3.5)}, {Date: yesterday, Currency: "PEN", Rate: dec(1)}})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// The rate of the same day is replaced
+	f.must(rates.Import([]models.ExchangeRate{{Date: yesterday, Currency: "USD", Rate: dec(3.5)}}))
+	f.must(rates.Save(&models.ExchangeRate{Date: yesterday, Currency: "USD", Rate: dec(3.8)}))
+	list, total, err := rates.List(repository.Page{})
+	f.must(err)
+	if total != 1 || list[0].Rate != dec(3.8) {
+		t.Fatalf("expected a single rate 3.8, got %+v", list)
+	}
+}
+
+func TestQuotationCurrency(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+
+	// Without exchange rate
+	usd := f.newQuotation(0, 2, 5)
+	usd.Currency = "USD"
+	err := quotations.Create(f.user.ID, &usd)
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+	if d := err.(*utilities.Error).Details; d[0].Field != "currency" || d[0].Code != utilities.FieldNoRate {
+		t.Fatalf("unexpected details %+v", d)
+	}
+
+	// 10*2 + 2*5 = 30 USD = 105 PEN, more than 100 PEN
+	f.must(NewExchangeRateService(f.store).Save(&models.ExchangeRate{Date: time.Now().AddDate(0, 0, -3), Currency: "USD", Rate: dec(3.5)}))
+	f.must(quotations.Create(f.user.ID, &usd))
+	pen := f.quote(1, 8, 10)
+
+	list, err := quotations.List(f.requirement.ID)
+	f.must(err)
+	if list[0].ID != pen.ID || list[0].Currency != "PEN" || list[0].Converted != dec(100) {
+		t.Fatalf("expected PEN quotation first, got %+v", list[0])
+	}
+	if list[1].ID != usd.ID || list[1].Summation != dec(30) || list[1].Rate != dec(3.5) || list[1].Converted != dec(105) {
+		t.Fatalf("unexpected USD quotation %+v", list[1])
+	}
+
+	table, err := quotations.ComparativeTable(f.requirement.ID, false)
+	f.must(err)
+	if table.Currency != "PEN" || table.CTResponseProviders[1].Currency != "USD" || table.CTResponseQuotations[2].Converted != dec(7) {
+		t.Fatalf("unexpected comparative table %+v", table)
+	}
+
+	// Awarded report in both currencies
+	_, err = NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, usd.ID)
+	f.must(err)
+	report, err := NewReportService(f.store).Awarded()
+	f.must(err)
+	if len(report) != 1 || report[0].Total != dec(30) || report[0].Currency != "USD" || report[0].Converted != dec(105) || report[0].BaseCurrency != "PEN" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestExchangeRateInUse(t *testing.T) {
+	f := newFixture(t)
+	rates := NewExchangeRateService(f.store)
+	f.must(CheckBaseCurrency(f.store, "USD"))
+
+	old := models.ExchangeRate{Date: time.Now().AddDate(0, 0, -5), Currency: "USD", Rate: dec(3.4)}
+	f.must(rates.Save(&old))
+	last := models.ExchangeRate{Date: time.Now().AddDate(0, 0, -3), Currency: "USD", Rate: dec(3.5)}
+	f.must(rates.Save(&last))
+	usd := f.newQuotation(0, 2, 5)
+	usd.Currency = "USD"
+	f.must(NewQuotationService(f.store).Create(f.user.ID, &usd))
+
+	// The quotation is converted with the last rate, the previous one is not used
+	expectError(t, rates.Delete(last.ID), http.StatusConflict, utilities.ErrInUse)
+	f.must(rates.Delete(old.ID))
+
+	// The rate used is not replaced, by other value or a rate after it
+	f.must(rates.Save(&models.ExchangeRate{Date: last.Date, Currency: "USD", Rate: dec(3.5)}))
+	expectError(t, rates.Save(&models.ExchangeRate{Date: last.Date, Currency: "USD", Rate: dec(3.9)}), http.StatusConflict, utilities.ErrInUse)
+	today := []models.ExchangeRate{{Date: time.Now(), Currency: "USD", Rate: dec(3.6)}}
+	expectError(t, rates.Import(today), http.StatusConflict, utilities.ErrInUse)
+	f.must(rates.Save(&models.ExchangeRate{Date: time.Now().AddDate(0, 0, 1), Currency: "USD", Rate: dec(3.7)}))
+
+	// The base currency is fixed once there are quotations
+	f.must(CheckBaseCurrency(f.store, "PEN"))
+	expectError(t, CheckBaseCurrency(f.store, "USD"), http.StatusConflict, utilities.ErrInUse)
+}
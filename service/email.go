@@ -0,0 +1,138 @@
+package service
+
+import (
+	"time"
+
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/mailer"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// EmailAttempts attempts of a email before it fails
+const EmailAttempts = 6
+
+const (
+	emailLease = 5 * time.Minute // Time a worker holds a email while it sends it
+	emailBatch = 50              // Emails sent on every run
+)
+
+// OutboxService emails saved in the outbox and sent in the background, the requests do not wait
+// for the SMTP server and the emails not sent are retried
+type OutboxService interface {
+	// Queue save the email in the outbox to send it in the background
+	Queue(email *models.Email, now time.Time) error
+	// Send the emails due at now, all of them when ids is empty. The emails rejected by the server
+	// fail, the other errors are retried with exponential backoff
+	Send(now time.Time, ids ...uint) error
+	// List the emails of the outbox, only for the administrators
+	List(user models.User, state string, page repository.Page) ([]models.Email, uint, error)
+}
+
+type outboxService struct {
+	store  repository.Store
+	sender mailer.Sender
+	log    *logger.Logger
+}
+
+// NewOutboxService create the outbox service over the store, the emails are sent with the sender,
+// it can be nil to queue the emails only
+func NewOutboxService(store repository.Store, sender mailer.Sender) OutboxService {
+	return &outboxService{store: store, sender: sender, log: logger.Default()}
+}
+
+func (s *outboxService) Queue(email *models.Email, now time.Time) error {
+	if err := invalid(utilities.ValidateStruct(email)); err != nil {
+		return err
+	}
+	email.ID = 0
+	if len(email.RequestID) > 64 {
+		email.RequestID = email.RequestID[:64]
+	}
+	email.State = models.EmailPending
+	email.Attempts = 0
+	email.NextAttempt = &now
+	email.Error = ""
+	email.SentAt = nil
+	return s.store.Transaction(func(tx repository.Store) error {
+		return tx.Emails().Create(email)
+	})
+}
+
+func (s *outboxService) Send(now time.Time, ids ...uint) error {
+	var filter []uint
+	if len(ids) > 0 {
+		filter = ids
+	}
+	due, err := s.store.Emails().ListDue(now, filter, emailBatch)
+	if err != nil {
+		return err
+	}
+	for _, e := range due {
+		// Other worker can be sending the same email
+		claimed, err := s.store.Emails().Claim(e.ID, now, now.Add(emailLease))
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		email, err := s.store.Emails().Get(e.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.attempt(email, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt send the email and record and log the result, the next attempt waits twice the
+// previous one. The entries have the request id of the request that queued the email
+func (s *outboxService) attempt(email models.Email, now time.Time) error {
+	message := mailer.Message{To: email.Recipient, Subject: email.Subject, Text: email.Text, HTML: email.HTML}
+	for _, a := range email.Attachments {
+		message.Attachments = append(message.Attachments, mailer.Attachment{Name: a.Name, ContentType: a.ContentType, Data: a.Data})
+	}
+
+	attempts := email.Attempts + 1
+	fields := map[string]interface{}{"attempts": attempts, "updated_at": now}
+	start := time.Now()
+	err := s.sender.Send(message)
+	l := s.log.With(logger.Fields{
+		"component":   "smtp",
+		"request_id":  email.RequestID,
+		"email_id":    email.ID,
+		"to":          email.Recipient,
+		"template":    email.Template,
+		"attempt":     attempts,
+		"duration_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+	}).WithError(err)
+	switch {
+	case err == nil:
+		fields["state"] = models.EmailSent
+		fields["sent_at"] = now
+		fields["next_attempt"] = nil
+		fields["error"] = ""
+		l.Infof("email sent")
+	case mailer.Permanent(err) || attempts >= EmailAttempts:
+		fields["state"] = models.EmailFailed
+		fields["next_attempt"] = nil
+		fields["error"] = err.Error()
+		l.Errorf("email not sent")
+	default:
+		fields["next_attempt"] = now.Add(backoff(attempts))
+		fields["error"] = err.Error()
+		l.WithField("next_attempt", fields["next_attempt"]).Warnf("email not sent, retried later")
+	}
+	return s.store.Emails().UpdateFields(email.ID, fields)
+}
+
+func (s *outboxService) List(user models.User, state string, page repository.Page) ([]models.Email, uint, error) {
+	if err := administrator(user); err != nil {
+		return nil, 0, err
+	}
+	return s.store.Emails().List(state, page)
+}
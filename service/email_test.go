@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/logger"
+	"github.com/paulantezana/requirement/mailer"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// outbox sender that keeps the messages, it returns err while it is set
+type outbox struct {
+	err  error
+	sent []mailer.Message
+}
+
+func (o *outbox) Send(m mailer.Message) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.sent = append(o.sent, m)
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	f := newFixture(t)
+	sender := &outbox{}
+	emails := NewOutboxService(f.store, sender)
+	logs := &bytes.Buffer{}
+	emails.(*outboxService).log = logger.New(logs, logger.INFO)
+	now := time.Now()
+
+	invalidEmail := models.Email{Recipient: "buyer", Subject: "Hola"}
+	expectError(t, emails.Queue(&invalidEmail, now), http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// Sent with its attachments
+	rfq := models.Email{Recipient: "provider@example.com", Subject: "Solicitud", Text: "Hola", RequestID: "rfq-request", Attachments: []models.EmailAttachment{{Name: "rfq.xlsx", Data: []byte("xlsx")}}}
+	f.must(emails.Queue(&rfq, now))
+	f.must(emails.Send(now, rfq.ID))
+	if len(sender.sent) != 1 || sender.sent[0].To != rfq.Recipient || string(sender.sent[0].Attachments[0].Data) != "xlsx" {
+		t.Fatalf("unexpected messages %+v", sender.sent)
+	}
+	sent, err := f.store.Emails().Get(rfq.ID)
+	f.must(err)
+	if sent.State != models.EmailSent || sent.SentAt == nil || sent.NextAttempt != nil {
+		t.Fatalf("unexpected email %+v", sent)
+	}
+	if !strings.Contains(logs.String(), `"request_id":"rfq-request"`) || !strings.Contains(logs.String(), `"message":"email sent"`) {
+		t.Fatalf("unexpected logs %s", logs)
+	}
+
+	// A server down is retried later, a rejected recipient fails
+	sender.err = errors.New("connection refused")
+	retried := models.Email{Recipient: "buyer@example.com", Subject: "Hola", Text: "Hola"}
+	f.must(emails.Queue(&retried, now))
+	f.must(emails.Send(now))
+	email, err := f.store.Emails().Get(retried.ID)
+	f.must(err)
+	if email.State != models.EmailPending || email.Attempts != 1 || !email.NextAttempt.Equal(now.Add(backoff(1))) || email.Error != "connection refused" {
+		t.Fatalf("unexpected retried email %+v", email)
+	}
+	sender.err = &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+	f.must(emails.Send(now.Add(backoff(1))))
+	email, err = f.store.Emails().Get(retried.ID)
+	f.must(err)
+	if email.State != models.EmailFailed || email.Attempts != 2 || email.NextAttempt != nil {
+		t.Fatalf("unexpected failed email %+v", email)
+	}
+	if !strings.Contains(logs.String(), `"message":"email not sent"`) || !strings.Contains(logs.String(), "mailbox unavailable") {
+		t.Fatalf("unexpected logs %s", logs)
+	}
+
+	// The outbox shows the failures to the administrators
+	failed, total, err := emails.List(f.user, models.EmailFailed, repository.Page{})
+	f.must(err)
+	if total != 1 || failed[0].ID != retried.ID || failed[0].Text != "" {
+		t.Fatalf("unexpected outbox %d %+v", total, failed)
+	}
+	_, _, err = emails.List(models.User{Profile: "user"}, "", repository.Page{})
+	expectError(t, err, http.StatusForbidden, utilities.ErrForbidden)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// Event change of a requirement or of its quotations notified to the interested users
+type Event struct {
+	Type          string `json:"event"`
+	ActorID       uint   `json:"actor_id"` // User that made the change, 0 for the providers in the portal
+	RequirementID uint   `json:"requirement_id"`
+	QuotationID   uint   `json:"quotation_id,omitempty"`
+	CommentID     uint   `json:"comment_id,omitempty"`
+	Recipients    []uint `json:"-"` // Notified besides the creator of the requirement
+	Detail        string `json:"-"` // Text shown in the emails, as the body of the comment
+}
+
+// Delivery notification created for a user, also sent by email when the user wants it
+type Delivery struct {
+	User         models.User
+	Notification models.Notification
+	Email        bool
+}
+
+// NotificationService notifications of the events to the users. The creator of a requirement is
+// notified of its quotations and changes of state, the mentioned users of the comments
+type NotificationService interface {
+	// Notify the event to its recipients except to the user that made the change, the
+	// notifications are returned to deliver them with the text in the language of every user
+	Notify(event Event) ([]Delivery, error)
+	// List the notifications of the user with the count of the unread ones, the texts are in lang
+	List(userID uint, lang string, unread bool, page repository.Page) ([]models.Notification, uint, uint, error)
+	// Read mark the notifications of the user as read, all of them when ids is empty
+	Read(userID uint, ids []uint) error
+
+	// Preferences email choice of the user for every event
+	Preferences(userID uint) ([]models.NotificationPreference, error)
+	SavePreferences(userID uint, preferences []models.NotificationPreference) error
+}
+
+type notificationService struct {
+	store repository.Store
+}
+
+// NewNotificationService create the notification service over the store
+func NewNotificationService(store repository.Store) NotificationService {
+	return &notificationService{store: store}
+}
+
+// notifiedEvents events notified to the users, in the order they are shown in the preferences
+var notifiedEvents = []string{
+	models.EventQuotationCreated,
+	models.EventQuotationAwarded,
+	models.EventRequirementRejected,
+	models.EventRequirementClosed,
+	models.EventCommentMention,
+}
+
+// ownerEvents events notified to the creator of the requirement
+var ownerEvents = map[string]bool{
+	models.EventQuotationCreated:    true,
+	models.EventQuotationAwarded:    true,
+	models.EventRequirementRejected: true,
+	models.EventRequirementClosed:   true,
+}
+
+// emailDefaults events sent by email to the users without preference
+var emailDefaults = map[string]bool{
+	models.EventCommentMention: true,
+}
+
+// eventMessages key of the text of the notifications in the catalog of messages
+var eventMessages = map[string]string{
+	models.EventRequirementCreated:  utilities.NoticeRequirementCreated,
+	models.EventQuotationCreated:    utilities.NoticeQuotationCreated,
+	models.EventQuotationAwarded:    utilities.NoticeQuotationAwarded,
+	models.EventRequirementRejected: utilities.NoticeRequirementRejected,
+	models.EventRequirementClosed:   utilities.NoticeRequirementClosed,
+	models.EventCommentMention:      utilities.NoticeCommentMention,
+}
+
+// NotificationMessage text of the notification in the language
+func NotificationMessage(lang string, notification models.Notification) string {
+	key, ok := eventMessages[notification.Event]
+	if !ok {
+		return notification.Subject
+	}
+	return utilities.Message(lang, key, notification.Subject)
+}
+
+// recipients users notified of the event, without duplicates
+func recipients(event Event, requirement models.Requirement) []uint {
+	ids := make([]uint, 0, len(event.Recipients)+1)
+	if ownerEvents[event.Type] {
+		ids = append(ids, requirement.UserID)
+	}
+	ids = append(ids, event.Recipients...)
+
+	unique := make([]uint, 0, len(ids))
+	seen := map[uint]bool{0: true, event.ActorID: true}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// wantsEmail check the preference of the user for the event
+func wantsEmail(store repository.Store, userID uint, event string) (bool, error) {
+	preferences, err := store.Notifications().ListPreferences(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range preferences {
+		if p.Event == event {
+			return p.Email, nil
+		}
+	}
+	return emailDefaults[event], nil
+}
+
+func (s *notificationService) Notify(event Event) ([]Delivery, error) {
+	requirement, err := s.store.Requirements().Get(event.RequirementID)
+	if err != nil {
+		return nil, notFound(err, event.RequirementID)
+	}
+	ids := recipients(event, requirement)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	users, err := s.store.Users().ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(users))
+	err = s.store.Transaction(func(tx repository.Store) error {
+		for _, user := range users {
+			if !user.State {
+				continue
+			}
+			notification := models.Notification{
+				Event:         event.Type,
+				UserID:        user.ID,
+				ActorID:       event.ActorID,
+				RequirementID: event.RequirementID,
+				QuotationID:   event.QuotationID,
+				CommentID:     event.CommentID,
+				Subject:       requirement.Name,
+			}
+			if err := tx.Notifications().Create(&notification); err != nil {
+				return err
+			}
+			notification.Message = NotificationMessage(utilities.UserLanguage(user, utilities.DefaultLanguage), notification)
+
+			email, err := wantsEmail(tx, user.ID, event.Type)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, Delivery{User: user, Notification: notification, Email: email && user.Email != ""})
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (s *notificationService) List(userID uint, lang string, unread bool, page repository.Page) ([]models.Notification, uint, uint, error) {
+	notifications, total, err := s.store.Notifications().List(userID, unread, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for k := range notifications {
+		notifications[k].Message = NotificationMessage(lang, notifications[k])
+	}
+	count, err := s.store.Notifications().CountUnread(userID)
+	return notifications, total, count, err
+}
+
+func (s *notificationService) Read(userID uint, ids []uint) error {
+	if len(ids) == 0 {
+		ids = nil
+	}
+	return s.store.Notifications().MarkRead(userID, ids, time.Now())
+}
+
+func (s *notificationService) Preferences(userID uint) ([]models.NotificationPreference, error) {
+	saved, err := s.store.Notifications().ListPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make([]models.NotificationPreference, 0, len(notifiedEvents))
+	for _, event := range notifiedEvents {
+		preference := models.NotificationPreference{UserID: userID, Event: event, Email: emailDefaults[event]}
+		for _, p := range saved {
+			if p.Event == event {
+				preference = p
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+func (s *notificationService) SavePreferences(userID uint, preferences []models.NotificationPreference) error {
+	details := make([]utilities.FieldError, 0)
+	for i, p := range preferences {
+		known := false
+		for _, event := range notifiedEvents {
+			known = known || p.Event == event
+		}
+		if !known {
+			details = append(details, utilities.FieldError{
+				Field:  fmt.Sprintf("preferences[%d].event", i),
+				Code:   utilities.FieldOneOf,
+				Params: []interface{}{strings.Join(notifiedEvents, " ")},
+			})
+		}
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		for k := range preferences {
+			preferences[k].UserID = userID
+			if err := tx.Notifications().SavePreference(&preferences[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestNotifications(t *testing.T) {
+	f := newFixture(t)
+	buyer := models.User{DNI: "87654321", UserName: "buyer", Email: "buyer@example.com", Password: "buyer", Profile: "user", Locale: "en", State: true}
+	f.must(NewUserService(f.store).Create(&buyer))
+	notifications := NewNotificationService(f.store)
+
+	// The creator is notified of the changes made by other users, not of its own changes
+	quotation := f.quote(0, 5, 10)
+	deliveries, err := notifications.Notify(Event{Type: models.EventQuotationCreated, ActorID: buyer.ID, RequirementID: f.requirement.ID, QuotationID: quotation.ID})
+	f.must(err)
+	if len(deliveries) != 1 || deliveries[0].Email {
+		t.Fatalf("expected a notification without email by default, got %+v", deliveries)
+	}
+	_, err = notifications.Notify(Event{Type: models.EventRequirementRejected, ActorID: f.user.ID, RequirementID: f.requirement.ID})
+	f.must(err)
+
+	list, total, unread, err := notifications.List(f.user.ID, "es", true, repository.Page{})
+	f.must(err)
+	if total != 1 || unread != 1 || list[0].Event != models.EventQuotationCreated || list[0].Message != "El requerimiento Office supplies recibió una cotización" {
+		t.Fatalf("unexpected notifications %d %d %+v", total, unread, list)
+	}
+
+	// The mentions go by email unless the user does not want them, in the language of the user
+	mention := Event{Type: models.EventCommentMention, ActorID: f.user.ID, RequirementID: f.requirement.ID, Recipients: []uint{buyer.ID}}
+	deliveries, err = notifications.Notify(mention)
+	f.must(err)
+	if len(deliveries) != 1 || deliveries[0].User.ID != buyer.ID || !deliveries[0].Email || deliveries[0].Notification.Message != "You were mentioned in a comment of the requirement Office supplies" {
+		t.Fatalf("expected a email to the buyer, got %+v", deliveries)
+	}
+	f.must(notifications.SavePreferences(buyer.ID, []models.NotificationPreference{{Event: models.EventCommentMention, Email: false}}))
+	deliveries, err = notifications.Notify(mention)
+	f.must(err)
+	if len(deliveries) != 1 || deliveries[0].Email {
+		t.Fatalf("expected no email, got %+v", deliveries)
+	}
+	preferences, err := notifications.Preferences(buyer.ID)
+	f.must(err)
+	if len(preferences) != len(notifiedEvents) {
+		t.Fatalf("expected a preference by event, got %d", len(preferences))
+	}
+	err = notifications.SavePreferences(buyer.ID, []models.NotificationPreference{{Event: "unknown", Email: true}})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// Read only the notifications of the user
+	f.must(notifications.Read(f.user.ID, nil))
+	_, _, unread, err = notifications.List(f.user.ID, "es", false, repository.Page{})
+	f.must(err)
+	if unread != 0 {
+		t.Fatalf("expected all read, got %d unread", unread)
+	}
+	_, _, unread, err = notifications.List(buyer.ID, "en", false, repository.Page{})
+	f.must(err)
+	if unread != 2 {
+		t.Fatalf("expected 2 unread of the buyer, got %d", unread)
+	}
+}
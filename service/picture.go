@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/paulantezana/requirement/imaging"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// Standard sizes of the pictures in pixels
+const (
+	avatarSize      = 256
+	avatarThumbnail = 64
+	logoWidth       = 512
+	logoHeight      = 256
+	logoThumbWidth  = 128
+	logoThumbHeight = 64
+)
+
+// PictureService avatars of the users and logo of the company. The pictures are scaled to the
+// standard sizes with a thumbnail and saved with the hash of their content as name
+type PictureService interface {
+	// SetAvatar replace the avatar of the user by the picture cropped to a square
+	SetAvatar(userID uint, content io.Reader) (models.User, error)
+	// SetLogo replace the logo of the setting by the picture fitted to the logo size
+	SetLogo(content io.Reader) (models.Setting, error)
+}
+
+type pictureService struct {
+	store repository.Store
+	files Files
+}
+
+// NewPictureService create the picture service over the store, with the pictures in the files
+func NewPictureService(store repository.Store, files Files) PictureService {
+	return &pictureService{store: store, files: files}
+}
+
+// picture encoded version of a uploaded picture
+type picture struct {
+	data        []byte
+	ext         string
+	contentType string
+}
+
+// decode the uploaded picture, the type is detected from the content
+func (s *pictureService) decode(content io.Reader) (image.Image, error) {
+	data, err := s.files.read(content)
+	if err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(data)
+	switch err {
+	case imaging.ErrFormat:
+		return nil, utilities.NewError(http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	case imaging.ErrTooLarge:
+		return nil, utilities.NewError(http.StatusRequestEntityTooLarge, utilities.ErrFileTooLarge, s.files.MaxSize>>20)
+	}
+	return img, err
+}
+
+// put save the pictures in the folder, named by the hash of their content
+func (s *pictureService) put(folder string, pictures ...picture) ([]string, error) {
+	keys := make([]string, 0, len(pictures))
+	for _, p := range pictures {
+		sum := sha256.Sum256(p.data)
+		key := fmt.Sprintf("%s/%s%s", folder, hex.EncodeToString(sum[:]), p.ext)
+		if err := s.files.Storage.Put(key, bytes.NewReader(p.data), int64(len(p.data)), p.contentType); err != nil {
+			s.remove(keys, nil)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// remove the files that are not kept, the bundled files are never removed. A file not
+// removed is only wasted space
+func (s *pictureService) remove(keys []string, keep []string) {
+	for _, key := range keys {
+		if key == "" || strings.HasPrefix(key, storage.StaticPrefix) || contains(keep, key) {
+			continue
+		}
+		s.files.Storage.Delete(key)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// jpegs encode the pictures as jpeg
+func jpegs(images ...image.Image) ([]picture, error) {
+	pictures := make([]picture, 0, len(images))
+	for _, img := range images {
+		data, err := imaging.JPEG(img)
+		if err != nil {
+			return nil, err
+		}
+		pictures = append(pictures, picture{data: data, ext: ".jpg", contentType: "image/jpeg"})
+	}
+	return pictures, nil
+}
+
+// pngs encode the pictures as png, with transparency
+func pngs(images ...image.Image) ([]picture, error) {
+	pictures := make([]picture, 0, len(images))
+	for _, img := range images {
+		data, err := imaging.PNG(img)
+		if err != nil {
+			return nil, err
+		}
+		pictures = append(pictures, picture{data: data, ext: ".png", contentType: "image/png"})
+	}
+	return pictures, nil
+}
+
+func (s *pictureService) SetAvatar(userID uint, content io.Reader) (models.User, error) {
+	user, err := s.store.Users().Get(userID)
+	if err != nil {
+		return user, notFound(err, userID)
+	}
+	img, err := s.decode(content)
+	if err != nil {
+		return user, err
+	}
+	pictures, err := jpegs(imaging.Fill(img, avatarSize, avatarSize), imaging.Fill(img, avatarThumbnail, avatarThumbnail))
+	if err != nil {
+		return user, err
+	}
+
+	// The folder of the user, the same picture of other user is other file
+	keys, err := s.put(fmt.Sprintf("profiles/%d", userID), pictures...)
+	if err != nil {
+		return user, err
+	}
+	fields := map[string]interface{}{"avatar": keys[0], "avatar_thumbnail": keys[1]}
+	if err := s.store.Users().UpdateFields(userID, fields); err != nil {
+		s.remove(keys, []string{user.Avatar, user.AvatarThumbnail})
+		return user, notFound(err, userID)
+	}
+	s.remove([]string{user.Avatar, user.AvatarThumbnail}, keys)
+	user.Avatar, user.AvatarThumbnail = keys[0], keys[1]
+	return user, nil
+}
+
+func (s *pictureService) SetLogo(content io.Reader) (models.Setting, error) {
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return setting, err
+	}
+	img, err := s.decode(content)
+	if err != nil {
+		return setting, err
+	}
+	pictures, err := pngs(imaging.Fit(img, logoWidth, logoHeight), imaging.Fit(img, logoThumbWidth, logoThumbHeight))
+	if err != nil {
+		return setting, err
+	}
+
+	keys, err := s.put("settings", pictures...)
+	if err != nil {
+		return setting, err
+	}
+	previous := []string{setting.Logo, setting.LogoThumbnail}
+	setting.Logo, setting.LogoThumbnail = keys[0], keys[1]
+	if err := s.store.Settings().Save(&setting); err != nil {
+		s.remove(keys, previous)
+		return setting, err
+	}
+	s.remove(previous, keys)
+	return setting, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/storage"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// pngOf encoded picture of the size with a single color
+func pngOf(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// size of the saved picture
+func size(t *testing.T, files storage.Storage, key string) image.Point {
+	t.Helper()
+	content, err := files.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	config, _, err := image.DecodeConfig(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return image.Pt(config.Width, config.Height)
+}
+
+func TestPictureAvatar(t *testing.T) {
+	f := newFixture(t)
+	files := storage.NewLocal(t.TempDir(), "/files", []byte("secret"), time.Minute)
+	pictures := NewPictureService(f.store, Files{Storage: files, MaxSize: 1 << 20})
+
+	user, err := pictures.SetAvatar(f.user.ID, bytes.NewReader(pngOf(t, 400, 300, color.White)))
+	f.must(err)
+	if !strings.HasPrefix(user.Avatar, "profiles/") || !strings.HasSuffix(user.Avatar, ".jpg") {
+		t.Fatalf("unexpected avatar %q", user.Avatar)
+	}
+	if s := size(t, files, user.Avatar); s != image.Pt(avatarSize, avatarSize) {
+		t.Errorf("expected avatar of %d, got %v", avatarSize, s)
+	}
+	if s := size(t, files, user.AvatarThumbnail); s != image.Pt(avatarThumbnail, avatarThumbnail) {
+		t.Errorf("expected thumbnail of %d, got %v", avatarThumbnail, s)
+	}
+
+	// The replaced files are removed
+	replaced, err := pictures.SetAvatar(f.user.ID, bytes.NewReader(pngOf(t, 100, 100, color.Black)))
+	f.must(err)
+	if replaced.Avatar == user.Avatar {
+		t.Fatal("expected a new name for other content")
+	}
+	for _, key := range []string{user.Avatar, user.AvatarThumbnail} {
+		if _, err := files.Get(key); err != storage.ErrNotFound {
+			t.Errorf("expected %s removed, got %v", key, err)
+		}
+	}
+	saved, err := f.store.Users().Get(f.user.ID)
+	f.must(err)
+	if saved.Avatar != replaced.Avatar || saved.AvatarThumbnail != replaced.AvatarThumbnail {
+		t.Errorf("unexpected saved avatar %q %q", saved.Avatar, saved.AvatarThumbnail)
+	}
+
+	// Not a image, or bigger than the limit
+	_, err = pictures.SetAvatar(f.user.ID, strings.NewReader("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"))
+	expectError(t, err, http.StatusUnsupportedMediaType, utilities.ErrFileType)
+	small := NewPictureService(f.store, Files{Storage: files, MaxSize: 64})
+	_, err = small.SetAvatar(f.user.ID, bytes.NewReader(pngOf(t, 100, 100, color.Black)))
+	expectError(t, err, http.StatusRequestEntityTooLarge, utilities.ErrFileTooLarge)
+}
+
+func TestPictureLogo(t *testing.T) {
+	f := newFixture(t)
+	files := storage.NewLocal(t.TempDir(), "/files", []byte("secret"), time.Minute)
+	pictures := NewPictureService(f.store, Files{Storage: files, MaxSize: 1 << 20})
+
+	// The bundled default logo is never removed
+	setting, err := f.store.Settings().Get()
+	f.must(err)
+	setting.Logo = storage.StaticPrefix + "logo.png"
+	f.must(f.store.Settings().Save(&setting))
+
+	setting, err = pictures.SetLogo(bytes.NewReader(pngOf(t, 1024, 256, color.Black)))
+	f.must(err)
+	if s := size(t, files, setting.Logo); s != image.Pt(logoWidth, logoWidth/4) {
+		t.Errorf("expected logo fitted to %d, got %v", logoWidth, s)
+	}
+	if s := size(t, files, setting.LogoThumbnail); s != image.Pt(logoThumbWidth, logoThumbWidth/4) {
+		t.Errorf("expected thumbnail fitted to %d, got %v", logoThumbWidth, s)
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// LinkDays validity of the portal links of the requirements without expiration date
+const LinkDays = 7
+
+// PortalLine require shown to the provider, the suggested price is internal and never shown
+type PortalLine struct {
+	RequireID   uint            `json:"require_id"`
+	ProductName string          `json:"product_name"`
+	Amount      decimal.Decimal `json:"amount"`
+	UnitMeasure string          `json:"unit_measure"`
+	Observation string          `json:"observation"`
+	UnitPrice   decimal.Decimal `json:"unit_price"` // Price sent by the provider
+	Exempt      bool            `json:"exempt"`     // Without IGV
+	NotQuoted   bool            `json:"not_quoted"`
+}
+
+// PortalView requirement that the provider was invited to quote
+type PortalView struct {
+	RequirementID   uint      `json:"requirement_id"`
+	RequirementName string    `json:"requirement_name"`
+	Place           string    `json:"place"`
+	Destination     string    `json:"destination"`
+	ExpirationDate  time.Time `json:"expiration_date"`
+	ProviderID      uint      `json:"provider_id"`
+	ProviderName    string    `json:"provider_name"`
+	QuotationID     uint      `json:"quotation_id"` // 0 until the provider sends the quotation
+	Currency        string    `json:"currency"`     // Of the quotation sent, the base currency until then
+	TaxIncluded     bool      `json:"tax_included"` // Of the quotation sent, the default of the setting until then
+
+	Requires []PortalLine `json:"requires"`
+}
+
+// PortalService providers that quote the requirements by themselves with a link
+type PortalService interface {
+	// View the requirement to quote, the request for quotation is marked as opened
+	View(providerID uint, requirementID uint) (PortalView, error)
+	// Submit create the quotation of the provider pending of the approval of the buyer
+	Submit(userID uint, quotation *models.Quotation) error
+	// Decline the provider does not quote the requirement
+	Decline(providerID uint, requirementID uint, reason string) error
+}
+
+type portalService struct {
+	store      repository.Store
+	quotations *quotationService
+	rfqs       RfqService
+}
+
+// NewPortalService create the portal service over the store
+func NewPortalService(store repository.Store) PortalService {
+	return &portalService{store: store, quotations: newQuotationService(store), rfqs: NewRfqService(store)}
+}
+
+// LinkExpiration the links expire at the end of the expiration day of the requirement
+func LinkExpiration(requirement models.Requirement) time.Time {
+	if requirement.ExpirationDate.IsZero() {
+		return time.Now().AddDate(0, 0, LinkDays)
+	}
+	y, m, d := requirement.ExpirationDate.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, requirement.ExpirationDate.Location())
+}
+
+// openRequirement requirement that still receives quotations
+func openRequirement(store repository.Store, requirementID uint) (models.Requirement, error) {
+	requirement, err := store.Requirements().Get(requirementID)
+	if err != nil {
+		return requirement, notFound(err, requirementID)
+	}
+	if !CanChangeState(requirement.State, models.RequirementQuoted) || LinkExpiration(requirement).Before(time.Now()) {
+		return requirement, invalidState(requirementID)
+	}
+	return requirement, nil
+}
+
+func (s *portalService) View(providerID uint, requirementID uint) (PortalView, error) {
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return PortalView{}, notFound(err, requirementID)
+	}
+	provider, err := s.store.Providers().Get(providerID)
+	if err != nil {
+		return PortalView{}, notFound(err, providerID)
+	}
+
+	view := PortalView{
+		RequirementID:   requirement.ID,
+		RequirementName: requirement.Name,
+		Place:           requirement.Place,
+		Destination:     requirement.Destination,
+		ExpirationDate:  requirement.ExpirationDate,
+		ProviderID:      provider.ID,
+		ProviderName:    provider.Name,
+		Requires:        make([]PortalLine, 0),
+	}
+
+	// Prices already sent
+	quotation, err := s.quotationOf(providerID, requirementID)
+	if err != nil {
+		return view, err
+	}
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return view, err
+	}
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return view, err
+	}
+	view.Currency = conv.base
+	view.TaxIncluded = setting.TaxIncluded
+	prices := make(map[uint]models.QuotationDetail)
+	if quotation != nil {
+		view.QuotationID = quotation.ID
+		view.Currency = conv.currency(*quotation)
+		view.TaxIncluded = taxIncluded(*quotation)
+		for _, qd := range quotation.QuotationDetails {
+			prices[qd.RequireID] = qd
+		}
+	}
+
+	lines, err := s.store.Requires().ListByRequirement(requirementID)
+	if err != nil {
+		return view, err
+	}
+	for _, line := range lines {
+		view.Requires = append(view.Requires, PortalLine{
+			RequireID:   line.ID,
+			ProductName: line.ProductName,
+			Amount:      line.Amount,
+			UnitMeasure: line.UnitMeasure,
+			Observation: line.Observation,
+			UnitPrice:   prices[line.ID].UnitPrice,
+			NotQuoted:   prices[line.ID].NotQuoted,
+			Exempt:      line.Exempt,
+		})
+	}
+	return view, s.rfqs.Opened(requirementID, providerID)
+}
+
+// quotationOf quotation of the provider for the requirement, nil when there is none
+func (s *portalService) quotationOf(providerID uint, requirementID uint) (*models.Quotation, error) {
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	for k := range quotations {
+		if quotations[k].ProviderID == providerID {
+			return &quotations[k], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *portalService) Submit(userID uint, quotation *models.Quotation) error {
+	if _, err := openRequirement(s.store, quotation.RequirementID); err != nil {
+		return err
+	}
+
+	// A single quotation by provider
+	current, err := s.quotationOf(quotation.ProviderID, quotation.RequirementID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		return utilities.NewError(http.StatusConflict, utilities.ErrAlreadyQuoted)
+	}
+
+	quotation.ID = 0
+	quotation.Winner = false
+	quotation.SuggestWinner = false
+	quotation.Pending = true
+	return newQuotationService(s.store).create(userID, quotation)
+}
+
+func (s *portalService) Decline(providerID uint, requirementID uint, reason string) error {
+	if _, err := openRequirement(s.store, requirementID); err != nil {
+		return err
+	}
+	return s.rfqs.Decline(requirementID, providerID, reason)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestPortalSubmitPendingUntilApproved(t *testing.T) {
+	f := newFixture(t)
+	portal := NewPortalService(f.store)
+	quotations := NewQuotationService(f.store)
+
+	buyer := f.quote(0, 5, 10)
+	sent := f.newQuotation(1, 1, 1)
+	f.must(portal.Submit(f.user.ID, &sent))
+
+	// The quotation of the provider is not ranked nor can be awarded
+	q, err := f.store.Quotations().Get(sent.ID)
+	f.must(err)
+	if !q.Pending || q.WinnerLevel != 0 {
+		t.Fatalf("expected pending quotation without rank, got %+v", q)
+	}
+	table, err := quotations.ComparativeTable(f.requirement.ID, false)
+	f.must(err)
+	if len(table.CTResponseProviders) != 1 {
+		t.Fatalf("expected only the approved quotation in the comparative table, got %d", len(table.CTResponseProviders))
+	}
+	_, err = NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, sent.ID)
+	expectError(t, err, http.StatusConflict, utilities.ErrQuotationPending)
+
+	// Once approved it is the cheapest
+	f.must(quotations.Approve(sent.ID))
+	for id, level := range map[uint]uint{sent.ID: 1, buyer.ID: 2} {
+		q, err := f.store.Quotations().Get(id)
+		f.must(err)
+		if q.WinnerLevel != level {
+			t.Errorf("quotation %d: expected winner level %d, got %d", id, level, q.WinnerLevel)
+		}
+	}
+}
+
+func TestPortalSubmitOnce(t *testing.T) {
+	f := newFixture(t)
+	portal := NewPortalService(f.store)
+
+	sent := f.newQuotation(1, 1, 1)
+	f.must(portal.Submit(f.user.ID, &sent))
+
+	view, err := portal.View(f.providers[1].ID, f.requirement.ID)
+	f.must(err)
+	if view.QuotationID != sent.ID || len(view.Requires) != 2 || view.Requires[0].UnitPrice != dec(1) {
+		t.Fatalf("unexpected portal view %+v", view)
+	}
+
+	again := f.newQuotation(1, 2, 2)
+	expectError(t, portal.Submit(f.user.ID, &again), http.StatusConflict, utilities.ErrAlreadyQuoted)
+}
+
+func TestPortalSubmitOtherRequirement(t *testing.T) {
+	f := newFixture(t)
+	other := models.Requirement{
+		Name:         "Other",
+		CostCenterID: f.costCenter.ID,
+		Requires:     []models.Require{{Amount: dec(1), ProductID: f.requirement.Requires[0].ProductID}},
+	}
+	f.must(NewRequirementService(f.store).Create(f.user.ID, &other))
+
+	sent := f.newQuotation(1, 1, 1)
+	sent.QuotationDetails[0].RequireID = other.Requires[0].ID
+	expectError(t, NewPortalService(f.store).Submit(f.user.ID, &sent), http.StatusUnprocessableEntity, utilities.ErrValidation)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// ProductService products that can be required
+type ProductService interface {
+	List(page repository.Page) ([]models.Product, uint, error)
+	Get(id uint) (models.Product, error)
+	Create(product *models.Product) error
+	Update(product *models.Product) error
+	Delete(id uint) error
+}
+
+type productService struct {
+	store repository.Store
+}
+
+// NewProductService create the product service over the store
+func NewProductService(store repository.Store) ProductService {
+	return &productService{store: store}
+}
+
+func (s *productService) List(page repository.Page) ([]models.Product, uint, error) {
+	return s.store.Products().List(page)
+}
+
+func (s *productService) Get(id uint) (models.Product, error) {
+	product, err := s.store.Products().Get(id)
+	return product, notFound(err, id)
+}
+
+// validate the struct tags and the category of the product
+func (s *productService) validate(product models.Product) error {
+	details := utilities.ValidateStruct(&product)
+	if product.CategoryID != nil {
+		_, err := s.store.Categories().Get(*product.CategoryID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference("category_id", *product.CategoryID))
+		case err != nil:
+			return err
+		}
+	}
+	return invalid(details)
+}
+
+func (s *productService) Create(product *models.Product) error {
+	if err := s.validate(*product); err != nil {
+		return err
+	}
+	return s.store.Products().Create(product)
+}
+
+func (s *productService) Update(product *models.Product) error {
+	if err := s.validate(*product); err != nil {
+		return err
+	}
+	if err := s.store.Products().Update(product); err != nil {
+		return notFound(err, product.ID)
+	}
+	// The false values and the category removed are not updated with the struct
+	fields := map[string]interface{}{}
+	if product.CategoryID == nil {
+		fields["category_id"] = nil
+	}
+	if !product.State {
+		fields["state"] = false
+	}
+	if !product.Exempt {
+		fields["exempt"] = false
+	}
+	if len(fields) > 0 {
+		return notFound(s.store.Products().UpdateFields(product.ID, fields), product.ID)
+	}
+	return nil
+}
+
+func (s *productService) Delete(id uint) error {
+	return notFound(s.store.Products().Delete(id), id)
+}
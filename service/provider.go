@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// ProviderService providers that quote the requirements
+type ProviderService interface {
+	List(page repository.Page) ([]models.Provider, uint, error)
+	Get(id uint) (models.Provider, error)
+	Create(provider *models.Provider) error
+	Update(provider *models.Provider) error
+	Delete(id uint) error
+
+	// CheckRUC return a conflict error when the RUC is already registered
+	CheckRUC(ruc string) error
+	// Import validate and insert all the providers, nothing is inserted when one fails
+	Import(providers []models.Provider) error
+}
+
+type providerService struct {
+	store repository.Store
+}
+
+// NewProviderService create the provider service over the store
+func NewProviderService(store repository.Store) ProviderService {
+	return &providerService{store: store}
+}
+
+func (s *providerService) List(page repository.Page) ([]models.Provider, uint, error) {
+	return s.store.Providers().List(page)
+}
+
+func (s *providerService) Get(id uint) (models.Provider, error) {
+	provider, err := s.store.Providers().Get(id)
+	if err != nil {
+		return provider, notFound(err, id)
+	}
+	provider.Attachments, err = attachments(s.store, models.AttachmentProvider, id)
+	return provider, err
+}
+
+func (s *providerService) Create(provider *models.Provider) error {
+	if err := invalid(utilities.ValidateStruct(provider)); err != nil {
+		return err
+	}
+	return s.store.Providers().Create(provider)
+}
+
+func (s *providerService) Update(provider *models.Provider) error {
+	if err := invalid(utilities.ValidateStruct(provider)); err != nil {
+		return err
+	}
+	if err := s.store.Providers().Update(provider); err != nil {
+		return notFound(err, provider.ID)
+	}
+	if !provider.State {
+		return notFound(s.store.Providers().UpdateFields(provider.ID, map[string]interface{}{"state": false}), provider.ID)
+	}
+	return nil
+}
+
+func (s *providerService) Delete(id uint) error {
+	if err := attached(s.store, models.AttachmentProvider, id); err != nil {
+		return err
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Catalog().ReplaceCategories(id, nil); err != nil {
+			return err
+		}
+		if err := tx.Catalog().ReplaceProducts(id, nil); err != nil {
+			return err
+		}
+		return notFound(tx.Providers().Delete(id), id)
+	})
+}
+
+func (s *providerService) CheckRUC(ruc string) error {
+	_, err := s.store.Providers().GetByRUC(ruc)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return utilities.NewError(http.StatusConflict, utilities.ErrRucRegistered)
+}
+
+func (s *providerService) Import(providers []models.Provider) error {
+	// Validate all the rows, the field is prefixed with the row number of the sheet
+	details := make([]utilities.FieldError, 0)
+	for k := range providers {
+		for _, fe := range utilities.ValidateStruct(&providers[k]) {
+			fe.Field = fmt.Sprintf("rows[%d].%s", k+2, fe.Field)
+			details = append(details, fe)
+		}
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		for k := range providers {
+			if err := tx.Providers().Create(&providers[k]); err != nil {
+				e := utilities.ToError(err)
+				e.Details = append(e.Details, utilities.FieldError{
+					Field:  fmt.Sprintf("rows[%d].ruc", k+2),
+					Code:   utilities.FieldInvalid,
+					Params: []interface{}{providers[k].RUC},
+				})
+				return e
+			}
+		}
+		return nil
+	})
+}
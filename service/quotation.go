@@ -0,0 +1,837 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// QuotationSummary quotation of the list of a requirement with its total
+type QuotationSummary struct {
+	ID            uint            `json:"id"`
+	ProviderID    uint            `json:"provider_id"`
+	ProviderName  string          `json:"provider_name"`
+	UserID        uint            `json:"user_id"`
+	UserFirstName string          `json:"user_first_name"`
+	UserLastName  string          `json:"user_last_name"`
+	RequirementID uint            `json:"requirement_id"`
+	Count         uint            `json:"count"`
+	WinnerLevel   uint            `json:"winner_level"`
+	Winner        bool            `json:"winner"`
+	Pending       bool            `json:"pending"`
+	Partial       bool            `json:"partial"`
+	Sealed        bool            `json:"sealed"` // The summation is hidden until the bid opening
+	Currency      string          `json:"currency"`
+	TaxIncluded   bool            `json:"tax_included"`
+	Summation     decimal.Decimal `json:"summation"` // Sum of the lines as quoted
+	Rate          decimal.Decimal `json:"rate"`      // Exchange rate to the base currency on the emission date
+	Converted     decimal.Decimal `json:"converted"` // Total with IGV in the base currency
+
+	Taxes
+}
+
+// QuotationLine price quoted for a require
+type QuotationLine struct {
+	ID             uint            `json:"id"`
+	Amount         decimal.Decimal `json:"amount"`
+	UnitMeasure    string          `json:"unit_measure"`
+	ProductID      uint            `json:"product_id"`
+	ProductName    string          `json:"product_name"`
+	SuggestedPrice decimal.Decimal `json:"suggested_price"`
+	UnitPrice      decimal.Decimal `json:"unit_price"`
+	NotQuoted      bool            `json:"not_quoted"`
+	Exempt         bool            `json:"exempt"` // Without IGV
+	Observation    string          `json:"observation"`
+}
+
+// QuotationView quotation with the provider and the lines quoted
+type QuotationView struct {
+	ID            uint      `json:"id"`
+	EmissionDate  time.Time `json:"emission_date"`
+	SuggestWinner bool      `json:"suggest_winner"` // Winner suggestion by user
+	Observation   string    `json:"observation"`
+
+	ProviderID    uint            `json:"provider_id"`
+	ProviderName  string          `json:"provider_name"`
+	RequirementID uint            `json:"requirement_id"`
+	Sealed        bool            `json:"sealed"` // The unit prices are hidden until the bid opening
+	Currency      string          `json:"currency"`
+	TaxIncluded   bool            `json:"tax_included"`
+	TaxRate       decimal.Decimal `json:"tax_rate"`
+
+	Taxes
+	QuotationDetails []QuotationLine `json:"quotation_details"`
+}
+
+// CTQuotation price of a require in the comparative table, sequence is the column of the quotation
+type CTQuotation struct {
+	QuotationID uint            `json:"quotation_id"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Converted   decimal.Decimal `json:"converted"`   // Unit price without IGV in the base currency
+	FirstPrice  decimal.Decimal `json:"first_price"` // Price of the first offer, only with the savings
+	NotQuoted   bool            `json:"not_quoted"`
+	Sequence    uint            `json:"sequence"`
+}
+
+// CTRequire row of the comparative table
+type CTRequire struct {
+	ID          uint            `json:"id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Name        string          `json:"name"`
+	UnitMeasure string          `json:"unit_measure"`
+	Exempt      bool            `json:"exempt"`
+	Observation string          `json:"observation"`
+}
+
+// CTProvider column of the comparative table
+type CTProvider struct {
+	Name        string          `json:"name"`
+	Manager     string          `json:"manager"`
+	DeliverDate time.Time       `json:"deliver_date"`
+	Partial     bool            `json:"partial"`
+	Currency    string          `json:"currency"`
+	Rate        decimal.Decimal `json:"rate"`
+	TaxIncluded bool            `json:"tax_included"`
+
+	Taxes
+	// Only with the savings: total of the first offer and savings of the final offer
+	FirstTotal decimal.Decimal `json:"first_total"`
+	Savings    decimal.Decimal `json:"savings"`
+}
+
+// ComparativeTable prices of all the quotations of a requirement, ordered by winner level
+type ComparativeTable struct {
+	CTResponseQuotations []CTQuotation `json:"ct_response_quotations"`
+	CTResponseRequires   []CTRequire   `json:"ct_response_requires"`
+	CTResponseProviders  []CTProvider  `json:"ct_response_providers"`
+	Sealed               bool          `json:"sealed"`   // The unit prices are hidden until the bid opening
+	Currency             string        `json:"currency"` // Base currency of the converted prices
+}
+
+// PurchaseLine line of the purchase order
+type PurchaseLine struct {
+	Code        string          `json:"code"`
+	Amount      string          `json:"amount"`
+	UnitMeasure string          `json:"unit_measure"`
+	Description string          `json:"description"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Total       decimal.Decimal `json:"total"`
+	Exempt      bool            `json:"exempt"`
+}
+
+// PurchaseOrder lines of the winner quotation of a requirement
+type PurchaseOrder struct {
+	PurchaseOrder []PurchaseLine     `json:"purchase_order"`
+	Currency      string             `json:"currency"`
+	TaxIncluded   bool               `json:"tax_included"`
+	TaxRate       decimal.Decimal    `json:"tax_rate"`
+	Provider      models.Provider    `json:"provider"`
+	Requirement   models.Requirement `json:"requirement"`
+
+	Taxes
+}
+
+// QuotationService quotations of the providers for a requirement
+type QuotationService interface {
+	List(requirementID uint) ([]QuotationSummary, error)
+	Get(id uint) (models.Quotation, error)
+	Detail(id uint) (QuotationView, error)
+	Create(userID uint, quotation *models.Quotation) error
+	Update(userID uint, quotation *models.Quotation) error
+	Delete(id uint) error
+	// Approve a quotation sent by the provider in the portal, from now it is ranked
+	Approve(id uint) error
+
+	// ComparativeTable with savings compares the first offer of each quotation with the final offer
+	ComparativeTable(requirementID uint, savings bool) (ComparativeTable, error)
+	PurchaseOrder(requirementID uint) (PurchaseOrder, error)
+}
+
+type quotationService struct {
+	store repository.Store
+}
+
+// NewQuotationService create the quotation service over the store
+func NewQuotationService(store repository.Store) QuotationService {
+	return newQuotationService(store)
+}
+
+func newQuotationService(store repository.Store) *quotationService {
+	return &quotationService{store: store}
+}
+
+// requires lines of the requirement by id
+func requires(store repository.Store, requirementID uint) (map[uint]repository.RequireLine, error) {
+	lines, err := store.Requires().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]repository.RequireLine, len(lines))
+	for _, line := range lines {
+		byID[line.ID] = line
+	}
+	return byID, nil
+}
+
+func (s *quotationService) List(requirementID uint) ([]QuotationSummary, error) {
+	isSealed, err := unseal(s.store, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := requires(s.store, requirementID)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]QuotationSummary, 0, len(quotations))
+	for _, q := range quotations {
+		provider, err := s.store.Providers().Get(q.ProviderID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		user, err := s.store.Users().Get(q.UserID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		summaries = append(summaries, QuotationSummary{
+			ID:            q.ID,
+			ProviderID:    q.ProviderID,
+			ProviderName:  provider.Name,
+			UserID:        q.UserID,
+			UserFirstName: user.FirstName,
+			UserLastName:  user.LastName,
+			RequirementID: q.RequirementID,
+			Count:         uint(len(q.QuotationDetails)),
+			WinnerLevel:   q.WinnerLevel,
+			Winner:        q.Winner,
+			Pending:       q.Pending,
+			Partial:       q.Partial,
+			Sealed:        isSealed,
+			Currency:      conv.currency(q),
+			TaxIncluded:   taxIncluded(q),
+		})
+		if !isSealed {
+			summary := &summaries[len(summaries)-1]
+			if summary.Rate, err = conv.rate(q); err != nil {
+				return nil, err
+			}
+			if summary.Summation, err = summation(q, lines); err != nil {
+				return nil, err
+			}
+			if summary.Taxes, err = taxes(q, lines); err != nil {
+				return nil, err
+			}
+			if summary.Converted, err = convert(summary.Total, summary.Rate); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return summaries, nil
+}
+
+func (s *quotationService) Get(id uint) (models.Quotation, error) {
+	quotation, err := s.get(id)
+	if err != nil {
+		return quotation, err
+	}
+	isSealed, err := unseal(s.store, quotation.RequirementID)
+	if err != nil {
+		return quotation, err
+	}
+	if isSealed {
+		quotation.WinnerLevel = 0
+		for k := range quotation.QuotationDetails {
+			quotation.QuotationDetails[k].UnitPrice = decimal.Zero
+		}
+	}
+	quotation.Attachments, err = attachments(s.store, models.AttachmentQuotation, id)
+	return quotation, err
+}
+
+// get quotation with the prices, also of the sealed bids
+func (s *quotationService) get(id uint) (models.Quotation, error) {
+	quotation, err := s.store.Quotations().Get(id)
+	return quotation, notFound(err, id)
+}
+
+func (s *quotationService) Detail(id uint) (QuotationView, error) {
+	quotation, err := s.get(id)
+	if err != nil {
+		return QuotationView{}, err
+	}
+	isSealed, err := unseal(s.store, quotation.RequirementID)
+	if err != nil {
+		return QuotationView{}, err
+	}
+	provider, err := s.store.Providers().Get(quotation.ProviderID)
+	if err != nil && err != repository.ErrNotFound {
+		return QuotationView{}, err
+	}
+	lines, err := requires(s.store, quotation.RequirementID)
+	if err != nil {
+		return QuotationView{}, err
+	}
+
+	view := QuotationView{
+		ID:               quotation.ID,
+		EmissionDate:     quotation.EmissionDate,
+		SuggestWinner:    quotation.SuggestWinner,
+		Observation:      quotation.Observation,
+		ProviderID:       quotation.ProviderID,
+		ProviderName:     provider.Name,
+		RequirementID:    quotation.RequirementID,
+		Sealed:           isSealed,
+		Currency:         quotation.Currency,
+		TaxIncluded:      taxIncluded(quotation),
+		TaxRate:          quotation.TaxRate,
+		QuotationDetails: make([]QuotationLine, 0, len(quotation.QuotationDetails)),
+	}
+	if !isSealed {
+		if view.Taxes, err = taxes(quotation, lines); err != nil {
+			return view, err
+		}
+	}
+	for _, qd := range quotation.QuotationDetails {
+		line := lines[qd.RequireID]
+		if isSealed {
+			qd.UnitPrice = decimal.Zero
+		}
+		view.QuotationDetails = append(view.QuotationDetails, QuotationLine{
+			ID:             qd.ID,
+			Amount:         line.Amount,
+			UnitMeasure:    line.UnitMeasure,
+			ProductID:      line.ProductID,
+			ProductName:    line.ProductName,
+			SuggestedPrice: line.SuggestedPrice,
+			UnitPrice:      qd.UnitPrice,
+			NotQuoted:      qd.NotQuoted,
+			Exempt:         line.Exempt,
+			Observation:    line.Observation,
+		})
+	}
+	return view, nil
+}
+
+func (s *quotationService) Create(userID uint, quotation *models.Quotation) error {
+	quotation.Pending = false
+	return s.create(userID, quotation)
+}
+
+// create validate and insert the quotation, the quotation, its first revision, the request
+// for quotation, the state of the requirement and the ranking are saved in a single transaction
+func (s *quotationService) create(userID uint, quotation *models.Quotation) error {
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return err
+	}
+	setting, err := s.store.Settings().Get()
+	if err != nil {
+		return err
+	}
+	quotation.Currency = conv.currency(*quotation)
+	quotation.EmissionDate = time.Now()
+
+	// The IGV of the setting applies to the quotation since its emission
+	quotation.TaxRate = setting.TaxRate
+	if quotation.TaxIncluded == nil {
+		quotation.TaxIncluded = &setting.TaxIncluded
+	}
+
+	// Validate data
+	details, requirement, err := s.validate(*quotation)
+	if err != nil {
+		return err
+	}
+	if quotation.Currency != conv.base && !hasField(details, "currency") {
+		if _, err := conv.rate(*quotation); err != nil {
+			if e, ok := err.(*utilities.Error); !ok || e.Code != utilities.ErrNoExchangeRate {
+				return err
+			}
+			details = append(details, utilities.FieldError{
+				Field:  "currency",
+				Code:   utilities.FieldNoRate,
+				Params: []interface{}{quotation.Currency, quotation.EmissionDate.Format(rateDate)},
+			})
+		}
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+	if !CanChangeState(requirement.State, models.RequirementQuoted) {
+		return invalidState(requirement.ID)
+	}
+
+	// The not quoted lines have no price
+	quotation.Partial = false
+	for k := range quotation.QuotationDetails {
+		if quotation.QuotationDetails[k].NotQuoted {
+			quotation.QuotationDetails[k].UnitPrice = decimal.Zero
+			quotation.Partial = true
+		}
+	}
+	quotation.UserID = userID
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		// Validate limit quotations
+		setting, err := tx.Settings().Get()
+		if err != nil {
+			return err
+		}
+		count, err := tx.Quotations().Count(quotation.RequirementID)
+		if err != nil {
+			return err
+		}
+		if setting.Quotations > 0 && count >= setting.Quotations {
+			return utilities.NewError(http.StatusConflict, utilities.ErrQuotationLimit)
+		}
+
+		// Insert quotation
+		if err := tx.Quotations().Create(quotation); err != nil {
+			return err
+		}
+		if err := revise(tx, userID, quotation.ID); err != nil {
+			return err
+		}
+		if err := recordPrices(tx, quotation.ID, quotation.EmissionDate); err != nil {
+			return err
+		}
+
+		// The request for quotation sent to the provider is answered, by the portal or the buyer
+		if err := NewRfqService(tx).Quoted(quotation.RequirementID, quotation.ProviderID); err != nil {
+			return err
+		}
+
+		// Change state requirement and winner level
+		if err := changeState(tx, requirement, models.RequirementQuoted, userID); err != nil {
+			return err
+		}
+		return NewAwardService(tx).Rank(quotation.RequirementID)
+	})
+}
+
+// validate return all the violations of a new quotation: struct tags, deliver date,
+// active provider, existing requirement and a single line for every require of the requirement
+func (s *quotationService) validate(quotation models.Quotation) ([]utilities.FieldError, models.Requirement, error) {
+	details := utilities.ValidateStruct(&quotation)
+
+	if isPastDate(quotation.DeliverDate) {
+		details = append(details, utilities.FieldError{Field: "deliver_date", Code: utilities.FieldPast})
+	}
+
+	if quotation.ProviderID != 0 {
+		provider, err := s.store.Providers().Get(quotation.ProviderID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference("provider_id", quotation.ProviderID))
+		case err != nil:
+			return nil, models.Requirement{}, err
+		case !provider.State:
+			details = append(details, inactive("provider_id", quotation.ProviderID))
+		}
+	}
+
+	requirement := models.Requirement{}
+	if quotation.RequirementID != 0 {
+		var err error
+		requirement, err = s.store.Requirements().Get(quotation.RequirementID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference("requirement_id", quotation.RequirementID))
+		case err != nil:
+			return nil, requirement, err
+		}
+	}
+
+	covered := make(map[uint]bool, len(quotation.QuotationDetails))
+	for i, qd := range quotation.QuotationDetails {
+		if qd.RequireID == 0 {
+			continue
+		}
+		field := fmt.Sprintf("quotation_details[%d].require_id", i)
+		require, err := s.store.Requires().Get(qd.RequireID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference(field, qd.RequireID))
+		case err != nil:
+			return nil, requirement, err
+		case require.RequirementID != quotation.RequirementID:
+			details = append(details, utilities.FieldError{Field: field, Code: utilities.FieldForeign, Params: []interface{}{qd.RequireID}})
+		case covered[qd.RequireID]:
+			details = append(details, utilities.FieldError{Field: field, Code: utilities.FieldRepeated, Params: []interface{}{qd.RequireID}})
+		}
+		covered[qd.RequireID] = true
+	}
+	details = append(details, coverage(quotation.QuotationDetails)...)
+
+	// Every require is quoted or marked as not quoted
+	if requirement.ID != 0 {
+		lines, err := s.store.Requires().ListByRequirement(requirement.ID)
+		if err != nil {
+			return nil, requirement, err
+		}
+		for _, line := range lines {
+			if !covered[line.ID] {
+				details = append(details, utilities.FieldError{Field: "quotation_details", Code: utilities.FieldMissing, Params: []interface{}{line.ID}})
+			}
+		}
+	}
+
+	return details, requirement, nil
+}
+
+// coverage violation of a quotation without any quoted line
+func coverage(lines []models.QuotationDetail) []utilities.FieldError {
+	for _, qd := range lines {
+		if !qd.NotQuoted {
+			return nil
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []utilities.FieldError{{Field: "quotation_details", Code: utilities.FieldRequired}}
+}
+
+func (s *quotationService) Update(userID uint, quotation *models.Quotation) error {
+	current, err := s.get(quotation.ID)
+	if err != nil {
+		return err
+	}
+	requirement, err := s.store.Requirements().Get(current.RequirementID)
+	if err != nil {
+		return notFound(err, current.RequirementID)
+	}
+
+	// Validate data: the deliver date and the lines of the quotation with positive prices
+	lines := make(map[uint]models.QuotationDetail, len(current.QuotationDetails))
+	for _, qd := range current.QuotationDetails {
+		lines[qd.ID] = qd
+	}
+	details := make([]utilities.FieldError, 0)
+	if quotation.DeliverDate.IsZero() {
+		details = append(details, utilities.FieldError{Field: "deliver_date", Code: utilities.FieldRequired})
+	} else if isPastDate(quotation.DeliverDate) {
+		details = append(details, utilities.FieldError{Field: "deliver_date", Code: utilities.FieldPast})
+	}
+	for i, qd := range quotation.QuotationDetails {
+		if _, ok := lines[qd.ID]; !ok {
+			details = append(details, reference(fmt.Sprintf("quotation_details[%d].id", i), qd.ID))
+			continue
+		}
+		for _, fe := range utilities.ValidateStruct(&models.QuotationDetail{UnitPrice: qd.UnitPrice, RequireID: lines[qd.ID].RequireID}) {
+			fe.Field = fmt.Sprintf("quotation_details[%d].%s", i, fe.Field)
+			details = append(details, fe)
+		}
+		if qd.NotQuoted {
+			qd.UnitPrice = decimal.Zero
+		}
+		line := lines[qd.ID]
+		line.UnitPrice, line.NotQuoted = qd.UnitPrice, qd.NotQuoted
+		lines[qd.ID] = line
+	}
+	merged := make([]models.QuotationDetail, 0, len(lines))
+	partial := false
+	for _, qd := range lines {
+		merged = append(merged, qd)
+		partial = partial || qd.NotQuoted
+	}
+	details = append(details, coverage(merged)...)
+	if err := invalid(details); err != nil {
+		return err
+	}
+	if !CanChangeState(requirement.State, models.RequirementQuoted) {
+		return invalidState(requirement.ID)
+	}
+
+	// Only the offer is editable: the provider, the requirement and the winner are changed by
+	// their own processes, the currency and the IGV are the ones of the emission date
+	fields := map[string]interface{}{
+		"deliver_date": quotation.DeliverDate,
+		"observation":  quotation.Observation,
+		"partial":      partial,
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		// The quotations created before the history keep its offer as the first revision
+		if err := revise(tx, userID, quotation.ID); err != nil {
+			return err
+		}
+
+		// Update quotation and the prices of the details
+		if err := tx.Quotations().UpdateFields(quotation.ID, fields); err != nil {
+			return notFound(err, quotation.ID)
+		}
+		for _, qd := range quotation.QuotationDetails {
+			line := lines[qd.ID]
+			fields := map[string]interface{}{"unit_price": line.UnitPrice, "not_quoted": line.NotQuoted}
+			if err := tx.Quotations().UpdateDetailFields(qd.ID, fields); err != nil {
+				return notFound(err, qd.ID)
+			}
+		}
+		if err := revise(tx, userID, quotation.ID); err != nil {
+			return err
+		}
+		if err := recordPrices(tx, quotation.ID, time.Now()); err != nil {
+			return err
+		}
+
+		// Winner level calculate
+		return NewAwardService(tx).Rank(current.RequirementID)
+	})
+}
+
+func (s *quotationService) Approve(id uint) error {
+	quotation, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if !quotation.Pending {
+		return nil
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quotations().UpdateFields(id, map[string]interface{}{"pending": false}); err != nil {
+			return notFound(err, id)
+		}
+		if err := recordPrices(tx, id, time.Now()); err != nil {
+			return err
+		}
+		return NewAwardService(tx).Rank(quotation.RequirementID)
+	})
+}
+
+func (s *quotationService) Delete(id uint) error {
+	quotation, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if err := attached(s.store, models.AttachmentQuotation, id); err != nil {
+		return err
+	}
+	if err := commented(s.store, models.CommentQuotation, id); err != nil {
+		return err
+	}
+
+	// The quotations of a awarded, closed or rejected requirement are kept: the winner has its
+	// commitment in the budget and the purchase order
+	requirement, err := s.store.Requirements().Get(quotation.RequirementID)
+	if err != nil {
+		return notFound(err, quotation.RequirementID)
+	}
+	if !CanChangeState(requirement.State, models.RequirementQuoted) {
+		return invalidState(requirement.ID)
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Revisions().DeleteByQuotation(id); err != nil {
+			return err
+		}
+		if err := tx.Quotations().Delete(id); err != nil {
+			return notFound(err, id)
+		}
+		return NewAwardService(tx).Rank(quotation.RequirementID)
+	})
+}
+
+func (s *quotationService) ComparativeTable(requirementID uint, savings bool) (ComparativeTable, error) {
+	table := ComparativeTable{
+		CTResponseQuotations: make([]CTQuotation, 0),
+		CTResponseRequires:   make([]CTRequire, 0),
+		CTResponseProviders:  make([]CTProvider, 0),
+	}
+
+	isSealed, err := unseal(s.store, requirementID)
+	if err != nil {
+		return table, err
+	}
+	table.Sealed = isSealed
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return table, err
+	}
+	table.Currency = conv.base
+
+	ordered, err := s.store.Requires().ListByRequirement(requirementID)
+	if err != nil {
+		return table, err
+	}
+	lines := make(map[uint]repository.RequireLine, len(ordered))
+	for _, line := range ordered {
+		lines[line.ID] = line
+		table.CTResponseRequires = append(table.CTResponseRequires, CTRequire{
+			ID:          line.ID,
+			Amount:      line.Amount,
+			Name:        line.ProductName,
+			UnitMeasure: line.UnitMeasure,
+			Exempt:      line.Exempt,
+			Observation: line.Observation,
+		})
+	}
+
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return table, err
+	}
+	sequence := uint(0)
+	for _, q := range quotations {
+		if q.Pending {
+			continue
+		}
+		sequence++
+		provider, err := s.store.Providers().Get(q.ProviderID)
+		if err != nil && err != repository.ErrNotFound {
+			return table, err
+		}
+		column := CTProvider{
+			Name:        provider.Name,
+			Manager:     provider.Manager,
+			DeliverDate: q.DeliverDate,
+			Partial:     q.Partial,
+			Currency:    conv.currency(q),
+			TaxIncluded: taxIncluded(q),
+		}
+		if !isSealed {
+			if column.Rate, err = conv.rate(q); err != nil {
+				return table, err
+			}
+			if column.Taxes, err = taxes(q, lines); err != nil {
+				return table, err
+			}
+		}
+
+		// Prices of the first offer
+		var first map[uint]decimal.Decimal
+		if savings && !isSealed {
+			if first, err = s.firstOffer(q); err != nil {
+				return table, err
+			}
+			offer := q
+			offer.QuotationDetails = make([]models.QuotationDetail, 0, len(q.QuotationDetails))
+			for _, qd := range q.QuotationDetails {
+				qd.UnitPrice = first[qd.ID]
+				offer.QuotationDetails = append(offer.QuotationDetails, qd)
+			}
+			amounts, err := taxes(offer, lines)
+			if err != nil {
+				return table, err
+			}
+			column.FirstTotal = amounts.Total
+			column.Savings = column.FirstTotal.Sub(column.Total)
+		}
+
+		for _, qd := range q.QuotationDetails {
+			if isSealed {
+				qd.UnitPrice = decimal.Zero
+			}
+			converted, err := netPrice(q, qd.UnitPrice, lines[qd.RequireID].Exempt).CheckedMul(column.Rate)
+			if err != nil {
+				return table, amountRange(err)
+			}
+			price := CTQuotation{
+				QuotationID: q.ID,
+				UnitPrice:   qd.UnitPrice,
+				Converted:   converted,
+				NotQuoted:   qd.NotQuoted,
+				Sequence:    sequence,
+			}
+			if first != nil {
+				price.FirstPrice = first[qd.ID]
+			}
+			table.CTResponseQuotations = append(table.CTResponseQuotations, price)
+		}
+		table.CTResponseProviders = append(table.CTResponseProviders, column)
+	}
+
+	return table, nil
+}
+
+// firstOffer unit prices of the first revision by quotation detail, the current prices
+// when the quotation has no revisions
+func (s *quotationService) firstOffer(quotation models.Quotation) (map[uint]decimal.Decimal, error) {
+	revisions, err := s.store.Revisions().ListByQuotation(quotation.ID)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[uint]decimal.Decimal, len(quotation.QuotationDetails))
+	for _, qd := range quotation.QuotationDetails {
+		prices[qd.ID] = qd.UnitPrice
+	}
+	if len(revisions) > 0 {
+		for _, rd := range revisions[0].RevisionDetails {
+			prices[rd.QuotationDetailID] = rd.UnitPrice
+		}
+	}
+	return prices, nil
+}
+
+func (s *quotationService) PurchaseOrder(requirementID uint) (PurchaseOrder, error) {
+	order := PurchaseOrder{PurchaseOrder: make([]PurchaseLine, 0)}
+
+	quotations, err := s.store.Quotations().ListByRequirement(requirementID)
+	if err != nil {
+		return order, err
+	}
+	var winner *models.Quotation
+	for k := range quotations {
+		if quotations[k].Winner {
+			winner = &quotations[k]
+			break
+		}
+	}
+	if winner == nil || len(winner.QuotationDetails) == 0 {
+		return order, utilities.NewError(http.StatusNotFound, utilities.ErrNoWinner, requirementID)
+	}
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return order, err
+	}
+	order.Currency = conv.currency(*winner)
+	order.TaxIncluded = taxIncluded(*winner)
+	order.TaxRate = winner.TaxRate
+
+	lines, err := requires(s.store, requirementID)
+	if err != nil {
+		return order, err
+	}
+	for _, qd := range winner.QuotationDetails {
+		if qd.NotQuoted {
+			continue
+		}
+		line := lines[qd.RequireID]
+		total, err := lineTotal(qd.UnitPrice, line.Amount)
+		if err != nil {
+			return order, err
+		}
+		order.PurchaseOrder = append(order.PurchaseOrder, PurchaseLine{
+			Amount:      line.Amount.String(),
+			UnitMeasure: line.UnitMeasure,
+			Description: line.ProductName,
+			UnitPrice:   qd.UnitPrice,
+			Total:       total,
+			Exempt:      line.Exempt,
+		})
+	}
+	if order.Taxes, err = taxes(*winner, lines); err != nil {
+		return order, err
+	}
+
+	if order.Provider, err = s.store.Providers().Get(winner.ProviderID); err != nil {
+		return order, notFound(err, winner.ProviderID)
+	}
+	if order.Requirement, err = s.store.Requirements().Get(requirementID); err != nil {
+		return order, notFound(err, requirementID)
+	}
+	return order, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestQuotationLimit(t *testing.T) {
+	f := newFixture(t)
+	f.quote(0, 1, 1)
+	f.quote(1, 2, 2)
+	f.quote(2, 3, 3)
+
+	quotation := f.newQuotation(0, 4, 4)
+	err := NewQuotationService(f.store).Create(f.user.ID, &quotation)
+	expectError(t, err, http.StatusConflict, utilities.ErrQuotationLimit)
+}
+
+func TestQuotationValidation(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+
+	// Disabled provider
+	f.must(f.store.Providers().UpdateFields(f.providers[0].ID, map[string]interface{}{"state": false}))
+	quotation := f.newQuotation(0, 1, 1)
+	quotation.QuotationDetails[1].RequireID = 999
+	err := quotations.Create(f.user.ID, &quotation)
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	fields := map[string]string{}
+	for _, d := range err.(*utilities.Error).Details {
+		fields[d.Field] = d.Code
+	}
+	if fields["provider_id"] != utilities.FieldState || fields["quotation_details[1].require_id"] != utilities.FieldRef {
+		t.Fatalf("unexpected details %v", fields)
+	}
+
+	// Negative prices
+	q := f.quote(1, 1, 1)
+	q.QuotationDetails[0].UnitPrice = dec(-1)
+	err = quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: q.DeliverDate, QuotationDetails: q.QuotationDetails})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+}
+
+func TestQuotationUpdateFields(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+	q := f.quote(0, 5, 5)
+
+	// The winner, the provider and the requirement are not editable
+	deliver := time.Now().AddDate(0, 0, 10)
+	f.must(quotations.Update(f.user.ID, &models.Quotation{
+		ID: q.ID, DeliverDate: deliver, Observation: "Delivered in the store", Winner: true,
+		RequirementID: 999, ProviderID: f.providers[1].ID, QuotationDetails: q.QuotationDetails,
+	}))
+	updated, err := f.store.Quotations().Get(q.ID)
+	f.must(err)
+	if updated.Winner || updated.RequirementID != f.requirement.ID || updated.ProviderID != f.providers[0].ID ||
+		updated.Observation != "Delivered in the store" || updated.DeliverDate.Day() != deliver.Day() {
+		t.Fatalf("unexpected quotation %+v", updated)
+	}
+
+	// Past deliver date
+	err = quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: time.Now().AddDate(0, 0, -2), QuotationDetails: q.QuotationDetails})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+	if !hasField(err.(*utilities.Error).Details, "deliver_date") {
+		t.Fatalf("expected deliver date violation, got %+v", err)
+	}
+
+	// The requirement does not accept quotations
+	f.must(f.store.Requirements().UpdateFields(f.requirement.ID, map[string]interface{}{"state": models.RequirementClosed}))
+	err = quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: deliver, QuotationDetails: q.QuotationDetails})
+	expectError(t, err, http.StatusConflict, utilities.ErrInvalidState)
+}
+
+func TestQuotationAmountRange(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+	f.must(f.db.Model(&models.Require{}).Where("requirement_id = ?", f.requirement.ID).UpdateColumn("amount", dec(9999999)).Error)
+
+	// The largest prices of all the lines do not fit in the total
+	largest := f.newQuotation(0, 9999999, 9999999)
+	err := quotations.Create(f.user.ID, &largest)
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrAmountRange)
+	count, err := f.store.Quotations().Count(f.requirement.ID)
+	f.must(err)
+	if count != 0 {
+		t.Fatalf("expected no quotations, got %d", count)
+	}
+
+	// A single line of the largest price fits
+	single := f.newQuotation(0, 9999999, 0)
+	single.QuotationDetails[1].NotQuoted = true
+	f.must(quotations.Create(f.user.ID, &single))
+	list, err := quotations.List(f.requirement.ID)
+	f.must(err)
+	if list[0].Total.String() != "99999980000001" {
+		t.Fatalf("unexpected total %+v", list[0])
+	}
+}
+
+func TestQuotationDeleteReranks(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+	cheapest := f.quote(0, 1, 1)
+	other := f.quote(1, 5, 5)
+
+	f.must(quotations.Delete(cheapest.ID))
+	q, err := f.store.Quotations().Get(other.ID)
+	f.must(err)
+	if q.WinnerLevel != 1 {
+		t.Fatalf("expected winner level 1, got %d", q.WinnerLevel)
+	}
+
+	// The winner of a awarded requirement is kept
+	_, err = NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, other.ID)
+	f.must(err)
+	expectError(t, quotations.Delete(other.ID), http.StatusConflict, utilities.ErrInvalidState)
+	_, err = quotations.PurchaseOrder(f.requirement.ID)
+	f.must(err)
+
+	table, err := quotations.ComparativeTable(f.requirement.ID, false)
+	f.must(err)
+	if len(table.CTResponseProviders) != 1 || len(table.CTResponseQuotations) != 2 || len(table.CTResponseRequires) != 2 {
+		t.Fatalf("unexpected comparative table %+v", table)
+	}
+}
+
+func TestQuotationCoverage(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+
+	other := models.Requirement{Name: "Other", CostCenterID: f.costCenter.ID, Requires: []models.Require{{Amount: dec(1), ProductID: f.requirement.Requires[0].ProductID}}}
+	f.must(NewRequirementService(f.store).Create(f.user.ID, &other))
+
+	// Missing line, require of other requirement and repeated require
+	quotation := f.newQuotation(0, 1)
+	quotation.QuotationDetails = append(quotation.QuotationDetails,
+		models.QuotationDetail{UnitPrice: dec(1), RequireID: other.Requires[0].ID},
+		models.QuotationDetail{UnitPrice: dec(1), RequireID: f.requirement.Requires[0].ID},
+	)
+	err := quotations.Create(f.user.ID, &quotation)
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	fields := map[string]string{}
+	for _, d := range err.(*utilities.Error).Details {
+		fields[d.Field] = d.Code
+	}
+	if fields["quotation_details"] != utilities.FieldMissing ||
+		fields["quotation_details[1].require_id"] != utilities.FieldForeign ||
+		fields["quotation_details[2].require_id"] != utilities.FieldRepeated {
+		t.Fatalf("unexpected details %v", fields)
+	}
+	if count, _ := f.store.Quotations().Count(f.requirement.ID); count != 0 {
+		t.Fatalf("expected no quotations, got %d", count)
+	}
+}
+
+func TestQuotationNotQuotedLines(t *testing.T) {
+	f := newFixture(t)
+	quotations := NewQuotationService(f.store)
+
+	// The partial quotation is cheaper but ranked after the complete one
+	partial := f.newQuotation(0, 1, 99)
+	partial.QuotationDetails[1].NotQuoted = true
+	f.must(quotations.Create(f.user.ID, &partial))
+	complete := f.quote(1, 5, 10)
+
+	list, err := quotations.List(f.requirement.ID)
+	f.must(err)
+	if list[0].ID != complete.ID || list[1].ID != partial.ID || !list[1].Partial || list[1].Summation != dec(10) {
+		t.Fatalf("unexpected ranking %+v", list)
+	}
+
+	// Every line not quoted
+	q, err := f.store.Quotations().Get(complete.ID)
+	f.must(err)
+	for k := range q.QuotationDetails {
+		q.QuotationDetails[k].NotQuoted = true
+	}
+	err = quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: q.DeliverDate, QuotationDetails: q.QuotationDetails})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	// Quoting the missing line makes the quotation complete
+	partial.QuotationDetails[1].NotQuoted = false
+	partial.QuotationDetails[1].UnitPrice = dec(1)
+	f.must(quotations.Update(f.user.ID, &models.Quotation{ID: partial.ID, DeliverDate: partial.DeliverDate, QuotationDetails: partial.QuotationDetails}))
+	q, err = f.store.Quotations().Get(partial.ID)
+	f.must(err)
+	if q.Partial || q.WinnerLevel != 1 {
+		t.Fatalf("expected complete winner quotation, got %+v", q)
+	}
+}
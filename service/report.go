@@ -0,0 +1,137 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/repository"
+)
+
+// AwardedLine awarded quotation with its total in the currency of the quotation and in the base currency
+type AwardedLine struct {
+	QuotationID     uint            `json:"quotation_id"`
+	RequirementID   uint            `json:"requirement_id"`
+	RequirementName string          `json:"requirement_name"`
+	ProviderID      uint            `json:"provider_id"`
+	ProviderName    string          `json:"provider_name"`
+	EmissionDate    time.Time       `json:"emission_date"`
+	Currency        string          `json:"currency"`
+	Subtotal        decimal.Decimal `json:"subtotal"` // Without IGV
+	Tax             decimal.Decimal `json:"tax"`
+	Total           decimal.Decimal `json:"total"`
+	Rate            decimal.Decimal `json:"rate"`
+	BaseCurrency    string          `json:"base_currency"`
+	Converted       decimal.Decimal `json:"converted"`
+}
+
+// ProviderAmount total awarded to a provider in the base currency
+type ProviderAmount struct {
+	ID       uint            `json:"-"`
+	Name     string          `json:"name"`
+	Currency string          `json:"currency"`
+	Top      decimal.Decimal `json:"top"`
+}
+
+// ReportService reports of the awarded amounts converted to the base currency
+type ReportService interface {
+	Awarded() ([]AwardedLine, error)
+	// TopProviderAmounts providers with the greater amounts awarded, limit = 0 means all
+	TopProviderAmounts(limit int) ([]ProviderAmount, error)
+}
+
+type reportService struct {
+	store repository.Store
+}
+
+// NewReportService create the report service over the store
+func NewReportService(store repository.Store) ReportService {
+	return &reportService{store: store}
+}
+
+func (s *reportService) Awarded() ([]AwardedLine, error) {
+	quotations, err := s.store.Quotations().ListWinners()
+	if err != nil {
+		return nil, err
+	}
+	conv, err := newConverter(s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]AwardedLine, 0, len(quotations))
+	for _, q := range quotations {
+		requirement, err := s.store.Requirements().Get(q.RequirementID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		provider, err := s.store.Providers().Get(q.ProviderID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		lines, err := requires(s.store, q.RequirementID)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := conv.rate(q)
+		if err != nil {
+			return nil, err
+		}
+		amounts, err := taxes(q, lines)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convert(amounts.Total, rate)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, AwardedLine{
+			QuotationID:     q.ID,
+			RequirementID:   q.RequirementID,
+			RequirementName: requirement.Name,
+			ProviderID:      q.ProviderID,
+			ProviderName:    provider.Name,
+			EmissionDate:    q.EmissionDate,
+			Currency:        conv.currency(q),
+			Subtotal:        amounts.Subtotal,
+			Tax:             amounts.Tax,
+			Total:           amounts.Total,
+			Rate:            rate,
+			BaseCurrency:    conv.base,
+			Converted:       converted,
+		})
+	}
+	return report, nil
+}
+
+func (s *reportService) TopProviderAmounts(limit int) ([]ProviderAmount, error) {
+	awarded, err := s.Awarded()
+	if err != nil {
+		return nil, err
+	}
+	byProvider := make(map[uint]*ProviderAmount)
+	tops := make([]*ProviderAmount, 0)
+	for _, line := range awarded {
+		top, ok := byProvider[line.ProviderID]
+		if !ok {
+			top = &ProviderAmount{ID: line.ProviderID, Name: line.ProviderName, Currency: line.BaseCurrency}
+			byProvider[line.ProviderID] = top
+			tops = append(tops, top)
+		}
+		if top.Top, err = top.Top.CheckedAdd(line.Converted); err != nil {
+			return nil, amountRange(err)
+		}
+	}
+	sort.SliceStable(tops, func(i, j int) bool {
+		return tops[i].Top.Cmp(tops[j].Top) > 0
+	})
+
+	amounts := make([]ProviderAmount, 0, len(tops))
+	for k, top := range tops {
+		if limit > 0 && k >= limit {
+			break
+		}
+		amounts = append(amounts, *top)
+	}
+	return amounts, nil
+}
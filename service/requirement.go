@@ -0,0 +1,280 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// transitions states reachable from each state of a requirement
+var transitions = map[string][]string{
+	models.RequirementCreated:  {models.RequirementQuoted, models.RequirementRejected},
+	models.RequirementQuoted:   {models.RequirementQuoted, models.RequirementRejected, models.RequirementAwarded},
+	models.RequirementAwarded:  {models.RequirementAwarded, models.RequirementClosed},
+	models.RequirementRejected: {},
+	models.RequirementClosed:   {},
+}
+
+// CanChangeState check if the requirement can pass from the state from to the state to
+func CanChangeState(from string, to string) bool {
+	for _, state := range transitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// changeState move the requirement to the state when the transition is allowed, the change
+// made by the user is recorded in the activity of the requirement
+func changeState(store repository.Store, requirement models.Requirement, state string, userID uint) error {
+	if !CanChangeState(requirement.State, state) {
+		return invalidState(requirement.ID)
+	}
+	if requirement.State == state {
+		return nil
+	}
+	if err := store.Requirements().UpdateFields(requirement.ID, map[string]interface{}{"state": state}); err != nil {
+		return notFound(err, requirement.ID)
+	}
+	return store.Requirements().AddStateChange(&models.StateChange{
+		RequirementID: requirement.ID,
+		FromState:     requirement.State,
+		ToState:       state,
+		UserID:        userID,
+	})
+}
+
+// RequirementService requirements of products and its requires
+type RequirementService interface {
+	List(page repository.Page) ([]models.Requirement, uint, error)
+	Get(id uint) (models.Requirement, error)
+	Create(userID uint, requirement *models.Requirement) error
+	Update(requirement *models.Requirement) error
+	Reject(userID uint, id uint) error
+	Close(userID uint, id uint) error
+	Delete(id uint) error
+
+	Requires(requirementID uint) ([]repository.RequireLine, error)
+	DeleteRequire(id uint) error
+}
+
+type requirementService struct {
+	store repository.Store
+}
+
+// NewRequirementService create the requirement service over the store
+func NewRequirementService(store repository.Store) RequirementService {
+	return &requirementService{store: store}
+}
+
+func (s *requirementService) List(page repository.Page) ([]models.Requirement, uint, error) {
+	return s.store.Requirements().List(page)
+}
+
+func (s *requirementService) Get(id uint) (models.Requirement, error) {
+	requirement, err := s.store.Requirements().Get(id)
+	if err != nil {
+		return requirement, notFound(err, id)
+	}
+	requirement.Attachments, err = attachments(s.store, models.AttachmentRequirement, id)
+	return requirement, err
+}
+
+func (s *requirementService) Create(userID uint, requirement *models.Requirement) error {
+	// Validation
+	if len(requirement.Requires) == 0 {
+		e := utilities.NewValidationError(utilities.FieldError{Field: "requires", Code: utilities.FieldRequired})
+		e.Key = utilities.ErrRequirementFields
+		return e
+	}
+	details, err := s.validate(*requirement)
+	if err != nil {
+		return err
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	// Default values
+	requirement.UserID = userID
+	requirement.EmissionDate = time.Now()
+	requirement.State = models.RequirementCreated
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Requirements().Create(requirement); err != nil {
+			return err
+		}
+		return tx.Requirements().AddStateChange(&models.StateChange{
+			RequirementID: requirement.ID,
+			ToState:       requirement.State,
+			UserID:        userID,
+		})
+	})
+}
+
+// validate return all the violations of a new requirement: struct tags, expiration date,
+// active cost center and active products of the requires
+func (s *requirementService) validate(requirement models.Requirement) ([]utilities.FieldError, error) {
+	details := utilities.ValidateStruct(&requirement)
+
+	if isPastDate(requirement.ExpirationDate) {
+		details = append(details, utilities.FieldError{Field: "expiration_date", Code: utilities.FieldPast})
+	}
+
+	costCenter, err := s.costCenter(requirement)
+	if err != nil {
+		return nil, err
+	}
+	details = append(details, costCenter...)
+
+	for i, rq := range requirement.Requires {
+		if rq.ProductID == 0 {
+			continue
+		}
+		field := fmt.Sprintf("requires[%d].product_id", i)
+		product, err := s.store.Products().Get(rq.ProductID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference(field, rq.ProductID))
+		case err != nil:
+			return nil, err
+		case !product.State:
+			details = append(details, inactive(field, rq.ProductID))
+		}
+	}
+
+	return details, nil
+}
+
+// costCenter violations of the cost center of the requirement: it exists and it is active
+func (s *requirementService) costCenter(requirement models.Requirement) ([]utilities.FieldError, error) {
+	if requirement.CostCenterID == 0 {
+		return nil, nil
+	}
+	costCenter, err := s.store.CostCenters().Get(requirement.CostCenterID)
+	switch {
+	case err == repository.ErrNotFound:
+		return []utilities.FieldError{reference("cost_center_id", requirement.CostCenterID)}, nil
+	case err != nil:
+		return nil, err
+	case !costCenter.State:
+		return []utilities.FieldError{inactive("cost_center_id", requirement.CostCenterID)}, nil
+	}
+	return nil, nil
+}
+
+func (s *requirementService) Update(requirement *models.Requirement) error {
+	details := utilities.ValidateStruct(requirement)
+	if isPastDate(requirement.ExpirationDate) {
+		details = append(details, utilities.FieldError{Field: "expiration_date", Code: utilities.FieldPast})
+	}
+	costCenter, err := s.costCenter(*requirement)
+	if err != nil {
+		return err
+	}
+	if err := invalid(append(details, costCenter...)); err != nil {
+		return err
+	}
+
+	// The state and the bid opening only change with the operations of the requirement
+	requirement.State = ""
+	requirement.OpenedAt = nil
+	requirement.OpenedBy = 0
+
+	current, err := s.Get(requirement.ID)
+	if err != nil {
+		return err
+	}
+	count, err := s.store.Quotations().Count(requirement.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		// The bids received openly can not be sealed
+		if requirement.Sealed && !current.Sealed {
+			return invalidState(requirement.ID)
+		}
+		// The expiration opens the sealed bids, it is fixed once they are received. Only the
+		// non zero fields are updated, so the sealed mode can not be removed
+		if current.Sealed && !requirement.ExpirationDate.IsZero() && !requirement.ExpirationDate.Equal(current.ExpirationDate) {
+			return invalidState(requirement.ID)
+		}
+	}
+	return notFound(s.store.Requirements().Update(requirement), requirement.ID)
+}
+
+func (s *requirementService) Reject(userID uint, id uint) error {
+	requirement, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return changeState(s.store, requirement, models.RequirementRejected, userID)
+}
+
+func (s *requirementService) Close(userID uint, id uint) error {
+	requirement, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	// The requirement is received, its commitment is consumed
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := changeState(tx, requirement, models.RequirementClosed, userID); err != nil {
+			return err
+		}
+		return consume(tx, id)
+	})
+}
+
+func (s *requirementService) Delete(id uint) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+
+	// Requirements with quotations can only be rejected
+	count, err := s.store.Quotations().Count(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return utilities.NewError(http.StatusConflict, utilities.ErrInUse)
+	}
+	if err := attached(s.store, models.AttachmentRequirement, id); err != nil {
+		return err
+	}
+	if err := commented(s.store, models.CommentRequirement, id); err != nil {
+		return err
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Requires().DeleteByRequirement(id); err != nil {
+			return err
+		}
+		return notFound(tx.Requirements().Delete(id), id)
+	})
+}
+
+func (s *requirementService) Requires(requirementID uint) ([]repository.RequireLine, error) {
+	return s.store.Requires().ListByRequirement(requirementID)
+}
+
+func (s *requirementService) DeleteRequire(id uint) error {
+	require, err := s.store.Requires().Get(id)
+	if err != nil {
+		return notFound(err, id)
+	}
+	requirement, err := s.Get(require.RequirementID)
+	if err != nil {
+		return err
+	}
+
+	// The lines can not change once the requirement was quoted
+	if requirement.State != models.RequirementCreated {
+		return invalidState(requirement.ID)
+	}
+	return notFound(s.store.Requires().Delete(id), id)
+}
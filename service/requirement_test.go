@@ -0,0 +1,140 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestRequirementTransitions(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{models.RequirementCreated, models.RequirementQuoted, true},
+		{models.RequirementCreated, models.RequirementAwarded, false},
+		{models.RequirementCreated, models.RequirementClosed, false},
+		{models.RequirementQuoted, models.RequirementRejected, true},
+		{models.RequirementQuoted, models.RequirementAwarded, true},
+		{models.RequirementAwarded, models.RequirementClosed, true},
+		{models.RequirementAwarded, models.RequirementQuoted, false},
+		{models.RequirementRejected, models.RequirementQuoted, false},
+		{models.RequirementClosed, models.RequirementAwarded, false},
+	}
+	for _, tt := range tests {
+		if got := CanChangeState(tt.from, tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.allowed, got)
+		}
+	}
+}
+
+func TestRequirementLifecycle(t *testing.T) {
+	f := newFixture(t)
+	requirements := NewRequirementService(f.store)
+
+	if state := f.state(); state != models.RequirementCreated {
+		t.Fatalf("expected state %s, got %s", models.RequirementCreated, state)
+	}
+
+	// Close is only allowed after the award
+	expectError(t, requirements.Close(f.user.ID, f.requirement.ID), http.StatusConflict, utilities.ErrInvalidState)
+
+	f.quote(0, 5, 10)
+	if state := f.state(); state != models.RequirementQuoted {
+		t.Fatalf("expected state %s, got %s", models.RequirementQuoted, state)
+	}
+
+	_, err := NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	f.must(requirements.Close(f.user.ID, f.requirement.ID))
+	if state := f.state(); state != models.RequirementClosed {
+		t.Fatalf("expected state %s, got %s", models.RequirementClosed, state)
+	}
+
+	// Closed requirements do not receive quotations
+	quotation := f.newQuotation(1, 1, 1)
+	expectError(t, NewQuotationService(f.store).Create(f.user.ID, &quotation), http.StatusConflict, utilities.ErrInvalidState)
+	expectError(t, requirements.Reject(f.user.ID, f.requirement.ID), http.StatusConflict, utilities.ErrInvalidState)
+}
+
+func TestRequirementReject(t *testing.T) {
+	f := newFixture(t)
+	requirements := NewRequirementService(f.store)
+
+	f.must(requirements.Reject(f.user.ID, f.requirement.ID))
+	if state := f.state(); state != models.RequirementRejected {
+		t.Fatalf("expected state %s, got %s", models.RequirementRejected, state)
+	}
+	expectError(t, requirements.Reject(f.user.ID, 999), http.StatusNotFound, utilities.ErrNotFound)
+}
+
+func TestRequirementDelete(t *testing.T) {
+	f := newFixture(t)
+	requirements := NewRequirementService(f.store)
+
+	f.quote(0, 5, 10)
+	expectError(t, requirements.Delete(f.requirement.ID), http.StatusConflict, utilities.ErrInUse)
+	expectError(t, requirements.DeleteRequire(f.requirement.Requires[0].ID), http.StatusConflict, utilities.ErrInvalidState)
+
+	other := models.Requirement{
+		Name:         "Cleaning",
+		CostCenterID: f.costCenter.ID,
+		Requires:     []models.Require{{Amount: dec(1), ProductID: f.requirement.Requires[0].ProductID}},
+	}
+	f.must(requirements.Create(f.user.ID, &other))
+	f.must(requirements.Delete(other.ID))
+	lines, err := requirements.Requires(other.ID)
+	f.must(err)
+	if len(lines) != 0 {
+		t.Fatalf("expected the requires deleted, got %d", len(lines))
+	}
+}
+
+func TestRequirementValidation(t *testing.T) {
+	f := newFixture(t)
+	requirements := NewRequirementService(f.store)
+
+	err := requirements.Create(f.user.ID, &models.Requirement{Name: "Empty"})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	err = requirements.Create(f.user.ID, &models.Requirement{
+		Name:           "Past",
+		ExpirationDate: time.Now().AddDate(0, 0, -2),
+		Requires:       []models.Require{{Amount: dec(1), ProductID: 999}},
+	})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+	fields := map[string]string{}
+	for _, d := range err.(*utilities.Error).Details {
+		fields[d.Field] = d.Code
+	}
+	if fields["expiration_date"] != utilities.FieldPast || fields["requires[0].product_id"] != utilities.FieldRef ||
+		fields["cost_center_id"] != utilities.FieldRequired {
+		t.Fatalf("unexpected details %v", fields)
+	}
+}
+
+func TestRequirementUpdateKeepsRequires(t *testing.T) {
+	f := newFixture(t)
+	q := f.quote(0, 5, 10)
+
+	// The requires change with their own operations, the update ignores them
+	requires := f.requirement.Requires
+	requires[0].Amount = dec(999)
+	requires = append(requires, models.Require{Amount: dec(1), ProductID: requires[0].ProductID})
+	f.must(NewRequirementService(f.store).Update(&models.Requirement{
+		ID: f.requirement.ID, Name: "Renamed", CostCenterID: f.costCenter.ID, Requires: requires,
+	}))
+	lines, err := NewRequirementService(f.store).Requires(f.requirement.ID)
+	f.must(err)
+	if len(lines) != 2 || lines[0].Amount != dec(10) {
+		t.Fatalf("unexpected requires %+v", lines)
+	}
+	list, err := NewQuotationService(f.store).List(f.requirement.ID)
+	f.must(err)
+	if list[0].ID != q.ID || list[0].Summation != dec(70) {
+		t.Fatalf("unexpected quotation %+v", list[0])
+	}
+}
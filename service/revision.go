@@ -0,0 +1,210 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// RevisionLine price of a require in the two revisions compared
+type RevisionLine struct {
+	QuotationDetailID uint            `json:"quotation_detail_id"`
+	RequireID         uint            `json:"require_id"`
+	ProductName       string          `json:"product_name"`
+	Amount            decimal.Decimal `json:"amount"`
+	OldPrice          decimal.Decimal `json:"old_price"`
+	NewPrice          decimal.Decimal `json:"new_price"`
+	NotQuoted         bool            `json:"not_quoted"` // Not quoted in the new revision
+	Difference        decimal.Decimal `json:"difference"` // New price minus old price
+}
+
+// RevisionDiff changes of the offer between two revisions of a quotation
+type RevisionDiff struct {
+	QuotationID    uint            `json:"quotation_id"`
+	From           uint            `json:"from"`
+	To             uint            `json:"to"`
+	OldDeliverDate time.Time       `json:"old_deliver_date"`
+	NewDeliverDate time.Time       `json:"new_deliver_date"`
+	OldObservation string          `json:"old_observation"`
+	NewObservation string          `json:"new_observation"`
+	OldTotal       decimal.Decimal `json:"old_total"` // With IGV
+	NewTotal       decimal.Decimal `json:"new_total"`
+
+	Lines []RevisionLine `json:"lines"`
+}
+
+// RevisionService history of the offers of the quotations
+type RevisionService interface {
+	List(quotationID uint) ([]models.QuotationRevision, error)
+	// Diff compare two revisions by number, from = 0 is the first offer and to = 0 the last
+	Diff(quotationID uint, from uint, to uint) (RevisionDiff, error)
+}
+
+type revisionService struct {
+	store repository.Store
+}
+
+// NewRevisionService create the revision service over the store
+func NewRevisionService(store repository.Store) RevisionService {
+	return &revisionService{store: store}
+}
+
+// revise save the current offer of the quotation as a new revision when it changed since
+// the last revision. The first revision is the offer of the creator of the quotation
+func revise(store repository.Store, userID uint, quotationID uint) error {
+	quotation, err := store.Quotations().Get(quotationID)
+	if err != nil {
+		return notFound(err, quotationID)
+	}
+	revisions, err := store.Revisions().ListByQuotation(quotationID)
+	if err != nil {
+		return err
+	}
+
+	revision := models.QuotationRevision{
+		Number:          1,
+		DeliverDate:     quotation.DeliverDate,
+		Observation:     quotation.Observation,
+		QuotationID:     quotation.ID,
+		UserID:          userID,
+		RevisionDetails: make([]models.RevisionDetail, 0, len(quotation.QuotationDetails)),
+	}
+	for _, qd := range quotation.QuotationDetails {
+		revision.RevisionDetails = append(revision.RevisionDetails, models.RevisionDetail{
+			UnitPrice:         qd.UnitPrice,
+			NotQuoted:         qd.NotQuoted,
+			QuotationDetailID: qd.ID,
+			RequireID:         qd.RequireID,
+		})
+	}
+
+	if len(revisions) == 0 {
+		revision.CreatedAt = quotation.CreatedAt
+		revision.UserID = quotation.UserID
+	} else {
+		last := revisions[len(revisions)-1]
+		if sameOffer(last, revision) {
+			return nil
+		}
+		revision.Number = last.Number + 1
+	}
+	return store.Revisions().Create(&revision)
+}
+
+// sameOffer check if the two revisions have the same prices, deliver date and observation
+func sameOffer(a models.QuotationRevision, b models.QuotationRevision) bool {
+	if !a.DeliverDate.Equal(b.DeliverDate) || a.Observation != b.Observation ||
+		len(a.RevisionDetails) != len(b.RevisionDetails) {
+		return false
+	}
+	lines := make(map[uint]models.RevisionDetail, len(a.RevisionDetails))
+	for _, rd := range a.RevisionDetails {
+		lines[rd.QuotationDetailID] = rd
+	}
+	for _, rd := range b.RevisionDetails {
+		line, ok := lines[rd.QuotationDetailID]
+		if !ok || line.UnitPrice != rd.UnitPrice || line.NotQuoted != rd.NotQuoted {
+			return false
+		}
+	}
+	return true
+}
+
+// revisions of the quotation, the prices are hidden while the bids are sealed
+func (s *revisionService) revisions(quotationID uint) (models.Quotation, []models.QuotationRevision, error) {
+	quotation, err := s.store.Quotations().Get(quotationID)
+	if err != nil {
+		return quotation, nil, notFound(err, quotationID)
+	}
+	isSealed, err := unseal(s.store, quotation.RequirementID)
+	if err != nil {
+		return quotation, nil, err
+	}
+	if isSealed {
+		return quotation, nil, utilities.NewError(http.StatusConflict, utilities.ErrSealed, quotation.RequirementID)
+	}
+	revisions, err := s.store.Revisions().ListByQuotation(quotationID)
+	return quotation, revisions, err
+}
+
+func (s *revisionService) List(quotationID uint) ([]models.QuotationRevision, error) {
+	_, revisions, err := s.revisions(quotationID)
+	return revisions, err
+}
+
+func (s *revisionService) Diff(quotationID uint, from uint, to uint) (RevisionDiff, error) {
+	quotation, revisions, err := s.revisions(quotationID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	if len(revisions) == 0 {
+		return RevisionDiff{}, utilities.NewNotFoundError(quotationID)
+	}
+	if from == 0 {
+		from = revisions[0].Number
+	}
+	if to == 0 {
+		to = revisions[len(revisions)-1].Number
+	}
+	var old, current *models.QuotationRevision
+	for k := range revisions {
+		if revisions[k].Number == from {
+			old = &revisions[k]
+		}
+		if revisions[k].Number == to {
+			current = &revisions[k]
+		}
+	}
+	if old == nil {
+		return RevisionDiff{}, utilities.NewNotFoundError(from)
+	}
+	if current == nil {
+		return RevisionDiff{}, utilities.NewNotFoundError(to)
+	}
+
+	lines, err := requires(s.store, quotation.RequirementID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	diff := RevisionDiff{
+		QuotationID:    quotationID,
+		From:           from,
+		To:             to,
+		OldDeliverDate: old.DeliverDate,
+		NewDeliverDate: current.DeliverDate,
+		OldObservation: old.Observation,
+		NewObservation: current.Observation,
+		Lines:          make([]RevisionLine, 0, len(current.RevisionDetails)),
+	}
+	oldAmounts, err := taxes(withPrices(quotation, old.RevisionDetails), lines)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	newAmounts, err := taxes(withPrices(quotation, current.RevisionDetails), lines)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	diff.OldTotal, diff.NewTotal = oldAmounts.Total, newAmounts.Total
+	oldPrices := make(map[uint]decimal.Decimal, len(old.RevisionDetails))
+	for _, rd := range old.RevisionDetails {
+		oldPrices[rd.QuotationDetailID] = rd.UnitPrice
+	}
+	for _, rd := range current.RevisionDetails {
+		line := lines[rd.RequireID]
+		diff.Lines = append(diff.Lines, RevisionLine{
+			QuotationDetailID: rd.QuotationDetailID,
+			RequireID:         rd.RequireID,
+			ProductName:       line.ProductName,
+			Amount:            line.Amount,
+			OldPrice:          oldPrices[rd.QuotationDetailID],
+			NewPrice:          rd.UnitPrice,
+			NotQuoted:         rd.NotQuoted,
+			Difference:        rd.UnitPrice.Sub(oldPrices[rd.QuotationDetailID]),
+		})
+	}
+	return diff, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+)
+
+func TestRevisionHistory(t *testing.T) {
+	f := newFixture(t)
+	q := f.quote(0, 5, 10)
+	quotations := NewQuotationService(f.store)
+
+	// The same offer does not create a revision
+	f.must(quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: q.DeliverDate, QuotationDetails: q.QuotationDetails}))
+	q.QuotationDetails[0].UnitPrice = dec(4)
+	f.must(quotations.Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: q.DeliverDate, Observation: "Improved offer", QuotationDetails: q.QuotationDetails}))
+
+	revisions := NewRevisionService(f.store)
+	list, err := revisions.List(q.ID)
+	f.must(err)
+	if len(list) != 2 || list[0].Number != 1 || list[1].Number != 2 {
+		t.Fatalf("expected revisions 1 and 2, got %+v", list)
+	}
+	if list[0].RevisionDetails[0].UnitPrice != dec(5) || list[1].RevisionDetails[0].UnitPrice != dec(4) {
+		t.Fatalf("unexpected prices of the revisions %+v", list)
+	}
+
+	diff, err := revisions.Diff(q.ID, 0, 0)
+	f.must(err)
+	if diff.From != 1 || diff.To != 2 || diff.NewObservation != "Improved offer" {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	// 10*5 + 2*10 = 70, 10*4 + 2*10 = 60
+	if diff.OldTotal != dec(70) || diff.NewTotal != dec(60) || diff.Lines[0].Difference != dec(-1) || diff.Lines[1].Difference != dec(0) {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+
+	table, err := quotations.ComparativeTable(f.requirement.ID, true)
+	f.must(err)
+	if table.CTResponseQuotations[0].FirstPrice != dec(5) || table.CTResponseProviders[0].Savings != dec(10) {
+		t.Fatalf("unexpected savings %+v", table)
+	}
+}
+
+func TestRevisionBaseline(t *testing.T) {
+	f := newFixture(t)
+	q := f.quote(0, 5, 10)
+
+	// Quotation created before the history
+	f.must(f.store.Revisions().DeleteByQuotation(q.ID))
+	q.QuotationDetails[1].UnitPrice = dec(8)
+	f.must(NewQuotationService(f.store).Update(f.user.ID, &models.Quotation{ID: q.ID, DeliverDate: q.DeliverDate, QuotationDetails: q.QuotationDetails}))
+
+	diff, err := NewRevisionService(f.store).Diff(q.ID, 1, 2)
+	f.must(err)
+	if diff.Lines[1].OldPrice != dec(10) || diff.Lines[1].NewPrice != dec(8) {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
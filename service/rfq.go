@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// RfqStatus last request for quotation sent to a provider
+type RfqStatus struct {
+	models.Rfq
+	ProviderName string `json:"provider_name"`
+}
+
+// RfqService requests for quotation sent to the providers and their answers
+type RfqService interface {
+	// Prepare check that the providers can be asked to quote the requirement and return them
+	Prepare(requirementID uint, providerIDs []uint) (models.Requirement, []models.Provider, error)
+	// Sent record the request sent by email to the provider
+	Sent(userID uint, requirementID uint, provider models.Provider) (models.Rfq, error)
+	// List the last request of every provider, unanswered requests of expired requirements are no_response
+	List(requirementID uint) ([]RfqStatus, error)
+
+	// Answers of the provider in the portal, ignored when the provider was not asked by email
+	Opened(requirementID uint, providerID uint) error
+	Quoted(requirementID uint, providerID uint) error
+	Decline(requirementID uint, providerID uint, reason string) error
+}
+
+type rfqService struct {
+	store repository.Store
+}
+
+// NewRfqService create the request for quotation service over the store
+func NewRfqService(store repository.Store) RfqService {
+	return &rfqService{store: store}
+}
+
+func (s *rfqService) Prepare(requirementID uint, providerIDs []uint) (models.Requirement, []models.Provider, error) {
+	requirement, err := openRequirement(s.store, requirementID)
+	if err != nil {
+		return requirement, nil, err
+	}
+	if len(providerIDs) == 0 {
+		return requirement, nil, invalid([]utilities.FieldError{{Field: "providers", Code: utilities.FieldRequired}})
+	}
+
+	// Only the active providers with email
+	details := make([]utilities.FieldError, 0)
+	providers := make([]models.Provider, 0, len(providerIDs))
+	for i, id := range providerIDs {
+		field := fmt.Sprintf("providers[%d]", i)
+		provider, err := s.store.Providers().Get(id)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference(field, id))
+		case err != nil:
+			return requirement, nil, err
+		case !provider.State:
+			details = append(details, inactive(field, id))
+		case provider.Email == "":
+			details = append(details, utilities.FieldError{Field: field + ".email", Code: utilities.FieldRequired})
+		default:
+			providers = append(providers, provider)
+		}
+	}
+	if err := invalid(details); err != nil {
+		return requirement, nil, err
+	}
+
+	return requirement, providers, nil
+}
+
+func (s *rfqService) Sent(userID uint, requirementID uint, provider models.Provider) (models.Rfq, error) {
+	rfq := models.Rfq{
+		State:         models.RfqSent,
+		Email:         provider.Email,
+		SentAt:        time.Now(),
+		RequirementID: requirementID,
+		ProviderID:    provider.ID,
+		UserID:        userID,
+	}
+	return rfq, s.store.Rfqs().Create(&rfq)
+}
+
+func (s *rfqService) List(requirementID uint) ([]RfqStatus, error) {
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return nil, notFound(err, requirementID)
+	}
+	rfqs, err := s.store.Rfqs().ListByRequirement(requirementID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The requirement no longer receives answers
+	closed := !CanChangeState(requirement.State, models.RequirementQuoted) || LinkExpiration(requirement).Before(time.Now())
+
+	// Last request by provider, in the order they were first sent
+	index := make(map[uint]int)
+	statuses := make([]RfqStatus, 0)
+	for _, rfq := range rfqs {
+		if closed && (rfq.State == models.RfqSent || rfq.State == models.RfqOpened) {
+			rfq.State = models.RfqNoResponse
+		}
+		if k, ok := index[rfq.ProviderID]; ok {
+			statuses[k].Rfq = rfq
+			continue
+		}
+		provider, err := s.store.Providers().Get(rfq.ProviderID)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		index[rfq.ProviderID] = len(statuses)
+		statuses = append(statuses, RfqStatus{Rfq: rfq, ProviderName: provider.Name})
+	}
+	return statuses, nil
+}
+
+// last request sent to the provider, nil when there is none
+func (s *rfqService) last(requirementID uint, providerID uint) (*models.Rfq, error) {
+	rfq, err := s.store.Rfqs().GetLast(requirementID, providerID)
+	if err == repository.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rfq, nil
+}
+
+func (s *rfqService) Opened(requirementID uint, providerID uint) error {
+	rfq, err := s.last(requirementID, providerID)
+	if err != nil || rfq == nil || rfq.State != models.RfqSent {
+		return err
+	}
+	return s.store.Rfqs().UpdateFields(rfq.ID, map[string]interface{}{
+		"state":     models.RfqOpened,
+		"opened_at": time.Now(),
+	})
+}
+
+func (s *rfqService) Quoted(requirementID uint, providerID uint) error {
+	rfq, err := s.last(requirementID, providerID)
+	if err != nil || rfq == nil || rfq.State == models.RfqQuoted {
+		return err
+	}
+	return s.store.Rfqs().UpdateFields(rfq.ID, map[string]interface{}{
+		"state":       models.RfqQuoted,
+		"answered_at": time.Now(),
+	})
+}
+
+func (s *rfqService) Decline(requirementID uint, providerID uint, reason string) error {
+	rfq, err := s.last(requirementID, providerID)
+	if err != nil || rfq == nil {
+		return err
+	}
+	if rfq.State == models.RfqQuoted {
+		return utilities.NewError(http.StatusConflict, utilities.ErrAlreadyQuoted)
+	}
+	return s.store.Rfqs().UpdateFields(rfq.ID, map[string]interface{}{
+		"state":       models.RfqDeclined,
+		"answered_at": time.Now(),
+		"reason":      reason,
+	})
+}
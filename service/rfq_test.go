@@ -0,0 +1,92 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+func TestRfqPrepare(t *testing.T) {
+	f := newFixture(t)
+	rfqs := NewRfqService(f.store)
+
+	// The providers of the fixture have no email
+	_, _, err := rfqs.Prepare(f.requirement.ID, []uint{f.providers[0].ID})
+	expectError(t, err, http.StatusUnprocessableEntity, utilities.ErrValidation)
+
+	f.must(f.store.Providers().UpdateFields(f.providers[0].ID, map[string]interface{}{"email": "sales@example.com"}))
+	_, providers, err := rfqs.Prepare(f.requirement.ID, []uint{f.providers[0].ID})
+	f.must(err)
+	if len(providers) != 1 || providers[0].Email != "sales@example.com" {
+		t.Fatalf("unexpected providers %+v", providers)
+	}
+
+	// Rejected requirements do not receive quotations
+	f.must(NewRequirementService(f.store).Reject(f.user.ID, f.requirement.ID))
+	_, _, err = rfqs.Prepare(f.requirement.ID, []uint{f.providers[0].ID})
+	expectError(t, err, http.StatusConflict, utilities.ErrInvalidState)
+}
+
+func TestRfqStates(t *testing.T) {
+	f := newFixture(t)
+	rfqs := NewRfqService(f.store)
+	portal := NewPortalService(f.store)
+
+	for _, p := range f.providers {
+		_, err := rfqs.Sent(f.user.ID, f.requirement.ID, p)
+		f.must(err)
+	}
+
+	// provider 0 opens and quotes, provider 1 declines, provider 2 does not answer
+	_, err := portal.View(f.providers[0].ID, f.requirement.ID)
+	f.must(err)
+	sent := f.newQuotation(0, 1, 1)
+	f.must(portal.Submit(f.user.ID, &sent))
+	f.must(portal.Decline(f.providers[1].ID, f.requirement.ID, "Sin stock"))
+	expectError(t, portal.Decline(f.providers[0].ID, f.requirement.ID, ""), http.StatusConflict, utilities.ErrAlreadyQuoted)
+
+	states := func() map[uint]string {
+		list, err := rfqs.List(f.requirement.ID)
+		f.must(err)
+		m := make(map[uint]string)
+		for _, s := range list {
+			m[s.ProviderID] = s.State
+		}
+		return m
+	}
+	want := map[uint]string{
+		f.providers[0].ID: models.RfqQuoted,
+		f.providers[1].ID: models.RfqDeclined,
+		f.providers[2].ID: models.RfqSent,
+	}
+	for id, state := range states() {
+		if want[id] != state {
+			t.Errorf("provider %d: expected %s, got %s", id, want[id], state)
+		}
+	}
+
+	// Once awarded the unanswered requests have no response
+	f.must(NewQuotationService(f.store).Approve(sent.ID))
+	_, err = NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+	if state := states()[f.providers[2].ID]; state != models.RfqNoResponse {
+		t.Fatalf("expected %s, got %s", models.RfqNoResponse, state)
+	}
+}
+
+func TestRfqQuotedByBuyer(t *testing.T) {
+	f := newFixture(t)
+	rfqs := NewRfqService(f.store)
+	_, err := rfqs.Sent(f.user.ID, f.requirement.ID, f.providers[0])
+	f.must(err)
+
+	// The buyer enters the quotation received by email
+	f.quote(0, 1, 1)
+	list, err := rfqs.List(f.requirement.ID)
+	f.must(err)
+	if len(list) != 1 || list[0].State != models.RfqQuoted || list[0].AnsweredAt == nil {
+		t.Fatalf("expected quoted request, got %+v", list)
+	}
+}
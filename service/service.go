@@ -0,0 +1,80 @@
+package service
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// notFound translate the not found error of the repositories to the api error of the id
+func notFound(err error, id uint) error {
+	if err == repository.ErrNotFound {
+		return utilities.NewNotFoundError(id)
+	}
+	return err
+}
+
+// invalid return the validation error of the violations, nil when there is none
+func invalid(details []utilities.FieldError) error {
+	if len(details) == 0 {
+		return nil
+	}
+	return utilities.NewValidationError(details...)
+}
+
+// invalidState error of a operation not allowed in the current state of the requirement
+func invalidState(requirementID uint) error {
+	return utilities.NewError(http.StatusConflict, utilities.ErrInvalidState, requirementID)
+}
+
+// administrator only the administrators are allowed
+func administrator(user models.User) error {
+	if user.Profile != "admin" {
+		return utilities.NewError(http.StatusForbidden, utilities.ErrForbidden)
+	}
+	return nil
+}
+
+// retryBackoff wait after the first failed attempt of a delivery, doubled after every attempt
+const retryBackoff = time.Minute
+
+// backoff wait after the failed attempts of a delivery
+func backoff(attempts uint) time.Duration {
+	return retryBackoff << (attempts - 1)
+}
+
+// hashPassword sha256 of the password in hexadecimal
+func hashPassword(password string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+}
+
+// isPastDate check if the date is before the current day
+func isPastDate(date time.Time) bool {
+	y, m, d := time.Now().Date()
+	return !date.IsZero() && date.Before(time.Date(y, m, d, 0, 0, 0, 0, date.Location()))
+}
+
+// hasField check if there is a violation of the field
+func hasField(details []utilities.FieldError, field string) bool {
+	for _, d := range details {
+		if d.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// reference field error of a referenced record that does not exist
+func reference(field string, id uint) utilities.FieldError {
+	return utilities.FieldError{Field: field, Code: utilities.FieldRef, Params: []interface{}{id}}
+}
+
+// inactive field error of a referenced record that is disabled
+func inactive(field string, id uint) utilities.FieldError {
+	return utilities.FieldError{Field: field, Code: utilities.FieldState, Params: []interface{}{id}}
+}
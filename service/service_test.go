@@ -0,0 +1,128 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// fixture store over a in-memory SQLite database with a user, a setting, three
+// providers, a cost center and a requirement of two products
+type fixture struct {
+	t           *testing.T
+	db          *gorm.DB
+	store       repository.Store
+	user        models.User
+	providers   []models.Provider
+	costCenter  models.CostCenter
+	requirement models.Requirement
+}
+
+func newFixture(t *testing.T) *fixture {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// A single connection, every new connection opens a empty memory database
+	db.DB().SetMaxOpenConns(1)
+
+	if err := repository.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{t: t, db: db, store: repository.NewStore(db)}
+	f.must(f.store.Settings().Save(&models.Setting{Item: 10, Quotations: 3}))
+
+	f.user = models.User{DNI: "12345678", UserName: "admin", Email: "admin@example.com", Password: "admin", Profile: "admin", State: true}
+	f.must(NewUserService(f.store).Create(&f.user))
+
+	for _, ruc := range []string{"20100070970", "20600000005", "20600000013"} {
+		provider := models.Provider{Name: "Provider " + ruc, RUC: ruc, State: true}
+		f.must(NewProviderService(f.store).Create(&provider))
+		f.providers = append(f.providers, provider)
+	}
+
+	products := make([]models.Product, 2)
+	for k := range products {
+		products[k] = models.Product{Name: "Product", UnitMeasure: "UND", State: true}
+		f.must(NewProductService(f.store).Create(&products[k]))
+	}
+
+	f.costCenter = models.CostCenter{Code: "ADM", Name: "Administration", State: true}
+	f.must(NewCostCenterService(f.store).Create(&f.costCenter))
+
+	f.requirement = models.Requirement{
+		Name:           "Office supplies",
+		ExpirationDate: time.Now().AddDate(0, 0, 10),
+		CostCenterID:   f.costCenter.ID,
+		Requires: []models.Require{
+			{Amount: dec(10), ProductID: products[0].ID},
+			{Amount: dec(2), ProductID: products[1].ID},
+		},
+	}
+	f.must(NewRequirementService(f.store).Create(f.user.ID, &f.requirement))
+
+	return f
+}
+
+func (f *fixture) must(err error) {
+	f.t.Helper()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// quote create a quotation of the provider with the unit prices of the requires
+func (f *fixture) quote(provider int, prices ...float64) models.Quotation {
+	f.t.Helper()
+	quotation := f.newQuotation(provider, prices...)
+	f.must(NewQuotationService(f.store).Create(f.user.ID, &quotation))
+	return quotation
+}
+
+func (f *fixture) newQuotation(provider int, prices ...float64) models.Quotation {
+	quotation := models.Quotation{
+		DeliverDate:   time.Now().AddDate(0, 0, 5),
+		ProviderID:    f.providers[provider].ID,
+		RequirementID: f.requirement.ID,
+	}
+	for k, price := range prices {
+		quotation.QuotationDetails = append(quotation.QuotationDetails, models.QuotationDetail{
+			UnitPrice: dec(price),
+			RequireID: f.requirement.Requires[k].ID,
+		})
+	}
+	return quotation
+}
+
+// dec decimal of the number
+func dec(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+// state current state of the requirement
+func (f *fixture) state() string {
+	f.t.Helper()
+	requirement, err := f.store.Requirements().Get(f.requirement.ID)
+	f.must(err)
+	return requirement.State
+}
+
+// expectError check the status and the code of a api error
+func expectError(t *testing.T, err error, status int, code string) {
+	t.Helper()
+	e, ok := err.(*utilities.Error)
+	if !ok {
+		t.Fatalf("expected api error %s, got %v", code, err)
+	}
+	if e.Status != status || e.Code != code {
+		t.Fatalf("expected %d %s, got %d %s", status, code, e.Status, e.Code)
+	}
+}
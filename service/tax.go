@@ -0,0 +1,132 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/paulantezana/requirement/decimal"
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// Taxes amounts of an offer: subtotal without IGV, IGV and total to pay
+type Taxes struct {
+	Subtotal decimal.Decimal `json:"subtotal"`
+	Tax      decimal.Decimal `json:"tax"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// hundred base of the tax rates in percent
+var hundred = decimal.NewFromInt(100)
+
+// taxIncluded check if the prices of the quotation include the IGV
+func taxIncluded(quotation models.Quotation) bool {
+	return quotation.TaxIncluded != nil && *quotation.TaxIncluded
+}
+
+// taxFactor 1 + rate, the prices with IGV divided by it are the prices without IGV
+func taxFactor(quotation models.Quotation) decimal.Decimal {
+	return decimal.NewFromInt(1).Add(quotation.TaxRate.Div(hundred))
+}
+
+// amountRange error of the amounts that do not fit in the decimals: every price and amount is
+// valid, but the largest ones of many lines, its IGV or its exchange rate overflow the totals
+func amountRange(err error) error {
+	if err == decimal.ErrRange {
+		return utilities.NewError(http.StatusUnprocessableEntity, utilities.ErrAmountRange)
+	}
+	return err
+}
+
+// lineTotal unit price by the amount rounded to the decimal places of the money
+func lineTotal(price decimal.Decimal, amount decimal.Decimal) (decimal.Decimal, error) {
+	total, err := price.CheckedMul(amount)
+	return total.Money(), amountRange(err)
+}
+
+// summation total of the quotation, the sum of the totals of the lines
+func summation(quotation models.Quotation, lines map[uint]repository.RequireLine) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, qd := range quotation.QuotationDetails {
+		line, err := lineTotal(qd.UnitPrice, lines[qd.RequireID].Amount)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if total, err = total.CheckedAdd(line); err != nil {
+			return decimal.Zero, amountRange(err)
+		}
+	}
+	return total, nil
+}
+
+// convert the amount to the base currency with the rate, rounded to the decimal places of the money
+func convert(amount decimal.Decimal, rate decimal.Decimal) (decimal.Decimal, error) {
+	converted, err := amount.CheckedMul(rate)
+	return converted.Money(), amountRange(err)
+}
+
+// taxes amounts of the quoted lines of the quotation with its tax rate. The lines of the
+// exempt products have no IGV, the IGV of the other lines is computed on their sum: added
+// to the prices without IGV or extracted from the prices with IGV, so the totals of both
+// kinds of offers compare fairly
+func taxes(quotation models.Quotation, lines map[uint]repository.RequireLine) (Taxes, error) {
+	exempt, taxable := decimal.Zero, decimal.Zero
+	for _, qd := range quotation.QuotationDetails {
+		line := lines[qd.RequireID]
+		total, err := lineTotal(qd.UnitPrice, line.Amount)
+		if err != nil {
+			return Taxes{}, err
+		}
+		if line.Exempt {
+			exempt, err = exempt.CheckedAdd(total)
+		} else {
+			taxable, err = taxable.CheckedAdd(total)
+		}
+		if err != nil {
+			return Taxes{}, amountRange(err)
+		}
+	}
+
+	amounts := Taxes{}
+	var err error
+	if taxIncluded(quotation) {
+		base := taxable.Div(taxFactor(quotation)).Money()
+		amounts.Tax = taxable.Sub(base)
+		amounts.Subtotal, err = exempt.CheckedAdd(base)
+	} else {
+		amounts.Subtotal, err = exempt.CheckedAdd(taxable)
+		if err == nil {
+			amounts.Tax, err = taxable.CheckedMul(quotation.TaxRate.Div(hundred))
+			amounts.Tax = amounts.Tax.Money()
+		}
+	}
+	if err != nil {
+		return Taxes{}, amountRange(err)
+	}
+	amounts.Total, err = amounts.Subtotal.CheckedAdd(amounts.Tax)
+	return amounts, amountRange(err)
+}
+
+// netPrice unit price without IGV of a line of the quotation
+func netPrice(quotation models.Quotation, price decimal.Decimal, exempt bool) decimal.Decimal {
+	if exempt || !taxIncluded(quotation) {
+		return price
+	}
+	return price.Div(taxFactor(quotation))
+}
+
+// withPrices copy of the quotation with other offer: the prices and the not quoted lines
+// by quotation detail, used to compute the amounts of the revisions
+func withPrices(quotation models.Quotation, details []models.RevisionDetail) models.Quotation {
+	offer := quotation
+	offer.QuotationDetails = make([]models.QuotationDetail, 0, len(details))
+	for _, rd := range details {
+		offer.QuotationDetails = append(offer.QuotationDetails, models.QuotationDetail{
+			ID:        rd.QuotationDetailID,
+			UnitPrice: rd.UnitPrice,
+			NotQuoted: rd.NotQuoted,
+			RequireID: rd.RequireID,
+		})
+	}
+	return offer
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+)
+
+func TestTaxes(t *testing.T) {
+	lines := map[uint]repository.RequireLine{
+		1: {ID: 1, Amount: dec(10)},
+		2: {ID: 2, Amount: dec(2)},
+	}
+	included := true
+	quotation := models.Quotation{
+		TaxRate: dec(18),
+		QuotationDetails: []models.QuotationDetail{
+			{RequireID: 1, UnitPrice: dec(10)},
+			{RequireID: 2, UnitPrice: dec(5)},
+		},
+	}
+	cases := []struct {
+		name                 string
+		included             *bool
+		exempt               bool
+		subtotal, tax, total float64
+	}{
+		{"exclusive", nil, false, 110, 19.8, 129.8},
+		{"inclusive", &included, false, 93.22, 16.78, 110},
+		{"exempt line", nil, true, 110, 1.8, 111.8},
+	}
+	for _, c := range cases {
+		quotation.TaxIncluded = c.included
+		line := lines[1]
+		line.Exempt = c.exempt
+		lines[1] = line
+		amounts, err := taxes(quotation, lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amounts.Subtotal != dec(c.subtotal) || amounts.Tax != dec(c.tax) || amounts.Total != dec(c.total) {
+			t.Errorf("%s: unexpected amounts %s %s %s", c.name, amounts.Subtotal, amounts.Tax, amounts.Total)
+		}
+	}
+}
+
+func TestRankWithTax(t *testing.T) {
+	f := newFixture(t)
+	setting, err := f.store.Settings().Get()
+	f.must(err)
+	setting.TaxRate, setting.TaxIncluded = dec(18), true
+	f.must(f.store.Settings().Save(&setting))
+
+	// Without IGV the offer of 129.80 is more expensive than the offer of 122 with IGV
+	excluded := false
+	exclusive := f.newQuotation(0, 10, 5)
+	exclusive.TaxIncluded = &excluded
+	f.must(NewQuotationService(f.store).Create(f.user.ID, &exclusive))
+	inclusive := f.quote(1, 11, 6)
+
+	for id, level := range map[uint]uint{inclusive.ID: 1, exclusive.ID: 2} {
+		q, err := f.store.Quotations().Get(id)
+		f.must(err)
+		if q.WinnerLevel != level {
+			t.Errorf("quotation %d: expected level %d, got %d", id, level, q.WinnerLevel)
+		}
+	}
+
+	// The default of the setting and its rate are kept by the quotation
+	q, err := f.store.Quotations().Get(inclusive.ID)
+	f.must(err)
+	if !taxIncluded(q) || q.TaxRate != dec(18) {
+		t.Errorf("expected prices with IGV of 18%%, got %v %s", q.TaxIncluded, q.TaxRate)
+	}
+	summaries, err := NewQuotationService(f.store).List(f.requirement.ID)
+	f.must(err)
+	for _, s := range summaries {
+		if s.ID == exclusive.ID && (s.Subtotal != dec(110) || s.Tax != dec(19.8) || s.Converted != dec(129.8)) {
+			t.Errorf("unexpected summary %s %s %s", s.Subtotal, s.Tax, s.Converted)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// TemplateService requirement templates, the creation of requirements from the templates or by
+// cloning other requirement, and the scheduled creation of the recurrent templates
+type TemplateService interface {
+	List(page repository.Page) ([]models.RequirementTemplate, uint, error)
+	Get(id uint) (models.RequirementTemplate, error)
+	Create(userID uint, template *models.RequirementTemplate) error
+	Update(template *models.RequirementTemplate) error
+	Delete(id uint) error
+	// Save the requirement with its requires as a template with the name
+	Save(userID uint, requirementID uint, name string) (models.RequirementTemplate, error)
+
+	// Instantiate create a new requirement from the template
+	Instantiate(userID uint, templateID uint) (models.Requirement, error)
+	// Clone create a new requirement with the data and the requires of other requirement, with fresh dates and state
+	Clone(userID uint, requirementID uint) (models.Requirement, error)
+	// RunDue create the requirements of the templates scheduled before now and schedule its next run
+	RunDue(now time.Time) ([]models.Requirement, error)
+}
+
+type templateService struct {
+	store repository.Store
+}
+
+// NewTemplateService create the template service over the store
+func NewTemplateService(store repository.Store) TemplateService {
+	return &templateService{store: store}
+}
+
+// businessDay the n-th business day, monday to friday, of the month at the start of the day
+func businessDay(year int, month time.Month, n uint, loc *time.Location) time.Time {
+	if n == 0 {
+		n = 1
+	}
+	day := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	for count := uint(0); ; day = day.AddDate(0, 0, 1) {
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			if count++; count == n {
+				return day
+			}
+		}
+	}
+}
+
+// nextRun first business day n of a month after the time
+func nextRun(after time.Time, n uint) time.Time {
+	run := businessDay(after.Year(), after.Month(), n, after.Location())
+	if run.After(after) {
+		return run
+	}
+	return businessDay(after.Year(), after.Month()+1, n, after.Location())
+}
+
+// schedule next run of the template from now, nil without recurrence
+func schedule(template models.RequirementTemplate, now time.Time) *time.Time {
+	if template.Recurrence == "" {
+		return nil
+	}
+	run := nextRun(now, template.BusinessDay)
+	return &run
+}
+
+func (s *templateService) List(page repository.Page) ([]models.RequirementTemplate, uint, error) {
+	return s.store.Templates().List(page)
+}
+
+func (s *templateService) Get(id uint) (models.RequirementTemplate, error) {
+	template, err := s.store.Templates().Get(id)
+	return template, notFound(err, id)
+}
+
+// validate return the violations of the template: struct tags, active cost center and active products
+func (s *templateService) validate(template models.RequirementTemplate) ([]utilities.FieldError, error) {
+	details := utilities.ValidateStruct(&template)
+
+	requirements := &requirementService{store: s.store}
+	costCenter, err := requirements.costCenter(models.Requirement{CostCenterID: template.CostCenterID})
+	if err != nil {
+		return nil, err
+	}
+	details = append(details, costCenter...)
+
+	for i, rq := range template.TemplateRequires {
+		if rq.ProductID == 0 {
+			continue
+		}
+		field := fmt.Sprintf("template_requires[%d].product_id", i)
+		product, err := s.store.Products().Get(rq.ProductID)
+		switch {
+		case err == repository.ErrNotFound:
+			details = append(details, reference(field, rq.ProductID))
+		case err != nil:
+			return nil, err
+		case !product.State:
+			details = append(details, inactive(field, rq.ProductID))
+		}
+	}
+	return details, nil
+}
+
+func (s *templateService) Create(userID uint, template *models.RequirementTemplate) error {
+	details, err := s.validate(*template)
+	if err != nil {
+		return err
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+	template.UserID = userID
+	template.NextRun = schedule(*template, time.Now())
+	template.LastRun = nil
+	template.LastRequirementID = 0
+	return s.store.Templates().Create(template)
+}
+
+func (s *templateService) Update(template *models.RequirementTemplate) error {
+	details, err := s.validate(*template)
+	if err != nil {
+		return err
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	// The owner and the runs only change with the schedule
+	template.UserID = 0
+	template.LastRun = nil
+	template.LastRequirementID = 0
+	template.NextRun = nil
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Templates().Update(template); err != nil {
+			return notFound(err, template.ID)
+		}
+		fields := map[string]interface{}{
+			"sealed":          template.Sealed,
+			"expiration_days": template.ExpirationDays,
+			"recurrence":      template.Recurrence,
+			"business_day":    template.BusinessDay,
+			"next_run":        schedule(*template, time.Now()),
+		}
+		if err := tx.Templates().UpdateFields(template.ID, fields); err != nil {
+			return notFound(err, template.ID)
+		}
+		return tx.Templates().ReplaceRequires(template.ID, template.TemplateRequires)
+	})
+}
+
+func (s *templateService) Delete(id uint) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		return notFound(tx.Templates().Delete(id), id)
+	})
+}
+
+func (s *templateService) Save(userID uint, requirementID uint, name string) (models.RequirementTemplate, error) {
+	requirement, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return models.RequirementTemplate{}, notFound(err, requirementID)
+	}
+	lines, err := s.store.Requires().ListByRequirement(requirementID)
+	if err != nil {
+		return models.RequirementTemplate{}, err
+	}
+
+	template := models.RequirementTemplate{
+		Name:             name,
+		Requirement:      requirement.Name,
+		Place:            requirement.Place,
+		Destination:      requirement.Destination,
+		Claimant:         requirement.Claimant,
+		Sealed:           requirement.Sealed,
+		CostCenterID:     requirement.CostCenterID,
+		TemplateRequires: make([]models.TemplateRequire, 0, len(lines)),
+	}
+	if !requirement.ExpirationDate.IsZero() && requirement.ExpirationDate.After(requirement.EmissionDate) {
+		// Rounded to whole days
+		term := requirement.ExpirationDate.Sub(requirement.EmissionDate) + 12*time.Hour
+		template.ExpirationDays = uint(term / (24 * time.Hour))
+	}
+	for _, line := range lines {
+		template.TemplateRequires = append(template.TemplateRequires, models.TemplateRequire{
+			Amount:         line.Amount,
+			UnitMeasure:    line.UnitMeasure,
+			SuggestedPrice: line.SuggestedPrice,
+			Observation:    line.Observation,
+			ProductID:      line.ProductID,
+		})
+	}
+	return template, s.Create(userID, &template)
+}
+
+// fromTemplate new requirement with the data and the products of the template
+func fromTemplate(template models.RequirementTemplate, now time.Time) models.Requirement {
+	requirement := models.Requirement{
+		Name:         template.Requirement,
+		Place:        template.Place,
+		Destination:  template.Destination,
+		Claimant:     template.Claimant,
+		Sealed:       template.Sealed,
+		CostCenterID: template.CostCenterID,
+		Requires:     make([]models.Require, 0, len(template.TemplateRequires)),
+	}
+	if template.ExpirationDays > 0 {
+		requirement.ExpirationDate = now.AddDate(0, 0, int(template.ExpirationDays))
+	}
+	for _, rq := range template.TemplateRequires {
+		requirement.Requires = append(requirement.Requires, models.Require{
+			Amount:         rq.Amount,
+			UnitMeasure:    rq.UnitMeasure,
+			SuggestedPrice: rq.SuggestedPrice,
+			Observation:    rq.Observation,
+			ProductID:      rq.ProductID,
+		})
+	}
+	return requirement
+}
+
+func (s *templateService) Instantiate(userID uint, templateID uint) (models.Requirement, error) {
+	template, err := s.Get(templateID)
+	if err != nil {
+		return models.Requirement{}, err
+	}
+	requirement := fromTemplate(template, time.Now())
+	return requirement, NewRequirementService(s.store).Create(userID, &requirement)
+}
+
+func (s *templateService) Clone(userID uint, requirementID uint) (models.Requirement, error) {
+	original, err := s.store.Requirements().Get(requirementID)
+	if err != nil {
+		return models.Requirement{}, notFound(err, requirementID)
+	}
+	lines, err := s.store.Requires().ListByRequirement(requirementID)
+	if err != nil {
+		return models.Requirement{}, err
+	}
+
+	// The same term to quote as the original
+	requirement := models.Requirement{
+		Name:         original.Name,
+		Place:        original.Place,
+		Destination:  original.Destination,
+		Claimant:     original.Claimant,
+		Sealed:       original.Sealed,
+		CostCenterID: original.CostCenterID,
+		Requires:     make([]models.Require, 0, len(lines)),
+	}
+	if !original.ExpirationDate.IsZero() && original.ExpirationDate.After(original.EmissionDate) {
+		requirement.ExpirationDate = time.Now().Add(original.ExpirationDate.Sub(original.EmissionDate))
+	}
+	for _, line := range lines {
+		requirement.Requires = append(requirement.Requires, models.Require{
+			Amount:         line.Amount,
+			UnitMeasure:    line.UnitMeasure,
+			SuggestedPrice: line.SuggestedPrice,
+			Observation:    line.Observation,
+			ProductID:      line.ProductID,
+		})
+	}
+	return requirement, NewRequirementService(s.store).Create(userID, &requirement)
+}
+
+func (s *templateService) RunDue(now time.Time) ([]models.Requirement, error) {
+	templates, err := s.store.Templates().ListDue(now)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]models.Requirement, 0, len(templates))
+	var first error
+	for _, template := range templates {
+		// The run is taken before the creation: a template that fails waits for its next run
+		err := s.store.Templates().Advance(template.ID, *template.NextRun, schedule(template, now))
+		if err == repository.ErrNotFound {
+			continue
+		}
+		if err == nil {
+			requirement := fromTemplate(template, now)
+			err = s.store.Transaction(func(tx repository.Store) error {
+				if err := NewRequirementService(tx).Create(template.UserID, &requirement); err != nil {
+					return err
+				}
+				fields := map[string]interface{}{"last_run": now, "last_requirement_id": requirement.ID}
+				return tx.Templates().UpdateFields(template.ID, fields)
+			})
+			if err == nil {
+				created = append(created, requirement)
+			}
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("template %d: %v", template.ID, err)
+		}
+	}
+	return created, first
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+)
+
+func TestNextRun(t *testing.T) {
+	day := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		after time.Time
+		n     uint
+		run   time.Time
+	}{
+		{day(2024, 6, 1, 0), 1, day(2024, 6, 3, 0)},   // Saturday 1st, first business day on Monday 3rd
+		{day(2024, 6, 3, 0), 1, day(2024, 7, 1, 0)},   // At the run, the next month
+		{day(2024, 6, 2, 23), 0, day(2024, 6, 3, 0)},  // 0 is the first business day
+		{day(2024, 12, 10, 9), 3, day(2025, 1, 3, 0)}, // Next year
+		{day(2024, 2, 1, 0), 20, day(2024, 2, 28, 0)},
+	}
+	for _, c := range cases {
+		if run := nextRun(c.after, c.n); !run.Equal(c.run) {
+			t.Errorf("%s business day %d: expected %s, got %s", c.after, c.n, c.run, run)
+		}
+	}
+}
+
+func TestTemplateRunDue(t *testing.T) {
+	f := newFixture(t)
+	templates := NewTemplateService(f.store)
+	template, err := templates.Save(f.user.ID, f.requirement.ID, "Monthly supplies")
+	f.must(err)
+	if template.ExpirationDays != 10 || len(template.TemplateRequires) != 2 || template.NextRun != nil {
+		t.Fatalf("unexpected template %+v", template)
+	}
+
+	template.Recurrence = models.RecurrenceMonthly
+	f.must(templates.Update(&template))
+	template, err = templates.Get(template.ID)
+	f.must(err)
+	if template.NextRun == nil || !template.NextRun.After(time.Now()) {
+		t.Fatalf("expected a next run, got %v", template.NextRun)
+	}
+
+	// At the run the requirement is created once, the next run is in the next month
+	now := template.NextRun.Add(time.Minute)
+	created, err := templates.RunDue(now)
+	f.must(err)
+	if len(created) != 1 || created[0].State != models.RequirementCreated || len(created[0].Requires) != 2 {
+		t.Fatalf("expected 1 new requirement, got %+v", created)
+	}
+	created, err = templates.RunDue(now)
+	f.must(err)
+	if len(created) != 0 {
+		t.Fatalf("expected no requirements in the second run, got %d", len(created))
+	}
+	scheduled, err := templates.Get(template.ID)
+	f.must(err)
+	if !scheduled.NextRun.After(now) || scheduled.LastRun == nil || scheduled.LastRequirementID == 0 {
+		t.Errorf("unexpected schedule %v %v %d", scheduled.NextRun, scheduled.LastRun, scheduled.LastRequirementID)
+	}
+}
+
+func TestCloneRequirement(t *testing.T) {
+	f := newFixture(t)
+	f.quote(0, 5, 10)
+	_, err := NewAwardService(f.store).Award(f.user.ID, f.requirement.ID, 0)
+	f.must(err)
+
+	clone, err := NewTemplateService(f.store).Clone(f.user.ID, f.requirement.ID)
+	f.must(err)
+	if clone.ID == f.requirement.ID || clone.State != models.RequirementCreated || clone.Name != f.requirement.Name {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	lines, err := requires(f.store, clone.ID)
+	f.must(err)
+	if len(lines) != 2 {
+		t.Errorf("expected 2 requires, got %d", len(lines))
+	}
+}
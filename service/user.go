@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// UserService users and authentication
+type UserService interface {
+	List(page repository.Page) ([]models.User, uint, error)
+	Get(id uint) (models.User, error)
+	Create(user *models.User) error
+	Update(user *models.User) error
+	Delete(id uint) (models.User, error)
+
+	// Login find the active user by user name or email and password
+	Login(login string, password string) (models.User, error)
+	// ResetPassword set DNI + user name as the password, returns the new password
+	ResetPassword(id uint) (string, error)
+	ChangePassword(id uint, oldPassword string, password string) (models.User, error)
+	// ChangeLocale set the language of the messages of the user, empty to use the Accept-Language
+	ChangeLocale(id uint, locale string) (models.User, error)
+
+	// ForgotSearch generate the recovery key of the user with the email
+	ForgotSearch(email string) (models.User, error)
+	ForgotValidate(id uint, key string) (models.User, error)
+	ForgotChange(id uint, password string) (models.User, error)
+}
+
+type userService struct {
+	store repository.Store
+}
+
+// NewUserService create the user service over the store
+func NewUserService(store repository.Store) UserService {
+	return &userService{store: store}
+}
+
+func (s *userService) List(page repository.Page) ([]models.User, uint, error) {
+	return s.store.Users().List(page)
+}
+
+func (s *userService) Get(id uint) (models.User, error) {
+	user, err := s.store.Users().Get(id)
+	return user, notFound(err, id)
+}
+
+func (s *userService) Create(user *models.User) error {
+	// Default empty values
+	if len(user.Profile) == 0 {
+		user.Profile = "user"
+	}
+
+	// Validate data
+	details := utilities.ValidateStruct(user)
+	if len(user.Password) == 0 {
+		details = append(details, utilities.FieldError{Field: "password", Code: utilities.FieldRequired})
+	}
+	if err := invalid(details); err != nil {
+		return err
+	}
+
+	user.Password = hashPassword(user.Password)
+	user.Avatar, user.AvatarThumbnail = "", ""
+	return s.store.Users().Create(user)
+}
+
+func (s *userService) Update(user *models.User) error {
+	if err := invalid(utilities.ValidateStruct(user)); err != nil {
+		return err
+	}
+
+	// The password only changes with ChangePassword and the avatar with its upload
+	user.Password = ""
+	user.Avatar, user.AvatarThumbnail = "", ""
+	if err := s.store.Users().Update(user); err != nil {
+		return notFound(err, user.ID)
+	}
+	if !user.State {
+		return notFound(s.store.Users().UpdateFields(user.ID, map[string]interface{}{"state": false}), user.ID)
+	}
+	return nil
+}
+
+func (s *userService) Delete(id uint) (models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return user, err
+	}
+	return user, notFound(s.store.Users().Delete(id), id)
+}
+
+func (s *userService) Login(login string, password string) (models.User, error) {
+	user, err := s.store.Users().GetByLogin(login)
+	if err == repository.ErrNotFound || (err == nil && user.Password != hashPassword(password)) {
+		return models.User{}, utilities.NewError(http.StatusUnauthorized, utilities.ErrInvalidLogin)
+	}
+	if err != nil {
+		return user, err
+	}
+
+	// Check state user
+	if !user.State {
+		return models.User{}, utilities.NewError(http.StatusForbidden, utilities.ErrUserDisabled)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+func (s *userService) ResetPassword(id uint) (string, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+
+	password := user.DNI + user.UserName
+	return password, s.store.Users().UpdateFields(id, map[string]interface{}{"password": hashPassword(password)})
+}
+
+func (s *userService) ChangePassword(id uint, oldPassword string, password string) (models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return user, err
+	}
+
+	// Validate empty length old password
+	details := make([]utilities.FieldError, 0)
+	if len(oldPassword) == 0 {
+		details = append(details, utilities.FieldError{Field: "old_password", Code: utilities.FieldRequired})
+	}
+	if len(password) == 0 {
+		details = append(details, utilities.FieldError{Field: "password", Code: utilities.FieldRequired})
+	}
+	if err := invalid(details); err != nil {
+		return user, err
+	}
+
+	// validate old password
+	if user.Password != hashPassword(oldPassword) {
+		return user, utilities.NewError(http.StatusUnprocessableEntity, utilities.ErrWrongPassword)
+	}
+
+	return user, s.store.Users().UpdateFields(id, map[string]interface{}{"password": hashPassword(password)})
+}
+
+func (s *userService) ChangeLocale(id uint, locale string) (models.User, error) {
+	if locale != "" && !utilities.Supported(locale) {
+		return models.User{}, utilities.NewValidationError(utilities.FieldError{Field: "locale", Code: utilities.FieldOneOf, Params: []interface{}{"es en"}})
+	}
+	user, err := s.Get(id)
+	if err != nil {
+		return user, err
+	}
+	user.Locale = locale
+	return user, s.store.Users().UpdateFields(id, map[string]interface{}{"locale": locale})
+}
+
+func (s *userService) ForgotSearch(email string) (models.User, error) {
+	user, err := s.store.Users().GetByEmail(email)
+	if err == repository.ErrNotFound {
+		return user, utilities.NewError(http.StatusNotFound, utilities.ErrNotFound)
+	}
+	if err != nil {
+		return user, err
+	}
+
+	// Generate key validation
+	user.Key = fmt.Sprint((int)(rand.Float32() * 10000000))
+	return user, s.store.Users().UpdateFields(user.ID, map[string]interface{}{"key": user.Key})
+}
+
+func (s *userService) ForgotValidate(id uint, key string) (models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return user, err
+	}
+	if user.Key == "" || user.Key != key {
+		return user, utilities.NewError(http.StatusUnprocessableEntity, utilities.ErrInvalidRecovery, key)
+	}
+	return user, nil
+}
+
+func (s *userService) ForgotChange(id uint, password string) (models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return user, err
+	}
+	if len(password) == 0 {
+		return user, invalid([]utilities.FieldError{{Field: "password", Code: utilities.FieldRequired}})
+	}
+
+	return user, s.store.Users().UpdateFields(id, map[string]interface{}{
+		"password": hashPassword(password),
+		"key":      "",
+	})
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// WebhookAttempts attempts of a delivery before it fails
+const WebhookAttempts = 8
+
+const (
+	webhookLease    = 2 * time.Minute  // Time a worker holds a delivery while it sends it
+	webhookTimeout  = 10 * time.Second // Wait for the answer of the receiver
+	webhookBatch    = 100              // Deliveries sent on every run
+	webhookResponse = 1024             // Bytes of the answer saved in the log
+)
+
+// Headers of the requests of the webhooks
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookPayload body of the requests of the webhooks
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      Event     `json:"data"`
+}
+
+// SignWebhook signature of the body sent at the unix timestamp: "sha256=" and the HMAC-SHA256 of
+// "timestamp.body" with the secret in hexadecimal. The receivers compute it to check the sender
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookService subscriptions of the external systems to the events. The events are saved as
+// deliveries and sent in the background, the failed deliveries are retried with exponential backoff
+type WebhookService interface {
+	// List the webhooks without its secrets
+	List(user models.User) ([]models.Webhook, error)
+	Get(user models.User, id uint) (models.Webhook, error)
+	// Create the webhook, a secret is generated when it is empty
+	Create(user models.User, webhook *models.Webhook) error
+	// Update the webhook, the secret changes only when it is sent
+	Update(user models.User, webhook *models.Webhook) error
+	Delete(user models.User, id uint) error
+
+	// Enqueue a delivery of the event to every active webhook subscribed to it
+	Enqueue(event Event, now time.Time) ([]uint, error)
+	// Deliver send the deliveries due at now, all of them when ids is empty
+	Deliver(now time.Time, ids ...uint) error
+	// Deliveries log of the deliveries of the webhook
+	Deliveries(user models.User, webhookID uint, page repository.Page) ([]models.WebhookDelivery, uint, error)
+	// Redeliver send again the payload of a delivery as a new delivery
+	Redeliver(user models.User, id uint, now time.Time) (models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	store  repository.Store
+	client *http.Client
+}
+
+// NewWebhookService create the webhook service over the store, the deliveries are sent with the
+// client, nil uses a client with a timeout of 10 seconds
+func NewWebhookService(store repository.Store, client *http.Client) WebhookService {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	return &webhookService{store: store, client: client}
+}
+
+// subscribed check if the webhook receives the event
+func subscribed(webhook models.Webhook, event string) bool {
+	for _, e := range strings.Fields(webhook.Events) {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// newSecret random secret of a webhook
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validate the webhook and normalize its events
+func (s *webhookService) validate(webhook *models.Webhook) error {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	webhook.Events = strings.Join(strings.Fields(webhook.Events), " ")
+	details := utilities.ValidateStruct(webhook)
+	if webhook.Secret != "" && len(webhook.Secret) < 16 {
+		details = append(details, utilities.FieldError{Field: "secret", Code: utilities.FieldMin, Params: []interface{}{"16"}})
+	}
+	for _, e := range strings.Fields(webhook.Events) {
+		known := e == "*"
+		for _, event := range models.Events {
+			known = known || e == event
+		}
+		if !known {
+			details = append(details, utilities.FieldError{
+				Field:  "events",
+				Code:   utilities.FieldOneOf,
+				Params: []interface{}{"* " + strings.Join(models.Events, " ")},
+			})
+			break
+		}
+	}
+	return invalid(details)
+}
+
+func (s *webhookService) List(user models.User) ([]models.Webhook, error) {
+	if err := administrator(user); err != nil {
+		return nil, err
+	}
+	webhooks, err := s.store.Webhooks().List()
+	for k := range webhooks {
+		webhooks[k].Secret = ""
+	}
+	return webhooks, err
+}
+
+func (s *webhookService) Get(user models.User, id uint) (models.Webhook, error) {
+	if err := administrator(user); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook, err := s.store.Webhooks().Get(id)
+	webhook.Secret = ""
+	return webhook, notFound(err, id)
+}
+
+func (s *webhookService) Create(user models.User, webhook *models.Webhook) error {
+	if err := administrator(user); err != nil {
+		return err
+	}
+	if err := s.validate(webhook); err != nil {
+		return err
+	}
+	if webhook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+	webhook.ID = 0
+	webhook.UserID = user.ID
+	return s.store.Webhooks().Create(webhook)
+}
+
+func (s *webhookService) Update(user models.User, webhook *models.Webhook) error {
+	if err := administrator(user); err != nil {
+		return err
+	}
+	if err := s.validate(webhook); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{"url": webhook.URL, "events": webhook.Events, "state": webhook.State, "updated_at": time.Now()}
+	if webhook.Secret != "" {
+		fields["secret"] = webhook.Secret
+	}
+	webhook.Secret = ""
+	return notFound(s.store.Webhooks().UpdateFields(webhook.ID, fields), webhook.ID)
+}
+
+func (s *webhookService) Delete(user models.User, id uint) error {
+	if err := administrator(user); err != nil {
+		return err
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		return notFound(tx.Webhooks().Delete(id), id)
+	})
+}
+
+func (s *webhookService) Enqueue(event Event, now time.Time) ([]uint, error) {
+	webhooks, err := s.store.Webhooks().ListActive()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(WebhookPayload{Event: event.Type, CreatedAt: now, Data: event})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0)
+	err = s.store.Transaction(func(tx repository.Store) error {
+		for _, webhook := range webhooks {
+			if !subscribed(webhook, event.Type) {
+				continue
+			}
+			delivery := models.WebhookDelivery{
+				WebhookID:   webhook.ID,
+				Event:       event.Type,
+				Payload:     string(payload),
+				State:       models.DeliveryPending,
+				NextAttempt: &now,
+			}
+			if err := tx.Webhooks().CreateDelivery(&delivery); err != nil {
+				return err
+			}
+			ids = append(ids, delivery.ID)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+func (s *webhookService) Deliver(now time.Time, ids ...uint) error {
+	var filter []uint
+	if len(ids) > 0 {
+		filter = ids
+	}
+	due, err := s.store.Webhooks().ListDue(now, filter, webhookBatch)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range due {
+		// Other worker can be sending the same delivery
+		claimed, err := s.store.Webhooks().ClaimDelivery(delivery.ID, now, now.Add(webhookLease))
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.attempt(delivery, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt send the delivery and record the answer, the next attempt waits twice the previous one
+func (s *webhookService) attempt(delivery models.WebhookDelivery, now time.Time) error {
+	webhook, err := s.store.Webhooks().Get(delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	code, response := 0, "webhook disabled"
+	if webhook.State {
+		code, response = s.post(webhook, delivery, now)
+	}
+
+	attempts := delivery.Attempts + 1
+	fields := map[string]interface{}{"attempts": attempts, "status_code": code, "response": response, "updated_at": now}
+	switch {
+	case code >= 200 && code < 300:
+		fields["state"] = models.DeliverySucceeded
+		fields["delivered_at"] = now
+		fields["next_attempt"] = nil
+	case attempts >= WebhookAttempts || !webhook.State:
+		fields["state"] = models.DeliveryFailed
+		fields["next_attempt"] = nil
+	default:
+		fields["next_attempt"] = now.Add(backoff(attempts))
+	}
+	return s.store.Webhooks().UpdateDeliveryFields(delivery.ID, fields)
+}
+
+// post send the payload signed with the secret of the webhook, the code is 0 when there is no answer
+func (s *webhookService) post(webhook models.Webhook, delivery models.WebhookDelivery, now time.Time) (int, string) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "requirement-webhook")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, now.Unix(), body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+	answer, _ := ioutil.ReadAll(io.LimitReader(res.Body, webhookResponse))
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<20))
+	return res.StatusCode, printable(answer)
+}
+
+// printable answer saved in a text column: valid UTF-8 without NUL, the answer cut in the
+// middle of a character or binary is rejected by postgres
+func printable(answer []byte) string {
+	return strings.ToValidUTF8(strings.Replace(string(answer), "\x00", "", -1), "\uFFFD")
+}
+
+func (s *webhookService) Deliveries(user models.User, webhookID uint, page repository.Page) ([]models.WebhookDelivery, uint, error) {
+	if err := administrator(user); err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.store.Webhooks().Get(webhookID); err != nil {
+		return nil, 0, notFound(err, webhookID)
+	}
+	return s.store.Webhooks().ListDeliveries(webhookID, page)
+}
+
+func (s *webhookService) Redeliver(user models.User, id uint, now time.Time) (models.WebhookDelivery, error) {
+	if err := administrator(user); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	original, err := s.store.Webhooks().GetDelivery(id)
+	if err != nil {
+		return original, notFound(err, id)
+	}
+	delivery := models.WebhookDelivery{
+		WebhookID:   original.WebhookID,
+		Event:       original.Event,
+		Payload:     original.Payload,
+		State:       models.DeliveryPending,
+		NextAttempt: &now,
+		Redelivery:  original.ID,
+	}
+	return delivery, s.store.Webhooks().CreateDelivery(&delivery)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/paulantezana/requirement/models"
+	"github.com/paulantezana/requirement/repository"
+	"github.com/paulantezana/requirement/utilities"
+)
+
+// receiver local endpoint of a webhook that checks the signature, it fails while failing is true
+type receiver struct {
+	secret   string
+	failing  bool
+	payloads []WebhookPayload
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	timestamp, _ := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+	if req.Header.Get(WebhookSignatureHeader) != SignWebhook(r.secret, timestamp, body) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	if r.failing {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	payload := WebhookPayload{}
+	json.Unmarshal(body, &payload)
+	r.payloads = append(r.payloads, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	f := newFixture(t)
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	webhooks := NewWebhookService(f.store, server.Client())
+
+	// Only the administrators manage the webhooks and the events are known
+	hook := models.Webhook{URL: server.URL, Events: " quotation.awarded  requirement.closed ", State: true}
+	expectError(t, webhooks.Create(models.User{ID: f.user.ID, Profile: "user"}, &hook), http.StatusForbidden, utilities.ErrForbidden)
+	unknown := models.Webhook{URL: "ftp://example.com", Events: "requirement.deleted"}
+	expectError(t, webhooks.Create(f.user, &unknown), http.StatusUnprocessableEntity, utilities.ErrValidation)
+	f.must(webhooks.Create(f.user, &hook))
+	if hook.Secret == "" || hook.Events != "quotation.awarded requirement.closed" {
+		t.Fatalf("unexpected webhook %+v", hook)
+	}
+	r.secret = hook.Secret
+
+	// The events not subscribed are not delivered
+	now := time.Now()
+	ids, err := webhooks.Enqueue(Event{Type: models.EventQuotationCreated, RequirementID: f.requirement.ID}, now)
+	f.must(err)
+	if len(ids) != 0 {
+		t.Fatalf("expected no deliveries, got %v", ids)
+	}
+	ids, err = webhooks.Enqueue(Event{Type: models.EventRequirementClosed, ActorID: f.user.ID, RequirementID: f.requirement.ID}, now)
+	f.must(err)
+	f.must(webhooks.Deliver(now, ids...))
+	if len(r.payloads) != 1 || r.payloads[0].Event != models.EventRequirementClosed || r.payloads[0].Data.RequirementID != f.requirement.ID {
+		t.Fatalf("unexpected payloads %+v", r.payloads)
+	}
+	delivery, err := f.store.Webhooks().GetDelivery(ids[0])
+	f.must(err)
+	if delivery.State != models.DeliverySucceeded || delivery.StatusCode != http.StatusNoContent || delivery.Attempts != 1 || delivery.NextAttempt != nil {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+
+	// A failed delivery waits twice as long after every attempt
+	r.failing = true
+	ids, err = webhooks.Enqueue(Event{Type: models.EventQuotationAwarded, RequirementID: f.requirement.ID}, now)
+	f.must(err)
+	at := now
+	for attempt := uint(1); attempt <= 3; attempt++ {
+		f.must(webhooks.Deliver(at))
+		delivery, err = f.store.Webhooks().GetDelivery(ids[0])
+		f.must(err)
+		wait := backoff(attempt)
+		if delivery.State != models.DeliveryPending || delivery.Attempts != attempt || delivery.StatusCode != http.StatusServiceUnavailable || !delivery.NextAttempt.Equal(at.Add(wait)) {
+			t.Fatalf("attempt %d: unexpected delivery %+v", attempt, delivery)
+		}
+		// Not sent again before its time
+		f.must(webhooks.Deliver(at.Add(wait - time.Second)))
+		at = at.Add(wait)
+	}
+
+	// The log shows the attempts, the redelivery is a new delivery
+	log, total, err := webhooks.Deliveries(f.user, hook.ID, repository.Page{})
+	f.must(err)
+	if total != 2 || log[0].ID != ids[0] {
+		t.Fatalf("unexpected log %d %+v", total, log)
+	}
+	r.failing = false
+	again, err := webhooks.Redeliver(f.user, ids[0], at)
+	f.must(err)
+	f.must(webhooks.Deliver(at, again.ID))
+	again, err = f.store.Webhooks().GetDelivery(again.ID)
+	f.must(err)
+	if again.State != models.DeliverySucceeded || again.Redelivery != ids[0] || len(r.payloads) != 2 {
+		t.Fatalf("unexpected redelivery %+v", again)
+	}
+
+	// A disabled webhook does not receive the events
+	hook.State = false
+	f.must(webhooks.Update(f.user, &hook))
+	ids, err = webhooks.Enqueue(Event{Type: models.EventRequirementClosed, RequirementID: f.requirement.ID}, at)
+	f.must(err)
+	if len(ids) != 0 {
+		t.Fatalf("expected no deliveries, got %v", ids)
+	}
+	f.must(webhooks.Delete(f.user, hook.ID))
+}
+
+func TestWebhookAnswer(t *testing.T) {
+	// Binary answer cut in the middle of a character
+	if answer := printable([]byte("ok\x00\xff\xc3")); answer != "ok�" || !utf8.ValidString(answer) {
+		t.Fatalf("unexpected answer %q", answer)
+	}
+}
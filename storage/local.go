@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Local files in a directory of the disk. Its links point to the api, that checks the
+// signature with Verify before serving the file
+type Local struct {
+	directory string
+	base      string // URL of the files in the api
+	secret    []byte
+	expires   time.Duration
+	now       func() time.Time
+}
+
+// NewLocal storage in the directory, the links are base + key and expire after the duration
+func NewLocal(directory string, base string, secret []byte, expires time.Duration) *Local {
+	return &Local{directory: directory, base: base, secret: secret, expires: expires, now: time.Now}
+}
+
+// path of the file of the key in the disk
+func (l *Local) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.directory, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(key string, content io.Reader, size int64, contentType string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	// The content is written to a temporary file, the file of the key is replaced when it is complete
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".put-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(key string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string, name string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(l.now().Add(l.expires).Unix(), 10)
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("expires", expires)
+	query.Set("signature", l.signature(key, name, expires))
+	return l.base + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// signature of the link of the file
+func (l *Local) signature(key string, name string, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	io.WriteString(mac, key+"\n"+name+"\n"+expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check the signature of a link of the file and that it did not expire,
+// ErrNotFound when the link is not valid
+func (l *Local) Verify(key string, name string, expires string, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > unix {
+		return ErrNotFound
+	}
+	if !hmac.Equal([]byte(signature), []byte(l.signature(key, name, expires))) {
+		return ErrNotFound
+	}
+	return nil
+}
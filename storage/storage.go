@@ -0,0 +1,59 @@
+// Package storage saves the uploaded and generated files in the local disk or in a S3 compatible
+// object storage. The files are identified by a key, a relative path separated by slashes
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+)
+
+// StaticPrefix of the files bundled with the application, as the default logo. They are
+// read from the disk and they are never removed
+const StaticPrefix = "static/"
+
+// ErrNotFound the file of the key does not exist
+var ErrNotFound = errors.New("storage: file not found")
+
+// ErrInvalidKey the key is not a clean relative path
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage where the files are saved
+type Storage interface {
+	// Put save the content with the size in bytes, it replaces the file of the key
+	Put(key string, content io.Reader, size int64, contentType string) error
+	// Get open the content of the file, the caller closes it. ErrNotFound when it does not exist
+	Get(key string) (io.ReadCloser, error)
+	// Delete the file, without error when it does not exist
+	Delete(key string) error
+	// URL signed link to download the file with the name until it expires, without authentication
+	URL(key string, name string) (string, error)
+}
+
+// checkKey the key must be relative, without empty, . or .. segments
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// ContentType type of the file by the extension of the key
+func ContentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Disposition header to download the file with the name
+func Disposition(name string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
@@ -0,0 +1,201 @@
+package utilities
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/paulantezana/requirement/logger"
+)
+
+// Error codes, the client must use the code and never the message to take decisions
+const (
+	ErrBadRequest        = "bad_request"
+	ErrUnauthorized      = "unauthorized"
+	ErrForbidden         = "forbidden"
+	ErrNotFound          = "not_found"
+	ErrRouteNotFound     = "route_not_found"
+	ErrMethodNotAllowed  = "method_not_allowed"
+	ErrConflict          = "conflict"
+	ErrDuplicated        = "duplicated"
+	ErrInUse             = "in_use"
+	ErrValidation        = "validation_failed"
+	ErrInternal          = "internal_error"
+	ErrInvalidLogin      = "invalid_credentials"
+	ErrUserDisabled      = "user_disabled"
+	ErrInvalidRecovery   = "invalid_recovery_key"
+	ErrWrongPassword     = "wrong_old_password"
+	ErrQuotationLimit    = "quotation_limit_reached"
+	ErrNoWinner          = "no_winner_quotation"
+	ErrRucRegistered     = "ruc_registered"
+	ErrRequirementFields = "requirement_without_requires"
+	ErrInvalidState      = "invalid_state_transition"
+	ErrNoQuotations      = "no_quotations"
+	ErrInvalidLink       = "invalid_link"
+	ErrQuotationPending  = "quotation_pending"
+	ErrAlreadyQuoted     = "already_quoted"
+	ErrSealed            = "sealed_bids"
+	ErrNoExchangeRate    = "no_exchange_rate"
+	ErrBudgetExceeded    = "budget_exceeded"
+	ErrAmountRange       = "amount_out_of_range"
+	ErrFileTooLarge      = "file_too_large"
+	ErrFileType          = "unsupported_file_type"
+)
+
+// Field validation codes used in FieldError.Code
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+)
+
+// FieldError validation error of a single field of the payload
+type FieldError struct {
+	Field   string        `json:"field"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Params  []interface{} `json:"-"`
+}
+
+// Error error model of the api, rendered by HTTPErrorHandler
+type Error struct {
+	Status   int
+	Code     string
+	Key      string        // Key of the localized message, by default the code
+	Params   []interface{} // Arguments of the localized message
+	Details  []FieldError
+	Internal error // Original error, only written in the logs
+}
+
+// NewError create a error with a status and a code of the catalog
+func NewError(status int, code string, params ...interface{}) *Error {
+	return &Error{
+		Status: status,
+		Code:   code,
+		Params: params,
+	}
+}
+
+// NewNotFoundError record with id not found
+func NewNotFoundError(id uint) *Error {
+	e := NewError(http.StatusNotFound, ErrNotFound, id)
+	e.Key = "not_found_id"
+	return e
+}
+
+// NewValidationError payload with invalid fields
+func NewValidationError(details ...FieldError) *Error {
+	e := NewError(http.StatusUnprocessableEntity, ErrValidation)
+	e.Details = details
+	return e
+}
+
+// NewInternalError unexpected error, the detail is only logged
+func NewInternalError(err error) *Error {
+	e := NewError(http.StatusInternalServerError, ErrInternal)
+	e.Internal = err
+	return e
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Internal)
+	}
+	return e.Code
+}
+
+// ErrorBody code and field details of a error response
+type ErrorBody struct {
+	Code    string       `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// ResponseError body of all the error responses
+type ResponseError struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Error   ErrorBody `json:"error"`
+}
+
+// ToError convert any error returned by a handler to a api error
+func ToError(err error) *Error {
+	switch e := err.(type) {
+	case *Error:
+		return e
+	case *echo.HTTPError:
+		code := ErrInternal
+		switch e.Code {
+		case http.StatusBadRequest:
+			code = ErrBadRequest
+		case http.StatusUnauthorized:
+			code = ErrUnauthorized
+		case http.StatusForbidden:
+			code = ErrForbidden
+		case http.StatusNotFound:
+			code = ErrRouteNotFound
+		case http.StatusMethodNotAllowed:
+			code = ErrMethodNotAllowed
+		}
+		return &Error{Status: e.Code, Code: code, Internal: err}
+	case *pq.Error:
+		switch e.Code.Name() {
+		case "unique_violation":
+			return &Error{Status: http.StatusConflict, Code: ErrDuplicated, Internal: err}
+		case "foreign_key_violation":
+			return &Error{Status: http.StatusConflict, Code: ErrInUse, Internal: err}
+		}
+	case sqlite3.Error:
+		switch e.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &Error{Status: http.StatusConflict, Code: ErrDuplicated, Internal: err}
+		case sqlite3.ErrConstraintForeignKey:
+			return &Error{Status: http.StatusConflict, Code: ErrInUse, Internal: err}
+		}
+	case gorm.Errors:
+		if len(e) > 0 {
+			return ToError(e[0])
+		}
+	}
+	if err == gorm.ErrRecordNotFound {
+		return &Error{Status: http.StatusNotFound, Code: ErrNotFound, Internal: err}
+	}
+
+	return NewInternalError(err)
+}
+
+// HTTPErrorHandler render the errors returned by the handlers
+func HTTPErrorHandler(err error, c echo.Context) {
+	e := ToError(err)
+	if c.Response().Committed {
+		return
+	}
+
+	lang := Language(c)
+	key := e.Key
+	if key == "" {
+		key = e.Code
+	}
+	details := make([]FieldError, len(e.Details))
+	for i, d := range e.Details {
+		d.Message = Message(lang, d.Code, d.Params...)
+		details[i] = d
+	}
+
+	if c.Request().Method == echo.HEAD {
+		err = c.NoContent(e.Status)
+	} else {
+		err = c.JSON(e.Status, ResponseError{
+			Message: Message(lang, key, e.Params...),
+			Error: ErrorBody{
+				Code:    e.Code,
+				Details: details,
+			},
+		})
+	}
+	if err != nil {
+		logger.FromContext(c).WithError(err).Errorf("error response not sent")
+	}
+}
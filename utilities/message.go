@@ -0,0 +1,365 @@
+package utilities
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/paulantezana/requirement/models"
+)
+
+// DefaultLanguage language used when the client does not ask for a supported one
+const DefaultLanguage = "es"
+
+// Keys of the messages of the successful responses
+const (
+	MsgWelcome           = "welcome"
+	MsgLocaleChanged     = "locale_changed"
+	MsgPasswordChanged   = "password_changed"
+	MsgPasswordReset     = "password_reset"
+	MsgUserCreated       = "user_created"
+	MsgUserUpdated       = "user_updated"
+	MsgUserDeleted       = "user_deleted"
+	MsgAvatarUploaded    = "avatar_uploaded"
+	MsgCostCenterCreated = "cost_center_created"
+	MsgProductCreated    = "product_created"
+	MsgCategoryCreated   = "category_created"
+	MsgCatalogSaved      = "catalog_saved"
+	MsgProviderCreated   = "provider_created"
+	MsgProvidersImported = "providers_imported"
+	MsgRatesSaved        = "exchange_rates_saved"
+	MsgRequirementSaved  = "requirement_saved"
+	MsgTemplateSaved     = "template_saved"
+	MsgAttachmentSaved   = "attachment_saved"
+	MsgBidsOpened        = "bids_opened"
+	MsgRfqSent           = "rfq_sent"
+	MsgQuotationSaved    = "quotation_saved"
+	MsgQuotationAwarded  = "quotation_awarded"
+	MsgQuotationSent     = "quotation_sent"
+	MsgQuotationDeclined = "quotation_declined"
+)
+
+// Keys of the texts of the notifications, the parameter is the name of the requirement
+const (
+	NoticeRequirementCreated  = "notice_requirement_created"
+	NoticeQuotationCreated    = "notice_quotation_created"
+	NoticeQuotationAwarded    = "notice_quotation_awarded"
+	NoticeRequirementRejected = "notice_requirement_rejected"
+	NoticeRequirementClosed   = "notice_requirement_closed"
+	NoticeCommentMention      = "notice_comment_mention"
+)
+
+// Keys of the titles and the headers of the excel documents
+const (
+	XlsxRequirements  = "xlsx_requirements"
+	XlsxAwarded       = "xlsx_awarded"
+	XlsxRfq           = "xlsx_rfq"
+	XlsxNumber        = "xlsx_number"
+	XlsxRequirement   = "xlsx_requirement"
+	XlsxPlace         = "xlsx_place"
+	XlsxDestination   = "xlsx_destination"
+	XlsxDeliveryPlace = "xlsx_delivery_place"
+	XlsxDeadline      = "xlsx_deadline"
+	XlsxEmissionDate  = "xlsx_emission_date"
+	XlsxState         = "xlsx_state"
+	XlsxProvider      = "xlsx_provider"
+	XlsxProduct       = "xlsx_product"
+	XlsxAmount        = "xlsx_amount"
+	XlsxUnitMeasure   = "xlsx_unit_measure"
+	XlsxObservation   = "xlsx_observation"
+	XlsxUnitPrice     = "xlsx_unit_price"
+	XlsxCurrency      = "xlsx_currency"
+	XlsxSubtotal      = "xlsx_subtotal"
+	XlsxTax           = "xlsx_tax"
+	XlsxTotal         = "xlsx_total"
+	XlsxRate          = "xlsx_rate"
+	XlsxBaseCurrency  = "xlsx_base_currency"
+	XlsxConverted     = "xlsx_converted"
+)
+
+// Keys of the names of the files downloaded
+const (
+	FileRequirements = "file_requirements"
+	FileAwarded      = "file_awarded"
+	FileRfq          = "file_rfq"
+)
+
+// messages catalog of the messages by language and key
+var messages = map[string]map[string]string{
+	"es": {
+		ErrBadRequest:        "La solicitud no tiene un formato válido",
+		ErrUnauthorized:      "Necesita iniciar sesión para realizar esta acción",
+		ErrForbidden:         "No tiene permiso para realizar esta acción",
+		ErrNotFound:          "No se encontró el registro",
+		"not_found_id":       "No se encontró el registro con id %d",
+		ErrRouteNotFound:     "El recurso solicitado no existe",
+		ErrMethodNotAllowed:  "Método no permitido",
+		ErrConflict:          "La operación entra en conflicto con el estado actual del registro",
+		ErrDuplicated:        "Ya existe un registro con los mismos datos",
+		ErrInUse:             "El registro está siendo usado por otros registros",
+		ErrValidation:        "Los datos enviados no son válidos",
+		ErrInternal:          "Ocurrió un error inesperado, vuelva a intentarlo más tarde",
+		ErrInvalidLogin:      "El nombre de usuario o contraseña es incorecta",
+		ErrUserDisabled:      "El usuario está deshabilitado",
+		ErrInvalidRecovery:   "El número %s que ingresaste no coincide con tu código de seguridad. Vuelve a intentarlo",
+		ErrWrongPassword:     "La contraseña antigua es incorrecta",
+		ErrQuotationLimit:    "A alcanzado el número maximo de cotizaciones",
+		ErrNoWinner:          "El requerimiento con id %d no tiene una cotización ganadora",
+		ErrRucRegistered:     "El número de RUC ya esta registrado",
+		ErrRequirementFields: "Agregue al menos un producto para crear el requerimiento",
+		ErrInvalidState:      "El requerimiento con id %d no permite esta operación en su estado actual",
+		ErrNoQuotations:      "El requerimiento con id %d no tiene cotizaciones",
+		ErrInvalidLink:       "El enlace no es válido o ya expiró",
+		ErrQuotationPending:  "La cotización con id %d aún no fue aprobada",
+		ErrAlreadyQuoted:     "Ya envió una cotización para este requerimiento",
+		ErrSealed:            "Las cotizaciones del requerimiento con id %d están en sobre cerrado hasta la apertura",
+		ErrNoExchangeRate:    "No existe el tipo de cambio de %s para el %s",
+		ErrBudgetExceeded:    "El monto adjudicado %s excede el presupuesto disponible %s del centro de costo",
+		ErrAmountRange:       "Los importes de la cotización exceden el máximo permitido",
+		ErrFileTooLarge:      "El archivo excede el tamaño máximo de %d MB",
+		ErrFileType:          "El tipo de archivo no está permitido, adjunte PDF, imágenes, documentos de Office o texto",
+		FieldRequired:        "El campo es obligatorio",
+		FieldInvalid:         "El valor del campo no es válido",
+		FieldMin:             "El valor debe ser como mínimo %s",
+		FieldMax:             "El valor debe ser como máximo %s",
+		FieldGt:              "El valor debe ser mayor que %s",
+		FieldEmail:           "El correo electrónico no es válido",
+		FieldRUC:             "El número de RUC no es válido",
+		FieldDNI:             "El número de DNI debe tener 8 dígitos",
+		FieldOneOf:           "El valor debe ser uno de: %s",
+		FieldCurrency:        "La moneda debe ser un código ISO de 3 letras, por ejemplo PEN",
+		FieldURL:             "La dirección debe empezar con http:// o https://",
+		FieldPast:            "La fecha no puede ser anterior a hoy",
+		FieldRef:             "El registro con id %d no existe",
+		FieldState:           "El registro con id %d está deshabilitado",
+		FieldForeign:         "El registro con id %d pertenece a otro requerimiento",
+		FieldRepeated:        "El registro con id %d está repetido",
+		FieldMissing:         "Falta la línea del registro con id %d, cotícela o márquela como no cotizada",
+		FieldNoRate:          "No existe el tipo de cambio de %s para el %s",
+
+		MsgWelcome:           "Bienvenido al sistema %s",
+		MsgLocaleChanged:     "Los mensajes se mostrarán en español",
+		MsgPasswordChanged:   "La contraseña del usuario %s se cambió exitosamente",
+		MsgPasswordReset:     "La contraseña del usuario se restableció exitosamente, su nueva contraseña es %s",
+		MsgUserCreated:       "El usuario %s se registró exitosamente",
+		MsgUserUpdated:       "Los datos del usuario %s se actualizaron correctamente",
+		MsgUserDeleted:       "El usuario %s se eliminó correctamente",
+		MsgAvatarUploaded:    "El avatar del usuario %s se subió correctamente",
+		MsgCostCenterCreated: "El centro de costo %s se registró exitosamente",
+		MsgProductCreated:    "El producto %s se registró exitosamente",
+		MsgCategoryCreated:   "La categoría %s se registró exitosamente",
+		MsgCatalogSaved:      "El catálogo del proveedor se guardó exitosamente",
+		MsgProviderCreated:   "El proveedor %s se registró exitosamente",
+		MsgProvidersImported: "Se guardaron %d registros en la base de datos",
+		MsgRatesSaved:        "Se guardaron %d tipos de cambio en la base de datos",
+		MsgRequirementSaved:  "El requerimiento %s se registró exitosamente",
+		MsgTemplateSaved:     "La plantilla %s se registró exitosamente",
+		MsgAttachmentSaved:   "El archivo %s se adjuntó exitosamente",
+		MsgBidsOpened:        "La apertura de las cotizaciones del requerimiento con id %d se realizó exitosamente",
+		MsgRfqSent:           "Se enviaron %d solicitudes de cotización",
+		MsgQuotationSaved:    "La cotización %d se registró exitosamente",
+		MsgQuotationAwarded:  "La adjudicación de la cotización con id %d se realizó exitosamente",
+		MsgQuotationSent:     "Su cotización se envió exitosamente",
+		MsgQuotationDeclined: "Gracias por su respuesta",
+
+		NoticeRequirementCreated:  "Se registró el requerimiento %s",
+		NoticeQuotationCreated:    "El requerimiento %s recibió una cotización",
+		NoticeQuotationAwarded:    "El requerimiento %s fue adjudicado",
+		NoticeRequirementRejected: "El requerimiento %s fue rechazado",
+		NoticeRequirementClosed:   "El requerimiento %s fue cerrado",
+		NoticeCommentMention:      "Te mencionaron en un comentario del requerimiento %s",
+
+		XlsxRequirements:  "Requerimientos",
+		XlsxAwarded:       "Cotizaciones adjudicadas",
+		XlsxRfq:           "SOLICITUD DE COTIZACIÓN",
+		XlsxNumber:        "N°",
+		XlsxRequirement:   "Requerimiento",
+		XlsxPlace:         "Lugar",
+		XlsxDestination:   "Destino",
+		XlsxDeliveryPlace: "Lugar de entrega",
+		XlsxDeadline:      "Fecha límite",
+		XlsxEmissionDate:  "Fecha emisión",
+		XlsxState:         "Estado",
+		XlsxProvider:      "Proveedor",
+		XlsxProduct:       "Producto",
+		XlsxAmount:        "Cantidad",
+		XlsxUnitMeasure:   "Unidad de medida",
+		XlsxObservation:   "Observación",
+		XlsxUnitPrice:     "Precio unitario",
+		XlsxCurrency:      "Moneda",
+		XlsxSubtotal:      "Subtotal",
+		XlsxTax:           "IGV",
+		XlsxTotal:         "Total",
+		XlsxRate:          "Tipo de cambio",
+		XlsxBaseCurrency:  "Moneda base",
+		XlsxConverted:     "Total convertido",
+		FileRequirements:  "requerimientos.xlsx",
+		FileAwarded:       "cotizaciones-adjudicadas.xlsx",
+		FileRfq:           "solicitud-cotizacion-%d.xlsx",
+	},
+	"en": {
+		ErrBadRequest:        "The request is malformed",
+		ErrUnauthorized:      "You must sign in to perform this action",
+		ErrForbidden:         "You are not allowed to perform this action",
+		ErrNotFound:          "Record not found",
+		"not_found_id":       "Record with id %d not found",
+		ErrRouteNotFound:     "The requested resource does not exist",
+		ErrMethodNotAllowed:  "Method not allowed",
+		ErrConflict:          "The operation conflicts with the current state of the record",
+		ErrDuplicated:        "A record with the same data already exists",
+		ErrInUse:             "The record is referenced by other records",
+		ErrValidation:        "The submitted data is not valid",
+		ErrInternal:          "An unexpected error occurred, try again later",
+		ErrInvalidLogin:      "The user name or password is incorrect",
+		ErrUserDisabled:      "The user is disabled",
+		ErrInvalidRecovery:   "The number %s does not match your security code. Try again",
+		ErrWrongPassword:     "The old password is incorrect",
+		ErrQuotationLimit:    "The maximum number of quotations has been reached",
+		ErrNoWinner:          "The requirement with id %d has no winner quotation",
+		ErrRucRegistered:     "The RUC number is already registered",
+		ErrRequirementFields: "Add at least one product to create the requirement",
+		ErrInvalidState:      "The requirement with id %d does not allow this operation in its current state",
+		ErrNoQuotations:      "The requirement with id %d has no quotations",
+		ErrInvalidLink:       "The link is not valid or has expired",
+		ErrQuotationPending:  "The quotation with id %d has not been approved yet",
+		ErrAlreadyQuoted:     "You already sent a quotation for this requirement",
+		ErrSealed:            "The quotations of the requirement with id %d are sealed until the bid opening",
+		ErrNoExchangeRate:    "There is no exchange rate of %s for %s",
+		ErrBudgetExceeded:    "The awarded amount %s exceeds the available budget %s of the cost center",
+		ErrAmountRange:       "The amounts of the quotation exceed the allowed maximum",
+		ErrFileTooLarge:      "The file exceeds the maximum size of %d MB",
+		ErrFileType:          "The file type is not allowed, attach PDF, images, Office documents or text",
+		FieldRequired:        "The field is required",
+		FieldInvalid:         "The value of the field is not valid",
+		FieldMin:             "The value must be at least %s",
+		FieldMax:             "The value must be at most %s",
+		FieldGt:              "The value must be greater than %s",
+		FieldEmail:           "The email address is not valid",
+		FieldRUC:             "The RUC number is not valid",
+		FieldDNI:             "The DNI number must have 8 digits",
+		FieldOneOf:           "The value must be one of: %s",
+		FieldCurrency:        "The currency must be a 3 letter ISO code, for example PEN",
+		FieldURL:             "The address must start with http:// or https://",
+		FieldPast:            "The date cannot be earlier than today",
+		FieldRef:             "The record with id %d does not exist",
+		FieldState:           "The record with id %d is disabled",
+		FieldForeign:         "The record with id %d belongs to another requirement",
+		FieldRepeated:        "The record with id %d is repeated",
+		FieldMissing:         "The line of the record with id %d is missing, quote it or mark it as not quoted",
+		FieldNoRate:          "There is no exchange rate of %s for %s",
+
+		MsgWelcome:           "Welcome to the system %s",
+		MsgLocaleChanged:     "The messages will be shown in English",
+		MsgPasswordChanged:   "The password of the user %s was changed successfully",
+		MsgPasswordReset:     "The password of the user was reset successfully, the new password is %s",
+		MsgUserCreated:       "The user %s was registered successfully",
+		MsgUserUpdated:       "The data of the user %s was updated successfully",
+		MsgUserDeleted:       "The user %s was deleted successfully",
+		MsgAvatarUploaded:    "The avatar of the user %s was uploaded successfully",
+		MsgCostCenterCreated: "The cost center %s was registered successfully",
+		MsgProductCreated:    "The product %s was registered successfully",
+		MsgCategoryCreated:   "The category %s was registered successfully",
+		MsgCatalogSaved:      "The catalog of the provider was saved successfully",
+		MsgProviderCreated:   "The provider %s was registered successfully",
+		MsgProvidersImported: "%d records were saved in the database",
+		MsgRatesSaved:        "%d exchange rates were saved in the database",
+		MsgRequirementSaved:  "The requirement %s was registered successfully",
+		MsgTemplateSaved:     "The template %s was registered successfully",
+		MsgAttachmentSaved:   "The file %s was attached successfully",
+		MsgBidsOpened:        "The quotations of the requirement with id %d were opened successfully",
+		MsgRfqSent:           "%d requests for quotation were sent",
+		MsgQuotationSaved:    "The quotation %d was registered successfully",
+		MsgQuotationAwarded:  "The quotation with id %d was awarded successfully",
+		MsgQuotationSent:     "Your quotation was sent successfully",
+		MsgQuotationDeclined: "Thank you for your answer",
+
+		NoticeRequirementCreated:  "The requirement %s was registered",
+		NoticeQuotationCreated:    "The requirement %s received a quotation",
+		NoticeQuotationAwarded:    "The requirement %s was awarded",
+		NoticeRequirementRejected: "The requirement %s was rejected",
+		NoticeRequirementClosed:   "The requirement %s was closed",
+		NoticeCommentMention:      "You were mentioned in a comment of the requirement %s",
+
+		XlsxRequirements:  "Requirements",
+		XlsxAwarded:       "Awarded quotations",
+		XlsxRfq:           "REQUEST FOR QUOTATION",
+		XlsxNumber:        "No.",
+		XlsxRequirement:   "Requirement",
+		XlsxPlace:         "Place",
+		XlsxDestination:   "Destination",
+		XlsxDeliveryPlace: "Delivery place",
+		XlsxDeadline:      "Deadline",
+		XlsxEmissionDate:  "Emission date",
+		XlsxState:         "State",
+		XlsxProvider:      "Provider",
+		XlsxProduct:       "Product",
+		XlsxAmount:        "Quantity",
+		XlsxUnitMeasure:   "Unit of measure",
+		XlsxObservation:   "Observation",
+		XlsxUnitPrice:     "Unit price",
+		XlsxCurrency:      "Currency",
+		XlsxSubtotal:      "Subtotal",
+		XlsxTax:           "IGV",
+		XlsxTotal:         "Total",
+		XlsxRate:          "Exchange rate",
+		XlsxBaseCurrency:  "Base currency",
+		XlsxConverted:     "Converted total",
+		FileRequirements:  "requirements.xlsx",
+		FileAwarded:       "awarded-quotations.xlsx",
+		FileRfq:           "request-for-quotation-%d.xlsx",
+	},
+}
+
+// Supported check the language has a catalog
+func Supported(lang string) bool {
+	_, ok := messages[lang]
+	return ok
+}
+
+// Language get the language of the request, the locale of the signed in user or else the first
+// supported language of the Accept-Language header
+func Language(c echo.Context) string {
+	if token, ok := c.Get("user").(*jwt.Token); ok {
+		if claims, ok := token.Claims.(*Claim); ok && Supported(claims.User.Locale) {
+			return claims.User.Locale
+		}
+	}
+	return AcceptLanguage(c.Request().Header.Get("Accept-Language"))
+}
+
+// AcceptLanguage first supported language of the Accept-Language header
+func AcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if Supported(lang) {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// UserLanguage language of the messages sent to the user out of its requests, the emails and the
+// notifications. The fallback is used when the user has no locale
+func UserLanguage(user models.User, fallback string) string {
+	if Supported(user.Locale) {
+		return user.Locale
+	}
+	return fallback
+}
+
+// Message get the message of the key in the language, formatted with params
+func Message(lang string, key string, params ...interface{}) string {
+	format, ok := messages[lang][key]
+	if !ok {
+		format, ok = messages[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(params) == 0 || !strings.Contains(format, "%") {
+		return format
+	}
+	return fmt.Sprintf(format, params...)
+}
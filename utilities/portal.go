@@ -0,0 +1,50 @@
+package utilities
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/paulantezana/requirement/config"
+	"net/http"
+	"time"
+)
+
+// PortalClaim claims of the link sent to a provider to quote a single requirement
+type PortalClaim struct {
+	ProviderID    uint `json:"provider_id"`
+	RequirementID uint `json:"requirement_id"`
+	UserID        uint `json:"user_id"` // Buyer that invited the provider
+	jwt.StandardClaims
+}
+
+// portalKey the links are signed with their own key, so they are never valid as a session token
+func portalKey() []byte {
+	return []byte("portal:" + config.GetConfig().Server.Key)
+}
+
+// GeneratePortalToken sign the link of the provider, valid until expires
+func GeneratePortalToken(claim PortalClaim, expires time.Time) (string, error) {
+	claim.StandardClaims = jwt.StandardClaims{
+		ExpiresAt: expires.Unix(),
+		IssuedAt:  time.Now().Unix(),
+		Issuer:    "paulantezana",
+		Subject:   "portal",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claim)
+	return token.SignedString(portalKey())
+}
+
+// ParsePortalToken validate the signature and expiration of the link
+func ParsePortalToken(token string) (PortalClaim, error) {
+	claim := PortalClaim{}
+	t, err := jwt.ParseWithClaims(token, &claim, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return portalKey(), nil
+	})
+	if err != nil || !t.Valid || claim.Subject != "portal" || claim.ProviderID == 0 || claim.RequirementID == 0 {
+		e := NewError(http.StatusUnauthorized, ErrInvalidLink)
+		e.Internal = err
+		return PortalClaim{}, e
+	}
+	return claim, nil
+}
@@ -23,3 +23,74 @@ type RequestQuotation struct {
 	ID            uint `json:"id"`
 	Type          uint `json:"query"`
 }
+
+// RequestComparative comparative table of a requirement, with the savings of the revisions optionally
+type RequestComparative struct {
+	ID      uint `json:"id"`
+	Savings bool `json:"savings"`
+}
+
+// RequestRevision revisions of a quotation compared by number
+type RequestRevision struct {
+	ID   uint `json:"id"`
+	From uint `json:"from"`
+	To   uint `json:"to"`
+}
+
+// RequestInvitation providers invited to quote a requirement in the portal
+type RequestInvitation struct {
+	RequirementID uint   `json:"requirement_id"`
+	Providers     []uint `json:"providers"`
+}
+
+// RequestBudget execution of the budgets of a year, the current year when it is 0
+type RequestBudget struct {
+	Year int `json:"year"`
+}
+
+// RequestPortal link of the provider, with the quotation when it is sent
+type RequestPortal struct {
+	Token string `json:"token"`
+}
+
+// RequestTemplate requirement saved as a template with the name, or the template to create a requirement
+type RequestTemplate struct {
+	ID            uint   `json:"id"`
+	RequirementID uint   `json:"requirement_id"`
+	Name          string `json:"name"`
+}
+
+// RequestAttachment attachments of a record: requirement, quotation, provider or comment
+type RequestAttachment struct {
+	Owner   string `json:"owner"`
+	OwnerID uint   `json:"owner_id"`
+}
+
+// RequestComment comments of a record: requirement or quotation
+type RequestComment struct {
+	Owner   string `json:"owner"`
+	OwnerID uint   `json:"owner_id"`
+}
+
+// RequestNotification page of the notifications of the user, only the unread when Unread is true
+type RequestNotification struct {
+	Unread      bool   `json:"unread"`
+	CurrentPage uint   `json:"current_page"`
+	Limit       uint   `json:"limit"`
+	IDs         []uint `json:"ids"` // Notifications to mark as read, all when it is empty
+}
+
+// RequestWebhook page of the deliveries of a webhook, or the delivery sent again
+type RequestWebhook struct {
+	ID          uint `json:"id"`
+	CurrentPage uint `json:"current_page"`
+	Limit       uint `json:"limit"`
+}
+
+// RequestEmail page of the outbox filtered by the state and the recipient of the emails
+type RequestEmail struct {
+	State       string `json:"state"`
+	Search      string `json:"search"`
+	CurrentPage uint   `json:"current_page"`
+	Limit       uint   `json:"limit"`
+}
@@ -0,0 +1,32 @@
+package utilities
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/paulantezana/requirement/config"
+	"github.com/paulantezana/requirement/models"
+	"time"
+)
+
+// StreamTokenLife the token of the stream goes in the query, it is only valid to connect
+const StreamTokenLife = time.Minute
+
+// StreamKey the stream tokens are signed with their own key, so they are never valid as a
+// session token and the session token is never valid in the query of the stream
+func StreamKey() []byte {
+	return []byte("stream:" + config.GetConfig().Server.Key)
+}
+
+// GenerateStreamToken sign a short lived token of the user to connect to the stream
+func GenerateStreamToken(user models.User) (string, error) {
+	claims := &Claim{
+		models.User{ID: user.ID, UserName: user.UserName, Profile: user.Profile, Locale: user.Locale},
+		jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(StreamTokenLife).Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    "paulantezana",
+			Subject:   "stream",
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(StreamKey())
+}
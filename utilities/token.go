@@ -4,7 +4,6 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/paulantezana/requirement/config"
 	"github.com/paulantezana/requirement/models"
-	"log"
 	"time"
 )
 
@@ -15,7 +14,7 @@ type Claim struct {
 }
 
 // GenerateJWT generate token custom claims
-func GenerateJWT(user models.User) string {
+func GenerateJWT(user models.User) (string, error) {
 	// Set custom claims
 	claims := &Claim{
 		user,
@@ -29,9 +28,5 @@ func GenerateJWT(user models.User) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Generate encoded token and send it as response.
-	result, err := token.SignedString([]byte(config.GetConfig().Server.Key))
-	if err != nil {
-		log.Fatal("No se pudo firmar el token")
-	}
-	return result
+	return token.SignedString([]byte(config.GetConfig().Server.Key))
 }
@@ -0,0 +1,231 @@
+package utilities
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validation codes of the rules supported in the validate tag
+// required      value different of the zero value
+// min=n, max=n  numbers: value range, strings: length, slices: number of items
+// gt=n          number greater than n
+// email         valid email address
+// ruc           Peruvian taxpayer number, 11 digits with check digit
+// dni           Peruvian identity document, 8 digits
+// oneof=a b     value is one of the list separated by spaces
+// currency      ISO 4217 code of 3 uppercase letters
+// url           absolute http or https address
+// dive          validate every item of the slice
+const (
+	FieldMin      = "min"
+	FieldMax      = "max"
+	FieldGt       = "gt"
+	FieldEmail    = "email"
+	FieldRUC      = "ruc"
+	FieldDNI      = "dni"
+	FieldOneOf    = "oneof"
+	FieldCurrency = "currency"
+	FieldURL      = "url"
+	FieldPast     = "past_date"
+	FieldRef      = "not_exist"
+	FieldState    = "inactive"
+
+	FieldForeign  = "other_requirement" // The require belongs to other requirement
+	FieldRepeated = "repeated"
+	FieldMissing  = "not_covered" // A require without line, it must be quoted or marked as not quoted
+	FieldNoRate   = "no_rate"     // Currency without exchange rate on the date
+)
+
+var (
+	emailRegexp    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	dniRegexp      = regexp.MustCompile(`^[0-9]{8}$`)
+	rucRegexp      = regexp.MustCompile(`^(10|15|17|20)[0-9]{9}$`)
+	currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Validator validate struct tags, used as echo validator
+type Validator struct{}
+
+// Validate implements echo.Validator
+func (v *Validator) Validate(i interface{}) error {
+	if details := ValidateStruct(i); len(details) > 0 {
+		return NewValidationError(details...)
+	}
+	return nil
+}
+
+// ValidateStruct return all the violations of the validate tags of i
+func ValidateStruct(i interface{}) []FieldError {
+	details := make([]FieldError, 0)
+	validateValue(reflect.ValueOf(i), "", &details)
+	return details
+}
+
+func validateValue(v reflect.Value, prefix string, details *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + jsonName(sf)
+		fv := v.Field(i)
+
+		for _, rule := range strings.Split(tag, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "dive" {
+				if fv.Kind() == reflect.Slice {
+					for j := 0; j < fv.Len(); j++ {
+						validateValue(fv.Index(j), fmt.Sprintf("%s[%d].", name, j), details)
+					}
+				}
+				continue
+			}
+
+			key, param := rule, ""
+			if k := strings.Index(rule, "="); k >= 0 {
+				key, param = rule[:k], rule[k+1:]
+			}
+			if !checkRule(fv, key, param) {
+				fe := FieldError{Field: name, Code: key}
+				if param != "" {
+					fe.Params = []interface{}{param}
+				}
+				*details = append(*details, fe)
+				break
+			}
+		}
+	}
+}
+
+// jsonName name of the field in the payload
+func jsonName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func checkRule(v reflect.Value, key string, param string) bool {
+	switch key {
+	case FieldRequired:
+		return !isZero(v)
+	case FieldMin, FieldMax, FieldGt:
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := measure(v)
+		if !ok {
+			return true
+		}
+		switch key {
+		case FieldMin:
+			return n >= limit
+		case FieldMax:
+			return n <= limit
+		default:
+			return n > limit
+		}
+	case FieldEmail:
+		return v.String() == "" || emailRegexp.MatchString(v.String())
+	case FieldDNI:
+		return v.String() == "" || dniRegexp.MatchString(v.String())
+	case FieldRUC:
+		return v.String() == "" || ValidRUC(v.String())
+	case FieldCurrency:
+		return v.String() == "" || currencyRegexp.MatchString(v.String())
+	case FieldURL:
+		if v.String() == "" {
+			return true
+		}
+		u, err := url.Parse(v.String())
+		return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	case FieldOneOf:
+		s := fmt.Sprint(v.Interface())
+		if s == "" {
+			return true
+		}
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true
+			}
+		}
+		return false
+	}
+	panic(fmt.Sprintf("utilities: unknown validation rule %q", key))
+}
+
+// number value with exact arithmetic like the decimals, compared by its approximation
+type number interface {
+	Float64() float64
+}
+
+// measure the value compared by min, max and gt
+func measure(v reflect.Value) (float64, bool) {
+	if n, ok := v.Interface().(number); ok {
+		return n.Float64(), true
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+func isZero(v reflect.Value) bool {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// ValidRUC validate format and check digit of a RUC number
+func ValidRUC(ruc string) bool {
+	if !rucRegexp.MatchString(ruc) {
+		return false
+	}
+	weights := []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(ruc[i]-'0') * w
+	}
+	check := 11 - sum%11
+	if check >= 10 {
+		check -= 10
+	}
+	return check == int(ruc[10]-'0')
+}